@@ -1,8 +1,7 @@
 package colfer
 
 import (
-	"os"
-	"path/filepath"
+	"bytes"
 	"strings"
 	"text/template"
 
@@ -21,11 +20,29 @@ func IsCKeyword(s string) bool {
 	return false
 }
 
-// GenerateC writes the code into file "Colfer.h" and "Colfer.c".
-func GenerateC(basedir string, packages Packages) error {
+// cNatives sets the C names and datatypes. Packages without limit directives
+// share colfer_size_max and colfer_list_max.
+func cNatives(packages Packages) {
 	for _, p := range packages {
+		p.SizeMaxNative = "colfer_size_max"
+		if p.SizeMaxDirective {
+			p.SizeMaxNative = name.SnakeCase(p.Name + "_colfer_size_max")
+		}
+		p.ListMaxNative = "colfer_list_max"
+		if p.ListMaxDirective {
+			p.ListMaxNative = name.SnakeCase(p.Name + "_colfer_list_max")
+		}
+
 		for _, s := range p.Structs {
 			s.NameNative = name.SnakeCase(p.Name + "_" + s.Name)
+			s.SizeMaxNative = p.SizeMaxNative
+			if s.SizeMax != "" {
+				s.SizeMaxNative = s.NameNative + "_colfer_size_max"
+			}
+			s.ListMaxNative = p.ListMaxNative
+			if s.ListMax != "" && s.HasList() {
+				s.ListMaxNative = s.NameNative + "_colfer_list_max"
+			}
 
 			for _, f := range s.Fields {
 				f.NameNative = name.SnakeCase(f.Name)
@@ -50,30 +67,50 @@ func GenerateC(basedir string, packages Packages) error {
 			}
 		}
 	}
+}
 
-	if err := os.MkdirAll(basedir, os.ModeDir|os.ModePerm); err != nil {
-		return err
-	}
-
-	f, err := os.Create(filepath.Join(basedir, "Colfer.h"))
+// GenerateC writes the code into file "Colfer.h" and "Colfer.c".
+func GenerateC(basedir string, packages Packages) error {
+	sources, err := CSources(packages)
 	if err != nil {
 		return err
 	}
-	if err := template.Must(template.New("C-header").Parse(cHeaderTemplate)).Execute(f, packages); err != nil {
-		return err
-	}
-	if err = f.Close(); err != nil {
-		return err
+	return sources.Write(basedir)
+}
+
+// cSharedLimit returns the first package without a directive for the limit,
+// if any.
+func cSharedLimit(packages Packages, directive func(*Package) bool) *Package {
+	for _, p := range packages {
+		if !directive(p) {
+			return p
+		}
 	}
+	return nil
+}
 
-	f, err = os.Create(filepath.Join(basedir, "Colfer.c"))
-	if err != nil {
-		return err
+var cFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"sharedSizeMax": func(packages Packages) *Package {
+		return cSharedLimit(packages, func(p *Package) bool { return p.SizeMaxDirective })
+	},
+	"sharedListMax": func(packages Packages) *Package {
+		return cSharedLimit(packages, func(p *Package) bool { return p.ListMaxDirective })
+	},
+}
+
+// CSources returns the code of GenerateC in memory.
+func CSources(packages Packages) (Sources, error) {
+	cNatives(packages)
+
+	var header, code bytes.Buffer
+	if err := template.Must(template.New("C-header").Funcs(cFuncs).Parse(cHeaderTemplate)).Execute(&header, packages); err != nil {
+		return nil, err
 	}
-	if err := template.Must(template.New("C").Parse(cTemplate)).Execute(f, packages); err != nil {
-		return err
+	if err := template.Must(template.New("C").Funcs(cFuncs).Parse(cTemplate)).Execute(&code, packages); err != nil {
+		return nil, err
 	}
-	return f.Close()
+	return Sources{"Colfer.h": header.Bytes(), "Colfer.c": code.Bytes()}, nil
 }
 
 const cHeaderTemplate = `// Code generated by colf(1); DO NOT EDIT.
@@ -98,13 +135,46 @@ const cHeaderTemplate = `// Code generated by colf(1); DO NOT EDIT.
 #ifdef __cplusplus
 extern "C" {
 #endif
+{{- with sharedSizeMax .}}
 
-
-// colfer_size_max is the upper limit for serial octet sizes.
+// colfer_size_max is the upper limit for serial octet sizes of the packages
+// without a size directive.
 extern size_t colfer_size_max;
+{{- end}}
+{{- with sharedListMax .}}
 
-// colfer_list_max is the upper limit for the number of elements in a list.
+// colfer_list_max is the upper limit for the number of elements in a list of
+// the packages without a list directive.
 extern size_t colfer_list_max;
+{{- end}}
+{{- range .}}
+{{- if .SizeMaxDirective}}
+
+// {{.SizeMaxNative}} is the upper limit for serial octet sizes of package
+// {{.Name}}.
+extern size_t {{.SizeMaxNative}};
+{{- end}}
+{{- if .ListMaxDirective}}
+
+// {{.ListMaxNative}} is the upper limit for the number of elements in a list
+// of package {{.Name}}.
+extern size_t {{.ListMaxNative}};
+{{- end}}
+{{- range .Structs}}
+{{- if .SizeMax}}
+
+// {{.SizeMaxNative}} is the upper limit for serial octet sizes of
+// {{.NameNative}}.
+extern size_t {{.SizeMaxNative}};
+{{- end}}
+{{- if and .ListMax .HasList}}
+
+// {{.ListMaxNative}} is the upper limit for the number of elements in a list
+// of {{.NameNative}}.
+extern size_t {{.ListMaxNative}};
+{{- end}}
+{{- end}}
+{{- end}}
 
 
 // colfer_text is a UTF-8 CLOB.
@@ -159,10 +229,16 @@ struct {{.NameNative}} {
 {{- end}} {{.NameNative}};
 {{- end}}
 };
+{{- if .FixedSizeMax}}
+
+// {{upper .NameNative}}_FIXED_MAX is the upper limit for serial octet sizes of
+// {{.NameNative}}, regardless of the field values.
+#define {{upper .NameNative}}_FIXED_MAX {{.FixedSizeMax}}
+{{- end}}
 
 // {{.NameNative}}_marshal_len returns the Colfer serial octet size.
 // When the return is zero then errno is set to EFBIG to indicate a breach of
-// either colfer_size_max or colfer_list_max.
+// either {{.SizeMaxNative}} or {{.ListMaxNative}}.
 size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o);
 
 // {{.NameNative}}_marshal encodes o as Colfer into buf and returns the number
@@ -171,11 +247,17 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf);
 
 // {{.NameNative}}_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
-// colfer_size_max, whichever occurs first.
+// {{.SizeMaxNative}}, whichever occurs first.
 // When the return is zero then errno is set to one of the following 3 values:
-// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either {{.SizeMaxNative}}
+// or {{.ListMaxNative}} and EILSEQ on schema mismatch.
 size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t datalen);
+
+// {{.NameNative}}_json writes the canonical JSON representation of o into buf
+// and returns the size of the entire representation, excluding the terminating
+// null byte. The output is truncated to buflen, including the null byte, like
+// snprintf(3). Timestamps with both fields zero are written as null.
+size_t {{.NameNative}}_json(const {{.NameNative}}* o, char* buf, size_t buflen);
 {{end}}{{end}}
 
 #ifdef __cplusplus
@@ -193,6 +275,12 @@ const cTemplate = `// Code generated by colf(1); DO NOT EDIT.
 #include "Colfer.h"
 #include <errno.h>
 #include <stdlib.h>
+{{- if .HasFloat}}
+#include <math.h>
+{{- end}}
+{{- if or .HasFloat .HasTimestamp}}
+#include <stdio.h>
+{{- end}}
 {{- if .HasTimestamp}}
 #include <time.h>
 {{end}}
@@ -206,10 +294,28 @@ const cTemplate = `// Code generated by colf(1); DO NOT EDIT.
 #define COLFER_ENDIAN
 #endif
 
-{{with index . 0}}
+{{with sharedSizeMax .}}
 size_t colfer_size_max = {{.SizeMax}};
+{{- end}}
+{{- with sharedListMax .}}
 size_t colfer_list_max = {{.ListMax}};
-{{end}}
+{{- end}}
+{{- range .}}
+{{- if .SizeMaxDirective}}
+size_t {{.SizeMaxNative}} = {{.SizeMax}};
+{{- end}}
+{{- if .ListMaxDirective}}
+size_t {{.ListMaxNative}} = {{.ListMax}};
+{{- end}}
+{{- range .Structs}}
+{{- if .SizeMax}}
+size_t {{.SizeMaxNative}} = {{.SizeMax}};
+{{- end}}
+{{- if and .ListMax .HasList}}
+size_t {{.ListMaxNative}} = {{.ListMax}};
+{{- end}}
+{{- end}}
+{{- end}}
 
 {{range .}}{{range .Structs}}
 size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
@@ -269,7 +375,7 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 	{
 		size_t n = o->{{.NameNative}}.len;
 		if (n) {
-			if (n > colfer_list_max) {
+			if (n > {{.Struct.ListMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -284,7 +390,7 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 	{
 		size_t n = o->{{.NameNative}}.len;
 		if (n) {
-			if (n > colfer_list_max) {
+			if (n > {{.Struct.ListMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -305,7 +411,7 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
  {{- if not .TypeList}}
 	{
 		size_t n = o->{{.NameNative}}.len;
-		if (n > colfer_size_max) {
+		if (n > {{.Struct.SizeMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -315,21 +421,21 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 	{
 		size_t n = o->{{.NameNative}}.len;
 		if (n) {
-			if (n > colfer_list_max) {
+			if (n > {{.Struct.ListMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
 			colfer_text* a = o->{{.NameNative}}.list;
 			for (size_t i = 0; i < n; ++i) {
 				size_t len = a[i].len;
-				if (len > colfer_size_max) {
+				if (len > {{.Struct.SizeMaxNative}}) {
 					errno = EFBIG;
 					return 0;
 				}
 				for (l += len + 1; len > 127; len >>= 7, ++l);
 			}
 			for (l += 2; n > 127; n >>= 7, ++l);
-			if (l > colfer_size_max) {
+			if (l > {{.Struct.SizeMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -340,7 +446,7 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
  {{- if not .TypeList}}
 	{
 		size_t n = o->{{.NameNative}}.len;
-		if (n > colfer_size_max) {
+		if (n > {{.Struct.SizeMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -350,21 +456,21 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 	{
 		size_t n = o->{{.NameNative}}.len;
 		if (n) {
-			if (n > colfer_list_max) {
+			if (n > {{.Struct.ListMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
 			colfer_binary* a = o->{{.NameNative}}.list;
 			for (size_t i = 0; i < n; ++i) {
 				size_t len = a[i].len;
-				if (len > colfer_size_max) {
+				if (len > {{.Struct.SizeMaxNative}}) {
 					errno = EFBIG;
 					return 0;
 				}
 				for (l += len + 1; len > 127; len >>= 7, ++l);
 			}
 			for (l += 2; n > 127; n >>= 7, ++l);
-			if (l > colfer_size_max) {
+			if (l > {{.Struct.SizeMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -380,14 +486,14 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 	{
 		size_t n = o->{{.NameNative}}.len;
 		if (n) {
-			if (n > colfer_list_max) {
+			if (n > {{.Struct.ListMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
 			{{.TypeRef.NameNative}}* a = o->{{.NameNative}}.list;
 			for (size_t i = 0; i < n; ++i) l += {{.TypeRef.NameNative}}_marshal_len(&a[i]);
 			for (l += 2; n > 127; n >>= 7, ++l);
-			if (l > colfer_size_max) {
+			if (l > {{.Struct.SizeMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -395,7 +501,7 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 	}
  {{- end}}
 {{end}}{{end}}
-	if (l > colfer_size_max) {
+	if (l > {{.SizeMaxNative}}) {
 		errno = EFBIG;
 		return 0;
 	}
@@ -752,11 +858,11 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 	const uint8_t* p = data;
 	const uint8_t* end;
 	int enderr;
-	if (datalen < colfer_size_max) {
+	if (datalen < {{.SizeMaxNative}}) {
 		end = p + datalen;
 		enderr = EWOULDBLOCK;
 	} else {
-		end = p + colfer_size_max;
+		end = p + {{.SizeMaxNative}};
 		enderr = EFBIG;
 	}
 
@@ -973,7 +1079,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
+		if (n > {{.Struct.ListMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1047,7 +1153,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
+		if (n > {{.Struct.ListMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1139,7 +1245,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_size_max) {
+		if (n > {{.Struct.SizeMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1179,7 +1285,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
+		if (n > {{.Struct.ListMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1208,7 +1314,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 					len |= (c & 127) << shift;
 				}
 			}
-			if (len > colfer_size_max) {
+			if (len > {{.Struct.SizeMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -1256,7 +1362,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_size_max) {
+		if (n > {{.Struct.SizeMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1296,7 +1402,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
+		if (n > {{.Struct.ListMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1325,7 +1431,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 					len |= (c & 127) << shift;
 				}
 			}
-			if (len > colfer_size_max) {
+			if (len > {{.Struct.SizeMaxNative}}) {
 				errno = EFBIG;
 				return 0;
 			}
@@ -1389,7 +1495,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
+		if (n > {{.Struct.ListMaxNative}}) {
 			errno = EFBIG;
 			return 0;
 		}
@@ -1421,4 +1527,330 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 
 	return (size_t) (p - (const uint8_t*) data);
 }
+{{end}}{{end}}
+
+// colfer_json_out is a JSON destination.
+typedef struct {
+	char*  buf;
+	size_t cap; // excludes the null byte
+	size_t n;   // number of bytes written, including truncation
+} colfer_json_out;
+
+static void colfer_json_put(colfer_json_out* w, const char* s, size_t n) {
+	if (w->n < w->cap) {
+		size_t room = w->cap - w->n;
+		memcpy(w->buf + w->n, s, n < room ? n : room);
+	}
+	w->n += n;
+}
+
+static void colfer_json_puts(colfer_json_out* w, const char* s) {
+	colfer_json_put(w, s, strlen(s));
+}
+{{- if .HasNumber}}
+
+static void colfer_json_uint(colfer_json_out* w, uint64_t x, int quote) {
+	char s[22];
+	char* p = s + sizeof s;
+	if (quote) *--p = '"';
+	do {
+		*--p = (char) ('0' + x % 10);
+		x /= 10;
+	} while (x);
+	if (quote) *--p = '"';
+	colfer_json_put(w, p, (size_t) (s + sizeof s - p));
+}
+
+static void colfer_json_int(colfer_json_out* w, int64_t x, int quote) {
+	if (x >= 0) {
+		colfer_json_uint(w, (uint64_t) x, quote);
+		return;
+	}
+	if (quote) colfer_json_puts(w, "\"");
+	colfer_json_puts(w, "-");
+	colfer_json_uint(w, -(uint64_t) x, 0);
+	if (quote) colfer_json_puts(w, "\"");
+}
+{{- end}}
+{{- if .HasFloat}}
+
+// colfer_json_up increments the last decimal of s in the notation of %e.
+static void colfer_json_up(char* s, size_t size) {
+	char* first = *s == '-' ? s + 1 : s;
+	char* e = strchr(s, 'e');
+	for (char* p = e; p != first; ) {
+		if (*--p == '.') continue;
+		if (*p != '9') {
+			++*p;
+			return;
+		}
+		*p = '0';
+	}
+	// all nines
+	*first = '1';
+	snprintf(e, size - (size_t) (e - s), "e%+03d", atoi(e + 1) + 1);
+}
+
+// colfer_json_float writes the shortest decimals which parse back into the
+// same value in the number notation of ECMAScript.
+static void colfer_json_float(colfer_json_out* w, double x, int f32) {
+	if (isnan(x)) {
+		colfer_json_puts(w, "\"NaN\"");
+		return;
+	}
+	if (isinf(x)) {
+		colfer_json_puts(w, x > 0 ? "\"Infinity\"" : "\"-Infinity\"");
+		return;
+	}
+	if (x == 0) {
+		colfer_json_puts(w, "0");
+		return;
+	}
+
+	char s[32];
+	for (int prec = 0; ; ++prec) {
+		snprintf(s, sizeof s, "%.*e", prec, x);
+		if (prec == (f32 ? 8 : 16)) break;
+		if (f32 ? strtof(s, NULL) == (float) x : strtod(s, NULL) == x) break;
+
+		// The neighbour above may qualify, as the gap below a power
+		// of two is half the size of the one above.
+		if (fabs(strtod(s, NULL)) < fabs(x)) {
+			colfer_json_up(s, sizeof s);
+			if (f32 ? strtof(s, NULL) == (float) x : strtod(s, NULL) == x) break;
+		}
+	}
+
+	const char* p = s;
+	if (*p == '-') {
+		colfer_json_puts(w, "-");
+		++p;
+	}
+	char digits[20];
+	int k = 0;
+	for (; *p != 'e'; ++p)
+		if (*p >= '0' && *p <= '9') digits[k++] = *p;
+	while (k > 1 && digits[k - 1] == '0') --k;
+	// decimal point position
+	int n = atoi(p + 1) + 1;
+
+	if (k <= n && n <= 21) {
+		colfer_json_put(w, digits, (size_t) k);
+		for (int i = k; i < n; ++i) colfer_json_puts(w, "0");
+	} else if (0 < n && n <= 21) {
+		colfer_json_put(w, digits, (size_t) n);
+		colfer_json_puts(w, ".");
+		colfer_json_put(w, digits + n, (size_t) (k - n));
+	} else if (-6 < n && n <= 0) {
+		colfer_json_puts(w, "0.");
+		for (int i = n; i < 0; ++i) colfer_json_puts(w, "0");
+		colfer_json_put(w, digits, (size_t) k);
+	} else {
+		colfer_json_put(w, digits, 1);
+		if (k > 1) {
+			colfer_json_puts(w, ".");
+			colfer_json_put(w, digits + 1, (size_t) (k - 1));
+		}
+		snprintf(s, sizeof s, "e%c%d", n > 0 ? '+' : '-', n > 0 ? n - 1 : 1 - n);
+		colfer_json_puts(w, s);
+	}
+}
+{{- end}}
+{{- if .HasTimestamp}}
+
+// colfer_json_timestamp writes RFC 3339 with the year as in Go, and the
+// fraction without trailing zeros.
+static void colfer_json_timestamp(colfer_json_out* w, const struct timespec* t) {
+	if (t->tv_sec == 0 && t->tv_nsec == 0) {
+		colfer_json_puts(w, "null");
+		return;
+	}
+
+	int64_t days = (int64_t) t->tv_sec / 86400;
+	int64_t secs = (int64_t) t->tv_sec % 86400;
+	if (secs < 0) {
+		secs += 86400;
+		--days;
+	}
+
+	// civil from days, as in "chrono-Compatible Low-Level Date Algorithms"
+	days += 719468;
+	int64_t era = (days >= 0 ? days : days - 146096) / 146097;
+	int64_t doe = days - era * 146097;
+	int64_t yoe = (doe - doe / 1460 + doe / 36524 - doe / 146096) / 365;
+	int64_t doy = doe - (365 * yoe + yoe / 4 - yoe / 100);
+	int64_t mp = (5 * doy + 2) / 153;
+	int64_t day = doy - (153 * mp + 2) / 5 + 1;
+	int64_t month = mp < 10 ? mp + 3 : mp - 9;
+	int64_t year = yoe + era * 400 + (month <= 2);
+
+	char s[64];
+	int n = snprintf(s, sizeof s, "\"%s%04lld-%02d-%02dT%02d:%02d:%02d",
+		year < 0 ? "-" : "", (long long) (year < 0 ? -year : year),
+		(int) month, (int) day,
+		(int) (secs / 3600), (int) (secs / 60 % 60), (int) (secs % 60));
+	if (t->tv_nsec != 0) {
+		n += snprintf(s + n, sizeof s - (size_t) n, ".%09ld", (long) t->tv_nsec);
+		while (s[n - 1] == '0') --n;
+	}
+	colfer_json_put(w, s, (size_t) n);
+	colfer_json_puts(w, "Z\"");
+}
+{{- end}}
+{{- if .HasText}}
+
+// colfer_json_utf8 returns the size of the UTF-8 encoding at p, or zero when
+// the encoding is not valid.
+static size_t colfer_json_utf8(const uint8_t* p, const uint8_t* end) {
+	uint8_t lo = 0x80, hi = 0xbf;
+	size_t n;
+	if (*p >= 0xc2 && *p <= 0xdf) {
+		n = 2;
+	} else if (*p >= 0xe0 && *p <= 0xef) {
+		n = 3;
+		if (*p == 0xe0) lo = 0xa0;
+		if (*p == 0xed) hi = 0x9f;
+	} else if (*p >= 0xf0 && *p <= 0xf4) {
+		n = 4;
+		if (*p == 0xf0) lo = 0x90;
+		if (*p == 0xf4) hi = 0x8f;
+	} else {
+		return 0;
+	}
+	if ((size_t) (end - p) < n || p[1] < lo || p[1] > hi) return 0;
+	for (size_t i = 2; i < n; ++i)
+		if (p[i] < 0x80 || p[i] > 0xbf) return 0;
+	return n;
+}
+
+// colfer_json_text writes a string with the escapes of JSON.stringify from
+// ECMAScript. Each byte of malformed UTF-8 is replaced with U+FFFD.
+static void colfer_json_text(colfer_json_out* w, const colfer_text* t) {
+	colfer_json_puts(w, "\"");
+	const uint8_t* p = (const uint8_t*) t->utf8;
+	const uint8_t* end = p + t->len;
+	while (p < end) {
+		if (*p >= 0x80) {
+			size_t n = colfer_json_utf8(p, end);
+			if (n) {
+				colfer_json_put(w, (const char*) p, n);
+				p += n;
+			} else {
+				colfer_json_puts(w, "\xef\xbf\xbd");
+				++p;
+			}
+			continue;
+		}
+
+		switch (*p) {
+		case '"':
+			colfer_json_puts(w, "\\\"");
+			break;
+		case '\\':
+			colfer_json_puts(w, "\\\\");
+			break;
+		case '\b':
+			colfer_json_puts(w, "\\b");
+			break;
+		case '\f':
+			colfer_json_puts(w, "\\f");
+			break;
+		case '\n':
+			colfer_json_puts(w, "\\n");
+			break;
+		case '\r':
+			colfer_json_puts(w, "\\r");
+			break;
+		case '\t':
+			colfer_json_puts(w, "\\t");
+			break;
+		default:
+			if (*p < ' ') {
+				char s[] = "\\u00xx";
+				s[4] = "0123456789abcdef"[*p >> 4];
+				s[5] = "0123456789abcdef"[*p & 15];
+				colfer_json_puts(w, s);
+			} else {
+				colfer_json_put(w, (const char*) p, 1);
+			}
+		}
+		++p;
+	}
+	colfer_json_puts(w, "\"");
+}
+{{- end}}
+{{- if .HasBinary}}
+
+// colfer_json_binary writes standard base64 with padding.
+static void colfer_json_binary(colfer_json_out* w, const colfer_binary* b) {
+	static const char enc[] = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+	colfer_json_puts(w, "\"");
+	for (size_t i = 0; i < b->len; i += 3) {
+		uint_fast32_t v = (uint_fast32_t) b->octets[i] << 16;
+		if (i + 1 < b->len) v |= (uint_fast32_t) b->octets[i + 1] << 8;
+		if (i + 2 < b->len) v |= b->octets[i + 2];
+		char s[4] = {
+			enc[v >> 18],
+			enc[v >> 12 & 63],
+			i + 1 < b->len ? enc[v >> 6 & 63] : '=',
+			i + 2 < b->len ? enc[v & 63] : '=',
+		};
+		colfer_json_put(w, s, 4);
+	}
+	colfer_json_puts(w, "\"");
+}
+{{- end}}
+{{range .}}{{range .Structs}}
+static void {{.NameNative}}_json_write(const {{.NameNative}}* o, colfer_json_out* w);
+{{- end}}{{end}}
+{{range .}}{{range .Structs}}
+size_t {{.NameNative}}_json(const {{.NameNative}}* o, char* buf, size_t buflen) {
+	colfer_json_out w = {buf, buflen ? buflen - 1 : 0, 0};
+	{{.NameNative}}_json_write(o, &w);
+	if (buflen) buf[w.n < w.cap ? w.n : w.cap] = 0;
+	return w.n;
+}
+
+static void {{.NameNative}}_json_write(const {{.NameNative}}* o, colfer_json_out* w) {
+{{- range .Fields}}
+	colfer_json_puts(w, "{{if .Index}},{{else}}{{"{"}}{{end}}\"{{.Name}}\":");
+{{- if .TypeList}}
+	colfer_json_puts(w, "[");
+	for (size_t i = 0; i < o->{{.NameNative}}.len; ++i) {
+		if (i) colfer_json_puts(w, ",");
+ {{- if eq .Type "float32"}}
+		colfer_json_float(w, o->{{.NameNative}}.list[i], 1);
+ {{- else if eq .Type "float64"}}
+		colfer_json_float(w, o->{{.NameNative}}.list[i], 0);
+ {{- else if eq .Type "text" "binary"}}
+		colfer_json_{{.Type}}(w, &o->{{.NameNative}}.list[i]);
+ {{- else}}
+		{{.TypeRef.NameNative}}_json_write(&o->{{.NameNative}}.list[i], w);
+ {{- end}}
+	}
+	colfer_json_puts(w, "]");
+{{- else if eq .Type "bool"}}
+	colfer_json_puts(w, o->{{.NameNative}} ? "true" : "false");
+{{- else if eq .Type "uint8" "uint16" "uint32"}}
+	colfer_json_uint(w, o->{{.NameNative}}, 0);
+{{- else if eq .Type "uint64"}}
+	colfer_json_uint(w, o->{{.NameNative}}, 1);
+{{- else if eq .Type "int32"}}
+	colfer_json_int(w, o->{{.NameNative}}, 0);
+{{- else if eq .Type "int64"}}
+	colfer_json_int(w, o->{{.NameNative}}, 1);
+{{- else if eq .Type "float32"}}
+	colfer_json_float(w, o->{{.NameNative}}, 1);
+{{- else if eq .Type "float64"}}
+	colfer_json_float(w, o->{{.NameNative}}, 0);
+{{- else if eq .Type "timestamp" "text" "binary"}}
+	colfer_json_{{.Type}}(w, &o->{{.NameNative}});
+{{- else}}
+	if (o->{{.NameNative}}) {{.TypeRef.NameNative}}_json_write(o->{{.NameNative}}, w);
+	else colfer_json_puts(w, "null");
+{{- end}}
+{{- end}}
+	colfer_json_puts(w, {{if .Fields}}"}"{{else}}"{}"{{end}});
+}
 {{end}}{{end}}`
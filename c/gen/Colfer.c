@@ -4,6 +4,8 @@
 #include "Colfer.h"
 #include <errno.h>
 #include <stdlib.h>
+#include <math.h>
+#include <stdio.h>
 #include <time.h>
 
 
@@ -21,7 +23,6 @@ size_t colfer_size_max = 16 * 1024 * 1024;
 size_t colfer_list_max = 64 * 1024;
 
 
-
 size_t gen_o_marshal_len(const gen_o* o) {
 	size_t l = 1;
 
@@ -1201,3 +1202,342 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 
 	return (size_t) (p - (const uint8_t*) data);
 }
+
+
+// colfer_json_out is a JSON destination.
+typedef struct {
+	char*  buf;
+	size_t cap; // excludes the null byte
+	size_t n;   // number of bytes written, including truncation
+} colfer_json_out;
+
+static void colfer_json_put(colfer_json_out* w, const char* s, size_t n) {
+	if (w->n < w->cap) {
+		size_t room = w->cap - w->n;
+		memcpy(w->buf + w->n, s, n < room ? n : room);
+	}
+	w->n += n;
+}
+
+static void colfer_json_puts(colfer_json_out* w, const char* s) {
+	colfer_json_put(w, s, strlen(s));
+}
+
+static void colfer_json_uint(colfer_json_out* w, uint64_t x, int quote) {
+	char s[22];
+	char* p = s + sizeof s;
+	if (quote) *--p = '"';
+	do {
+		*--p = (char) ('0' + x % 10);
+		x /= 10;
+	} while (x);
+	if (quote) *--p = '"';
+	colfer_json_put(w, p, (size_t) (s + sizeof s - p));
+}
+
+static void colfer_json_int(colfer_json_out* w, int64_t x, int quote) {
+	if (x >= 0) {
+		colfer_json_uint(w, (uint64_t) x, quote);
+		return;
+	}
+	if (quote) colfer_json_puts(w, "\"");
+	colfer_json_puts(w, "-");
+	colfer_json_uint(w, -(uint64_t) x, 0);
+	if (quote) colfer_json_puts(w, "\"");
+}
+
+// colfer_json_up increments the last decimal of s in the notation of %e.
+static void colfer_json_up(char* s, size_t size) {
+	char* first = *s == '-' ? s + 1 : s;
+	char* e = strchr(s, 'e');
+	for (char* p = e; p != first; ) {
+		if (*--p == '.') continue;
+		if (*p != '9') {
+			++*p;
+			return;
+		}
+		*p = '0';
+	}
+	// all nines
+	*first = '1';
+	snprintf(e, size - (size_t) (e - s), "e%+03d", atoi(e + 1) + 1);
+}
+
+// colfer_json_float writes the shortest decimals which parse back into the
+// same value in the number notation of ECMAScript.
+static void colfer_json_float(colfer_json_out* w, double x, int f32) {
+	if (isnan(x)) {
+		colfer_json_puts(w, "\"NaN\"");
+		return;
+	}
+	if (isinf(x)) {
+		colfer_json_puts(w, x > 0 ? "\"Infinity\"" : "\"-Infinity\"");
+		return;
+	}
+	if (x == 0) {
+		colfer_json_puts(w, "0");
+		return;
+	}
+
+	char s[32];
+	for (int prec = 0; ; ++prec) {
+		snprintf(s, sizeof s, "%.*e", prec, x);
+		if (prec == (f32 ? 8 : 16)) break;
+		if (f32 ? strtof(s, NULL) == (float) x : strtod(s, NULL) == x) break;
+
+		// The neighbour above may qualify, as the gap below a power
+		// of two is half the size of the one above.
+		if (fabs(strtod(s, NULL)) < fabs(x)) {
+			colfer_json_up(s, sizeof s);
+			if (f32 ? strtof(s, NULL) == (float) x : strtod(s, NULL) == x) break;
+		}
+	}
+
+	const char* p = s;
+	if (*p == '-') {
+		colfer_json_puts(w, "-");
+		++p;
+	}
+	char digits[20];
+	int k = 0;
+	for (; *p != 'e'; ++p)
+		if (*p >= '0' && *p <= '9') digits[k++] = *p;
+	while (k > 1 && digits[k - 1] == '0') --k;
+	// decimal point position
+	int n = atoi(p + 1) + 1;
+
+	if (k <= n && n <= 21) {
+		colfer_json_put(w, digits, (size_t) k);
+		for (int i = k; i < n; ++i) colfer_json_puts(w, "0");
+	} else if (0 < n && n <= 21) {
+		colfer_json_put(w, digits, (size_t) n);
+		colfer_json_puts(w, ".");
+		colfer_json_put(w, digits + n, (size_t) (k - n));
+	} else if (-6 < n && n <= 0) {
+		colfer_json_puts(w, "0.");
+		for (int i = n; i < 0; ++i) colfer_json_puts(w, "0");
+		colfer_json_put(w, digits, (size_t) k);
+	} else {
+		colfer_json_put(w, digits, 1);
+		if (k > 1) {
+			colfer_json_puts(w, ".");
+			colfer_json_put(w, digits + 1, (size_t) (k - 1));
+		}
+		snprintf(s, sizeof s, "e%c%d", n > 0 ? '+' : '-', n > 0 ? n - 1 : 1 - n);
+		colfer_json_puts(w, s);
+	}
+}
+
+// colfer_json_timestamp writes RFC 3339 with the year as in Go, and the
+// fraction without trailing zeros.
+static void colfer_json_timestamp(colfer_json_out* w, const struct timespec* t) {
+	if (t->tv_sec == 0 && t->tv_nsec == 0) {
+		colfer_json_puts(w, "null");
+		return;
+	}
+
+	int64_t days = (int64_t) t->tv_sec / 86400;
+	int64_t secs = (int64_t) t->tv_sec % 86400;
+	if (secs < 0) {
+		secs += 86400;
+		--days;
+	}
+
+	// civil from days, as in "chrono-Compatible Low-Level Date Algorithms"
+	days += 719468;
+	int64_t era = (days >= 0 ? days : days - 146096) / 146097;
+	int64_t doe = days - era * 146097;
+	int64_t yoe = (doe - doe / 1460 + doe / 36524 - doe / 146096) / 365;
+	int64_t doy = doe - (365 * yoe + yoe / 4 - yoe / 100);
+	int64_t mp = (5 * doy + 2) / 153;
+	int64_t day = doy - (153 * mp + 2) / 5 + 1;
+	int64_t month = mp < 10 ? mp + 3 : mp - 9;
+	int64_t year = yoe + era * 400 + (month <= 2);
+
+	char s[64];
+	int n = snprintf(s, sizeof s, "\"%s%04lld-%02d-%02dT%02d:%02d:%02d",
+		year < 0 ? "-" : "", (long long) (year < 0 ? -year : year),
+		(int) month, (int) day,
+		(int) (secs / 3600), (int) (secs / 60 % 60), (int) (secs % 60));
+	if (t->tv_nsec != 0) {
+		n += snprintf(s + n, sizeof s - (size_t) n, ".%09ld", (long) t->tv_nsec);
+		while (s[n - 1] == '0') --n;
+	}
+	colfer_json_put(w, s, (size_t) n);
+	colfer_json_puts(w, "Z\"");
+}
+
+// colfer_json_utf8 returns the size of the UTF-8 encoding at p, or zero when
+// the encoding is not valid.
+static size_t colfer_json_utf8(const uint8_t* p, const uint8_t* end) {
+	uint8_t lo = 0x80, hi = 0xbf;
+	size_t n;
+	if (*p >= 0xc2 && *p <= 0xdf) {
+		n = 2;
+	} else if (*p >= 0xe0 && *p <= 0xef) {
+		n = 3;
+		if (*p == 0xe0) lo = 0xa0;
+		if (*p == 0xed) hi = 0x9f;
+	} else if (*p >= 0xf0 && *p <= 0xf4) {
+		n = 4;
+		if (*p == 0xf0) lo = 0x90;
+		if (*p == 0xf4) hi = 0x8f;
+	} else {
+		return 0;
+	}
+	if ((size_t) (end - p) < n || p[1] < lo || p[1] > hi) return 0;
+	for (size_t i = 2; i < n; ++i)
+		if (p[i] < 0x80 || p[i] > 0xbf) return 0;
+	return n;
+}
+
+// colfer_json_text writes a string with the escapes of JSON.stringify from
+// ECMAScript. Each byte of malformed UTF-8 is replaced with U+FFFD.
+static void colfer_json_text(colfer_json_out* w, const colfer_text* t) {
+	colfer_json_puts(w, "\"");
+	const uint8_t* p = (const uint8_t*) t->utf8;
+	const uint8_t* end = p + t->len;
+	while (p < end) {
+		if (*p >= 0x80) {
+			size_t n = colfer_json_utf8(p, end);
+			if (n) {
+				colfer_json_put(w, (const char*) p, n);
+				p += n;
+			} else {
+				colfer_json_puts(w, "\xef\xbf\xbd");
+				++p;
+			}
+			continue;
+		}
+
+		switch (*p) {
+		case '"':
+			colfer_json_puts(w, "\\\"");
+			break;
+		case '\\':
+			colfer_json_puts(w, "\\\\");
+			break;
+		case '\b':
+			colfer_json_puts(w, "\\b");
+			break;
+		case '\f':
+			colfer_json_puts(w, "\\f");
+			break;
+		case '\n':
+			colfer_json_puts(w, "\\n");
+			break;
+		case '\r':
+			colfer_json_puts(w, "\\r");
+			break;
+		case '\t':
+			colfer_json_puts(w, "\\t");
+			break;
+		default:
+			if (*p < ' ') {
+				char s[] = "\\u00xx";
+				s[4] = "0123456789abcdef"[*p >> 4];
+				s[5] = "0123456789abcdef"[*p & 15];
+				colfer_json_puts(w, s);
+			} else {
+				colfer_json_put(w, (const char*) p, 1);
+			}
+		}
+		++p;
+	}
+	colfer_json_puts(w, "\"");
+}
+
+// colfer_json_binary writes standard base64 with padding.
+static void colfer_json_binary(colfer_json_out* w, const colfer_binary* b) {
+	static const char enc[] = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+	colfer_json_puts(w, "\"");
+	for (size_t i = 0; i < b->len; i += 3) {
+		uint_fast32_t v = (uint_fast32_t) b->octets[i] << 16;
+		if (i + 1 < b->len) v |= (uint_fast32_t) b->octets[i + 1] << 8;
+		if (i + 2 < b->len) v |= b->octets[i + 2];
+		char s[4] = {
+			enc[v >> 18],
+			enc[v >> 12 & 63],
+			i + 1 < b->len ? enc[v >> 6 & 63] : '=',
+			i + 2 < b->len ? enc[v & 63] : '=',
+		};
+		colfer_json_put(w, s, 4);
+	}
+	colfer_json_puts(w, "\"");
+}
+
+static void gen_o_json_write(const gen_o* o, colfer_json_out* w);
+
+size_t gen_o_json(const gen_o* o, char* buf, size_t buflen) {
+	colfer_json_out w = {buf, buflen ? buflen - 1 : 0, 0};
+	gen_o_json_write(o, &w);
+	if (buflen) buf[w.n < w.cap ? w.n : w.cap] = 0;
+	return w.n;
+}
+
+static void gen_o_json_write(const gen_o* o, colfer_json_out* w) {
+	colfer_json_puts(w, "{\"b\":");
+	colfer_json_puts(w, o->b ? "true" : "false");
+	colfer_json_puts(w, ",\"u32\":");
+	colfer_json_uint(w, o->u32, 0);
+	colfer_json_puts(w, ",\"u64\":");
+	colfer_json_uint(w, o->u64, 1);
+	colfer_json_puts(w, ",\"i32\":");
+	colfer_json_int(w, o->i32, 0);
+	colfer_json_puts(w, ",\"i64\":");
+	colfer_json_int(w, o->i64, 1);
+	colfer_json_puts(w, ",\"f32\":");
+	colfer_json_float(w, o->f32, 1);
+	colfer_json_puts(w, ",\"f64\":");
+	colfer_json_float(w, o->f64, 0);
+	colfer_json_puts(w, ",\"t\":");
+	colfer_json_timestamp(w, &o->t);
+	colfer_json_puts(w, ",\"s\":");
+	colfer_json_text(w, &o->s);
+	colfer_json_puts(w, ",\"a\":");
+	colfer_json_binary(w, &o->a);
+	colfer_json_puts(w, ",\"o\":");
+	if (o->o) gen_o_json_write(o->o, w);
+	else colfer_json_puts(w, "null");
+	colfer_json_puts(w, ",\"os\":");
+	colfer_json_puts(w, "[");
+	for (size_t i = 0; i < o->os.len; ++i) {
+		if (i) colfer_json_puts(w, ",");
+		gen_o_json_write(&o->os.list[i], w);
+	}
+	colfer_json_puts(w, "]");
+	colfer_json_puts(w, ",\"ss\":");
+	colfer_json_puts(w, "[");
+	for (size_t i = 0; i < o->ss.len; ++i) {
+		if (i) colfer_json_puts(w, ",");
+		colfer_json_text(w, &o->ss.list[i]);
+	}
+	colfer_json_puts(w, "]");
+	colfer_json_puts(w, ",\"as\":");
+	colfer_json_puts(w, "[");
+	for (size_t i = 0; i < o->as.len; ++i) {
+		if (i) colfer_json_puts(w, ",");
+		colfer_json_binary(w, &o->as.list[i]);
+	}
+	colfer_json_puts(w, "]");
+	colfer_json_puts(w, ",\"u8\":");
+	colfer_json_uint(w, o->u8, 0);
+	colfer_json_puts(w, ",\"u16\":");
+	colfer_json_uint(w, o->u16, 0);
+	colfer_json_puts(w, ",\"f32s\":");
+	colfer_json_puts(w, "[");
+	for (size_t i = 0; i < o->f32s.len; ++i) {
+		if (i) colfer_json_puts(w, ",");
+		colfer_json_float(w, o->f32s.list[i], 1);
+	}
+	colfer_json_puts(w, "]");
+	colfer_json_puts(w, ",\"f64s\":");
+	colfer_json_puts(w, "[");
+	for (size_t i = 0; i < o->f64s.len; ++i) {
+		if (i) colfer_json_puts(w, ",");
+		colfer_json_float(w, o->f64s.list[i], 0);
+	}
+	colfer_json_puts(w, "]");
+	colfer_json_puts(w, "}");
+}
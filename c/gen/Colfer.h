@@ -18,11 +18,12 @@
 extern "C" {
 #endif
 
-
-// colfer_size_max is the upper limit for serial octet sizes.
+// colfer_size_max is the upper limit for serial octet sizes of the packages
+// without a size directive.
 extern size_t colfer_size_max;
 
-// colfer_list_max is the upper limit for the number of elements in a list.
+// colfer_list_max is the upper limit for the number of elements in a list of
+// the packages without a list directive.
 extern size_t colfer_list_max;
 
 
@@ -114,6 +115,12 @@ size_t gen_o_marshal(const gen_o* o, void* buf);
 // or colfer_list_max and EILSEQ on schema mismatch.
 size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen);
 
+// gen_o_json writes the canonical JSON representation of o into buf
+// and returns the size of the entire representation, excluding the terminating
+// null byte. The output is truncated to buflen, including the null byte, like
+// snprintf(3). Timestamps with both fields zero are written as null.
+size_t gen_o_json(const gen_o* o, char* buf, size_t buflen);
+
 
 #ifdef __cplusplus
 } // extern "C"
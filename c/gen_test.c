@@ -19,6 +19,16 @@ void hexstr(char* buf, const void* data, size_t datalen) {
 	*buf = 0;
 }
 
+// unhex maps a hex string into buf.
+void unhex(void* buf, const char* hex) {
+	uint8_t* p = buf;
+	for (; hex[0] && hex[1]; hex += 2) {
+		int hi = hex[0] <= '9' ? hex[0] - '0' : hex[0] - 'a' + 10;
+		int lo = hex[1] <= '9' ? hex[1] - '0' : hex[1] - 'a' + 10;
+		*p++ = (uint8_t) (hi << 4 | lo);
+	}
+}
+
 int gen_o_equal(const gen_o* pa, const gen_o* pb) {
 	if (pa == NULL || pb == NULL) return pa == pb;
 	const gen_o a = *pa, b = *pb;
@@ -239,6 +249,52 @@ int main() {
 		colfer_size_max = 16 * 1024 * 1024;
 	}
 
+	printf("TEST JSON...\n");
+	// canonical JSON per serial, which must be identical in all languages
+	FILE* golden = fopen("../testdata/json.txt", "r");
+	if (!golden) {
+		printf("JSON golden file: %s\n", strerror(errno));
+		errno = 0;
+	}
+	char* json = malloc(4096);
+	char line[2048];
+	while (golden && fgets(line, sizeof line, golden)) {
+		if (line[0] == '#' || line[0] == '\n') continue;
+		line[strcspn(line, "\n")] = 0;
+		// serial, direction and JSON separated by a space
+		char* want = strchr(line, ' ');
+		if (want) want = strchr(want + 1, ' ');
+		if (!want) {
+			printf("malformed JSON golden %s\n", line);
+			continue;
+		}
+		*strchr(line, ' ') = 0;
+		++want;
+
+		size_t len = strlen(line) / 2;
+		unhex(buf, line);
+
+		gen_o o = {0};
+		if (gen_o_unmarshal(&o, buf, len) != len) {
+			printf("0x%s: unmarshal error %d\n", line, errno);
+			errno = 0;
+			continue;
+		}
+
+		size_t n = gen_o_json(&o, json, 4096);
+		if (n != strlen(want) || strcmp(json, want))
+			printf("0x%s: got JSON %s (%zu bytes)\n\twant %s\n", line, json, n, want);
+
+		// truncation
+		for (size_t lim = 0; lim < n; lim += 7) {
+			size_t got = gen_o_json(&o, json, lim);
+			if (got != n || (lim && (strncmp(json, want, lim - 1) || json[lim - 1])))
+				printf("0x%s: got JSON %zu bytes with buffer length %zu\n", line, got, lim);
+		}
+	}
+	if (golden) fclose(golden);
+	free(json);
+
 	free(buf);
 	free(hex);
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// project is the configuration file format.
+type project struct {
+	// Schemas are the default schema files and directories.
+	Schemas []string `json:"schemas"`
+	// SizeMax is the default for ColferSizeMax.
+	SizeMax string `json:"sizeMax"`
+	// ListMax is the default for ColferListMax.
+	ListMax string `json:"listMax"`
+
+	Targets []*buildTarget `json:"targets"`
+}
+
+// buildTarget is a compilation in the project.
+type buildTarget struct {
+	// Lang is the language operand.
+	Lang string `json:"lang"`
+	// Dir is the destination base directory.
+	Dir string `json:"dir"`
+	// Prefix is the package prefix.
+	Prefix string `json:"prefix"`
+	// SuperClass is the base for all generated classes.
+	SuperClass string `json:"superClass"`
+	// Schemas override the project's schema files and directories.
+	Schemas []string `json:"schemas"`
+	// SizeMax overrides the project's ColferSizeMax.
+	SizeMax string `json:"sizeMax"`
+	// ListMax overrides the project's ColferListMax.
+	ListMax string `json:"listMax"`
+	// ZeroCopy enables zero-copy unmarshalling.
+	ZeroCopy bool `json:"zeroCopy"`
+	// Reuse enables memory recycling on unmarshal.
+	Reuse bool `json:"reuse"`
+	// ValueTypes enables data structure values instead of pointers.
+	ValueTypes bool `json:"valueTypes"`
+}
+
+// buildCmd executes the build command.
+func buildCmd(args []string) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	config := flags.String("c", "colf.json", "Reads the project configuration from `file`.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] build [ build options ]\n\n")
+		os.Stderr.WriteString("Build compiles each target of a project configuration. The file is a\n")
+		os.Stderr.WriteString("JSON object with the following members. Paths are relative to the\n")
+		os.Stderr.WriteString("directory of the file.\n\n")
+		os.Stderr.WriteString("\tschemas     array of schema files and directories\n")
+		os.Stderr.WriteString("\tsizeMax     expression for ColferSizeMax, like option -s\n")
+		os.Stderr.WriteString("\tlistMax     expression for ColferListMax, like option -l\n")
+		os.Stderr.WriteString("\ttargets     array of objects with the following members\n\n")
+		os.Stderr.WriteString("\tlang        language, as in the compile synopsis\n")
+		os.Stderr.WriteString("\tdir         destination base directory, like option -b\n")
+		os.Stderr.WriteString("\tprefix      package prefix, like option -p\n")
+		os.Stderr.WriteString("\tsuperClass  super class, like option -x\n")
+		os.Stderr.WriteString("\tschemas     overrides the project's schemas\n")
+		os.Stderr.WriteString("\tsizeMax     overrides the project's sizeMax\n")
+		os.Stderr.WriteString("\tlistMax     overrides the project's listMax\n")
+		os.Stderr.WriteString("\tzeroCopy    boolean for zero-copy unmarshalling, like option -z\n")
+		os.Stderr.WriteString("\treuse       boolean for memory recycling on unmarshal, like option -r\n")
+		os.Stderr.WriteString("\tvalueTypes  boolean for data structure values, like option -e\n\n")
+		os.Stderr.WriteString("Options -s and -l apply when the configuration has no limits. Check\n")
+		os.Stderr.WriteString("mode (-check) verifies all targets.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	proj, err := readProject(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root := filepath.Dir(*config)
+
+	var stale int
+	for _, t := range proj.Targets {
+		opts := options(t.Lang, t.Prefix, firstOf(t.SizeMax, proj.SizeMax, *sizeMax), firstOf(t.ListMax, proj.ListMax, *listMax), t.SuperClass)
+		opts.ZeroCopy = t.ZeroCopy
+		opts.Reuse = t.Reuse
+		opts.ValueTypes = t.ValueTypes
+
+		schemas := t.Schemas
+		if len(schemas) == 0 {
+			schemas = proj.Schemas
+		}
+		var args []string
+		for _, s := range schemas {
+			args = append(args, filepath.Join(root, filepath.FromSlash(s)))
+		}
+		files := schemaFiles(args)
+		formatFiles(files)
+		sources, err := colfer.Compile(files, nil, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		dir := filepath.Join(root, filepath.FromSlash(t.Dir))
+		if *check {
+			stale += checkSources(dir, sources)
+			continue
+		}
+		if err := sources.Write(dir); err != nil {
+			log.Fatal(err)
+		}
+		report.Printf("Built %s in %s", t.Lang, dir)
+	}
+
+	if stale != 0 {
+		log.Printf("colf: %d stale files in %s", stale, *config)
+		os.Exit(1)
+	}
+}
+
+// readProject parses a configuration file.
+func readProject(file string) (*project, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	proj := new(project)
+	if err := dec.Decode(proj); err != nil {
+		return nil, fmt.Errorf("colf: project %s: %s", file, err)
+	}
+
+	if len(proj.Targets) == 0 {
+		return nil, fmt.Errorf("colf: project %s: no targets", file)
+	}
+	for i, t := range proj.Targets {
+		if t.Lang == "" {
+			return nil, fmt.Errorf("colf: project %s: target %d has no lang", file, i+1)
+		}
+		if len(t.Schemas) == 0 && len(proj.Schemas) == 0 {
+			return nil, fmt.Errorf("colf: project %s: target %d has no schemas", file, i+1)
+		}
+	}
+	return proj, nil
+}
+
+// firstOf returns the first non-empty value.
+func firstOf(values ...string) string {
+	for _, s := range values {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
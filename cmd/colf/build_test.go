@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadProject(t *testing.T) {
+	golden := []struct {
+		config string
+		// error substring or none
+		err string
+	}{
+		{`{"schemas": ["a.colf"], "targets": [{"lang": "Go"}]}`, ""},
+		{`{"targets": [{"lang": "Go", "schemas": ["a.colf"]}]}`, ""},
+		{`{"schemas": ["a.colf"], "targets": [{"lang": "Go"}], "extra": true}`, `unknown field "extra"`},
+		{`{"schemas": ["a.colf"], "targets": [{"lang": "Go", "dirs": "x"}]}`, `unknown field "dirs"`},
+		{`{"schemas": ["a.colf"]}`, "no targets"},
+		{`{"schemas": ["a.colf"], "targets": []}`, "no targets"},
+		{`{"schemas": ["a.colf"], "targets": [{"lang": "Go"}, {"dir": "x"}]}`, "target 2 has no lang"},
+		{`{"targets": [{"lang": "Go"}]}`, "target 1 has no schemas"},
+		{`{"schemas": [], "targets": [{"lang": "Go", "schemas": []}]}`, "target 1 has no schemas"},
+		{`{"schemas": "a.colf", "targets": [{"lang": "Go"}]}`, "cannot unmarshal"},
+		{`{"targets": [`, "unexpected EOF"},
+	}
+
+	dir, err := ioutil.TempDir("", "colf-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "colf.json")
+
+	for _, gold := range golden {
+		if err := ioutil.WriteFile(file, []byte(gold.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := readProject(file)
+		switch {
+		case gold.err == "" && err != nil:
+			t.Errorf("%s: %s", gold.config, err)
+		case gold.err != "" && err == nil:
+			t.Errorf("%s: no error, want %q", gold.config, gold.err)
+		case gold.err != "" && !strings.Contains(err.Error(), gold.err):
+			t.Errorf("%s: got error %q, want %q", gold.config, err, gold.err)
+		}
+	}
+
+	if _, err := readProject(filepath.Join(dir, "absent.json")); !os.IsNotExist(err) {
+		t.Errorf("got error %v for an absent file, want not exist", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colf-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, sub := range []string{"conf", "schema"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema := "package demo\n\ntype point struct {\n\tx []float32\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "schema", "demo.colf"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// paths are relative to the configuration file
+	project := `{
+	"schemas": ["../schema"],
+	"sizeMax": "2222",
+	"targets": [
+		{"lang": "Go", "dir": "../out/target", "sizeMax": "1111", "listMax": "11"},
+		{"lang": "Go", "dir": "../out/project"}
+	]
+}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "conf", "colf.json"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// no limits in the configuration
+	flags := `{"targets": [{"lang": "Go", "dir": "out/flag", "schemas": ["schema/demo.colf"]}]}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "flags.json"), []byte(flags), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, stderr, exit := runColf(t, dir, "-s", "3333", "-l", "33", "build", "-c", filepath.Join("conf", "colf.json")); exit != 0 {
+		t.Fatalf("build got exit status %d; stderr: %s", exit, stderr)
+	}
+	if _, stderr, exit := runColf(t, dir, "-s", "3333", "-l", "33", "build", "-c", "flags.json"); exit != 0 {
+		t.Fatalf("build got exit status %d; stderr: %s", exit, stderr)
+	}
+
+	golden := []struct {
+		dir              string
+		sizeMax, listMax string
+	}{
+		{"target", "1111", "11"},
+		{"project", "2222", "33"},
+		{"flag", "3333", "33"},
+	}
+	for _, gold := range golden {
+		code, err := ioutil.ReadFile(filepath.Join(dir, "out", gold.dir, "demo", "Colfer.go"))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if want := "ColferSizeMax = " + gold.sizeMax + "\n"; !strings.Contains(string(code), want) {
+			t.Errorf("%s: no %q in generated code", gold.dir, want)
+		}
+		if want := "ColferListMax = " + gold.listMax + "\n"; !strings.Contains(string(code), want) {
+			t.Errorf("%s: no %q in generated code", gold.dir, want)
+		}
+	}
+
+	if stdout, stderr, exit := runColf(t, dir, "-s", "3333", "-l", "33", "-check", "build", "-c", filepath.Join("conf", "colf.json")); exit != 0 {
+		t.Errorf("check got exit status %d; stdout: %s; stderr: %s", exit, stdout, stderr)
+	}
+	// flag limits differ from the code on disk
+	if _, _, exit := runColf(t, dir, "-check", "build", "-c", filepath.Join("conf", "colf.json")); exit != 1 {
+		t.Errorf("check with other flag limits got exit status %d, want 1", exit)
+	}
+}
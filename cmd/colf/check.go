@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// generatedMark identifies the output of colf(1) in the first lines.
+var generatedMark = []byte("Code generated by colf(1); DO NOT EDIT.")
+
+// checkSources compares the files with their counterpart in basedir. Each
+// difference is printed as a unified diff to the standard output. The return
+// is the number of stale files, which includes the generated files without a
+// counterpart in the directories of files.
+func checkSources(basedir string, files colfer.Sources) (stale int) {
+	for _, path := range files.Paths() {
+		file := filepath.Join(basedir, filepath.FromSlash(path))
+		want := files[path]
+
+		oldName := "a/" + path
+		got, err := ioutil.ReadFile(file)
+		switch {
+		case err == nil:
+			if bytes.Equal(got, want) {
+				report.Println("Up to date:", file)
+				continue
+			}
+		case os.IsNotExist(err):
+			oldName = "/dev/null"
+		default:
+			log.Fatal(err)
+		}
+
+		stale++
+		diff := unifiedDiff(oldName, "b/"+path, splitLines(got), splitLines(want))
+		if _, err := os.Stdout.WriteString(diff); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, path := range orphanSources(basedir, files) {
+		got, err := ioutil.ReadFile(filepath.Join(basedir, filepath.FromSlash(path)))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		stale++
+		diff := unifiedDiff("a/"+path, "/dev/null", splitLines(got), nil)
+		if _, err := os.Stdout.WriteString(diff); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return stale
+}
+
+// orphanSources returns the paths of generated files in basedir, which are
+// not in files, yet which do share a directory and an extension with any of
+// the files. Directories without any files, like those of removed packages,
+// are not inspected.
+func orphanSources(basedir string, files colfer.Sources) []string {
+	// extensions per directory
+	exts := make(map[string]map[string]bool)
+	for name := range files {
+		dir := path.Dir(name)
+		if exts[dir] == nil {
+			exts[dir] = make(map[string]bool)
+		}
+		exts[dir][path.Ext(name)] = true
+	}
+
+	var orphans []string
+	for dir, dirExts := range exts {
+		infos, err := ioutil.ReadDir(filepath.Join(basedir, filepath.FromSlash(dir)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Fatal(err)
+		}
+
+		for _, info := range infos {
+			name := path.Join(dir, info.Name())
+			if !info.Mode().IsRegular() || !dirExts[path.Ext(name)] {
+				continue
+			}
+			if _, ok := files[name]; ok {
+				continue
+			}
+
+			text, err := ioutil.ReadFile(filepath.Join(basedir, filepath.FromSlash(name)))
+			if err != nil {
+				log.Fatal(err)
+			}
+			// the mark is in the header
+			if len(text) > 512 {
+				text = text[:512]
+			}
+			if bytes.Contains(text, generatedMark) {
+				orphans = append(orphans, name)
+			}
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// splitLines returns each line of text, including the line feed.
+func splitLines(text []byte) []string {
+	var lines []string
+	for len(text) != 0 {
+		i := bytes.IndexByte(text, '\n') + 1
+		if i == 0 {
+			i = len(text)
+		}
+		lines = append(lines, string(text[:i]))
+		text = text[i:]
+	}
+	return lines
+}
+
+// edit is a diff line with ' ' for equal, '-' for deletion and '+' for
+// insertion.
+type edit struct {
+	op   byte
+	line string
+}
+
+// diffContext is the number of unchanged lines around each change.
+const diffContext = 3
+
+// diffEditMax is the edit distance at which the search gives up on a
+// minimal diff, to limit resource consumption.
+const diffEditMax = 2000
+
+// unifiedDiff returns the difference from a to b in the unified format.
+func unifiedDiff(aName, bName string, a, b []string) string {
+	edits := diffLines(a, b)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+
+	// line numbers before each edit
+	aLine := make([]int, len(edits)+1)
+	bLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.op != '+' {
+			aLine[i+1]++
+		}
+		if e.op != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for {
+			for end < len(edits) && edits[end].op != ' ' {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		stop := end + diffContext
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		buf.WriteString("@@ -" + hunkRange(aLine[start], aLine[stop]-aLine[start]))
+		buf.WriteString(" +" + hunkRange(bLine[start], bLine[stop]-bLine[start]) + " @@\n")
+		for _, e := range edits[start:stop] {
+			buf.WriteByte(e.op)
+			buf.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return buf.String()
+}
+
+// hunkRange returns the line range notation for n lines after offset.
+func hunkRange(offset, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", offset)
+	case 1:
+		return fmt.Sprint(offset + 1)
+	default:
+		return fmt.Sprintf("%d,%d", offset+1, n)
+	}
+}
+
+// diffLines returns the edits from a to b. The script is minimal, conform
+// the Myers algorithm, unless the distance exceeds diffEditMax.
+func diffLines(a, b []string) []edit {
+	// common prefix and suffix
+	var head, tail []edit
+	for len(a) != 0 && len(b) != 0 && a[0] == b[0] {
+		head = append(head, edit{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) != 0 && len(b) != 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append(tail, edit{' ', a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	middle := myers(a, b)
+	if middle == nil {
+		for _, line := range a {
+			middle = append(middle, edit{'-', line})
+		}
+		for _, line := range b {
+			middle = append(middle, edit{'+', line})
+		}
+	}
+
+	edits := append(head, middle...)
+	for i := len(tail) - 1; i >= 0; i-- {
+		edits = append(edits, tail[i])
+	}
+	return edits
+}
+
+// myers returns the shortest edit script, or nil when the distance exceeds
+// diffEditMax.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+	max := n + m
+	if max > diffEditMax {
+		max = diffEditMax
+	}
+
+	// v has the furthest x per diagonal k, at index k+offset
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace has the state of v before each round d, limited to the
+	// diagonals in reach: trace[d][i] is v[i-d+offset-1]
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // insertion
+			} else {
+				x = v[offset+k-1] + 1 // deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return myersPath(a, b, trace, d)
+			}
+		}
+	}
+	return nil
+}
+
+// myersPath reconstructs the edits from the search state.
+func myersPath(a, b []string, trace [][]int, d int) []edit {
+	var reverse []edit
+	x, y := len(a), len(b)
+	for ; d > 0; d-- {
+		// state before round d
+		v := func(k int) int { return trace[d][k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reverse = append(reverse, edit{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			reverse = append(reverse, edit{'+', b[y]})
+		} else {
+			x--
+			reverse = append(reverse, edit{'-', a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reverse = append(reverse, edit{' ', a[x]})
+	}
+
+	edits := make([]edit, len(reverse))
+	for i, e := range reverse {
+		edits[len(edits)-1-i] = e
+	}
+	return edits
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numberLines returns the decimals from 1 to n with a line feed each.
+func numberLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%d\n", i+1)
+	}
+	return lines
+}
+
+// replaceLines returns a copy of lines with the replacement per line number.
+func replaceLines(lines []string, replacements map[int]string) []string {
+	c := append([]string(nil), lines...)
+	for n, s := range replacements {
+		c[n-1] = s
+	}
+	return c
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	golden := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{"equal", numberLines(9), numberLines(9), ""},
+		{"empty", nil, nil, ""},
+		{"new file", nil, []string{"x\n", "y\n"}, "@@ -0,0 +1,2 @@\n+x\n+y\n"},
+		{"removed file", []string{"x\n", "y\n"}, nil, "@@ -1,2 +0,0 @@\n-x\n-y\n"},
+		{"insertion", numberLines(20), append(append(numberLines(2), "new\n"), numberLines(20)[2:]...),
+			"@@ -1,5 +1,6 @@\n 1\n 2\n+new\n 3\n 4\n 5\n"},
+		{"deletion", numberLines(20), append(numberLines(9), numberLines(20)[10:]...),
+			"@@ -7,7 +7,6 @@\n 7\n 8\n 9\n-10\n 11\n 12\n 13\n"},
+		{"no newline at end", []string{"a\n", "b"}, []string{"a\n", "b\n"},
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"merged hunks", numberLines(20), replaceLines(numberLines(20), map[int]string{3: "three\n", 9: "nine\n"}),
+			"@@ -1,12 +1,12 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n 11\n 12\n"},
+		{"merged hunks at twice the context", numberLines(20), replaceLines(numberLines(20), map[int]string{3: "three\n", 10: "ten\n"}),
+			"@@ -1,13 +1,13 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n"},
+		{"separate hunks", numberLines(20), replaceLines(numberLines(20), map[int]string{3: "three\n", 12: "twelve\n"}),
+			"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+				"@@ -9,7 +9,7 @@\n 9\n 10\n 11\n-12\n+twelve\n 13\n 14\n 15\n"},
+	}
+	for _, gold := range golden {
+		got := unifiedDiff("a/x", "b/x", gold.a, gold.b)
+		want := "--- a/x\n+++ b/x\n" + gold.want
+		if got != want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", gold.name, got, want)
+		}
+	}
+}
+
+// verifyEdits checks whether the edits transform a into b.
+func verifyEdits(t *testing.T, a, b []string, edits []edit) {
+	var gotA, gotB []string
+	for _, e := range edits {
+		switch e.op {
+		case ' ':
+			gotA = append(gotA, e.line)
+			gotB = append(gotB, e.line)
+		case '-':
+			gotA = append(gotA, e.line)
+		case '+':
+			gotB = append(gotB, e.line)
+		default:
+			t.Fatalf("got operation %q", e.op)
+		}
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Fatalf("edits %q do not transform %q into %q", edits, a, b)
+	}
+}
+
+// editDistance returns the minimal number of insertions and deletions.
+func editDistance(a, b []string) int {
+	// longest common subsequence
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] > lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return len(a) + len(b) - 2*lcs[0][0]
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a'+random.Intn(4))) + "\n"
+		}
+		return lines
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomLines(), randomLines()
+		edits := diffLines(a, b)
+		verifyEdits(t, a, b, edits)
+
+		var n int
+		for _, e := range edits {
+			if e.op != ' ' {
+				n++
+			}
+		}
+		if want := editDistance(a, b); n != want {
+			t.Fatalf("%q to %q: got %d changes, want %d", a, b, n, want)
+		}
+	}
+}
+
+func TestDiffEditMax(t *testing.T) {
+	var a, b []string
+	for i := 0; i < diffEditMax; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+	if edits := myers(a, b); edits != nil {
+		t.Errorf("got %d edits beyond the maximum distance, want fallback", len(edits))
+	}
+
+	a = append([]string{"head\n"}, a...)
+	b = append([]string{"head\n"}, b...)
+	edits := diffLines(a, b)
+	verifyEdits(t, a, b, edits)
+	if edits[0] != (edit{' ', "head\n"}) {
+		t.Errorf("got first edit %q, want common prefix", edits[0])
+	}
+	// fallback deletes all before it inserts
+	for i, e := range edits[1:] {
+		want := byte('-')
+		if i >= diffEditMax {
+			want = '+'
+		}
+		if e.op != want {
+			t.Fatalf("edit %d: got %q, want %q", i+1, e.op, want)
+		}
+	}
+}
+
+func TestCheckExit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colf-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema := "package demo\n\ntype point struct {\n\tx int32\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "demo.colf"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, stderr, exit := runColf(t, dir, "-check", "Java", "demo.colf"); exit != 1 {
+		t.Errorf("check without code got exit status %d, want 1; stderr: %s", exit, stderr)
+	}
+
+	if _, stderr, exit := runColf(t, dir, "Java", "demo.colf"); exit != 0 {
+		t.Fatalf("generation got exit status %d; stderr: %s", exit, stderr)
+	}
+	if stdout, stderr, exit := runColf(t, dir, "-check", "Java", "demo.colf"); exit != 0 || stdout != "" {
+		t.Errorf("check after generation got exit status %d and output %q; stderr: %s", exit, stdout, stderr)
+	}
+
+	// stale code
+	file := filepath.Join(dir, "demo", "Point.java")
+	code, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, append(code, "// edit\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, exit := runColf(t, dir, "-check", "Java", "demo.colf")
+	if exit != 1 {
+		t.Errorf("check of stale code got exit status %d, want 1", exit)
+	}
+	if !strings.Contains(stdout, "--- a/demo/Point.java\n+++ b/demo/Point.java\n") || !strings.Contains(stdout, "-// edit\n") {
+		t.Errorf("check of stale code got output %q", stdout)
+	}
+	if !strings.Contains(stderr, "colf: 1 stale files") {
+		t.Errorf("check of stale code got standard error %q", stderr)
+	}
+	if err := ioutil.WriteFile(file, code, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a struct removed from the schema
+	orphan := strings.Replace(string(code), "Point", "Gone", -1)
+	if err := ioutil.WriteFile(filepath.Join(dir, "demo", "Gone.java"), []byte(orphan), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// not generated
+	if err := ioutil.WriteFile(filepath.Join(dir, "demo", "Manual.java"), []byte("class Manual {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, exit = runColf(t, dir, "-check", "Java", "demo.colf")
+	if exit != 1 {
+		t.Errorf("check with orphan got exit status %d, want 1", exit)
+	}
+	if !strings.Contains(stdout, "--- a/demo/Gone.java\n+++ /dev/null\n") {
+		t.Errorf("check with orphan got output %q", stdout)
+	}
+	if strings.Contains(stdout, "Manual") {
+		t.Errorf("check reported a file which is not generated: %q", stdout)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/pascaldekloe/colfer/dynamic"
+)
+
+// decodeCmd executes the decode command.
+func decodeCmd(args []string) {
+	flags := flag.NewFlagSet("decode", flag.ExitOnError)
+	input := flags.String("i", "", "Reads the data from a `file` instead of the standard input.")
+	encoding := flags.String("e", "binary", "Sets the input `encoding`. The options are binary, hex and\n    \tbase64. White space is ignored with hex and base64.")
+	compact := flags.Bool("c", false, "Prints each JSON document on a single line.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] decode [ decode options ] struct [ file ... ]\n\n")
+		os.Stderr.WriteString("Decode prints Colfer data as JSON, one document per serial.\n")
+		os.Stderr.WriteString("Concatenated serials are decoded in order of appearance.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	schema, err := dynamic.NewSchema(parsePackages(flags.Args()[1:]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	t, err := schema.Lookup(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := readInput(*input, *encoding)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report.Printf("Decoding %d bytes as %s", len(data), t)
+
+	var buf bytes.Buffer
+	for offset := 0; offset < len(data); {
+		v, n, err := schema.Unmarshal(t, data[offset:])
+		if err == io.EOF {
+			log.Fatalf("colf: incomplete %s serial at byte %d", t, offset)
+		}
+		if err != nil {
+			if i, ok := err.(dynamic.ColferError); ok {
+				err = dynamic.ColferError(offset + int(i))
+			}
+			log.Fatalf("colf: %s serial at byte %d: %s", t, offset, err)
+		}
+		offset += n
+
+		doc, err := schema.AppendJSON(nil, t, v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		buf.Reset()
+		if *compact {
+			buf.Write(doc)
+		} else if err := json.Indent(&buf, doc, "", "\t"); err != nil {
+			log.Fatal(err)
+		}
+		buf.WriteByte('\n')
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// readInput returns the data from file, or the standard input when file is
+// the empty string.
+func readInput(file, encoding string) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == "" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(encoding) {
+	case "binary", "raw":
+		return data, nil
+
+	case "hex":
+		s := strings.TrimPrefix(stripSpace(string(data)), "0x")
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("colf: malformed hex input: %s", err)
+		}
+		return data, nil
+
+	case "base64":
+		s := stripSpace(string(data))
+		enc := base64.StdEncoding
+		if strings.ContainsAny(s, "-_") {
+			enc = base64.URLEncoding
+		}
+		if !strings.HasSuffix(s, "=") && len(s)%4 != 0 {
+			enc = enc.WithPadding(base64.NoPadding)
+		}
+		data, err := enc.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("colf: malformed base64 input: %s", err)
+		}
+		return data, nil
+
+	default:
+		return nil, fmt.Errorf("colf: unsupported encoding %q", encoding)
+	}
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
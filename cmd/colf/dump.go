@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pascaldekloe/colfer"
+	"github.com/pascaldekloe/colfer/dynamic"
+)
+
+// dumpCmd executes the dump command.
+func dumpCmd(args []string) {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	input := flags.String("i", "", "Reads the data from a `file` instead of the standard input.")
+	encoding := flags.String("e", "binary", "Sets the input `encoding`. The options are binary, hex and\n    \tbase64. White space is ignored with hex and base64.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] dump [ dump options ] [ struct [ file ... ] ]\n\n")
+		os.Stderr.WriteString("Dump prints Colfer data as an annotated hex listing, one section per\n")
+		os.Stderr.WriteString("serial. Decoding failures are marked at the byte of occurrence. Without\n")
+		os.Stderr.WriteString("a struct the field values are guessed, as Colfer is not self-describing.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var schema *dynamic.Schema
+	var t *colfer.Struct
+	if flags.NArg() != 0 {
+		var err error
+		schema, err = dynamic.NewSchema(parsePackages(flags.Args()[1:]))
+		if err != nil {
+			log.Fatal(err)
+		}
+		t, err = schema.Lookup(flags.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	data, err := readInput(*input, *encoding)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	for offset := 0; offset < len(data); {
+		if offset != 0 {
+			w.WriteByte('\n')
+		}
+
+		var n int
+		var err error
+		if t == nil {
+			fmt.Fprintf(w, "serial at byte %d:\n", offset)
+			n, err = dynamic.DumpRaw(w, data[offset:])
+		} else {
+			fmt.Fprintf(w, "%s serial at byte %d:\n", t, offset)
+			n, err = schema.Dump(w, t, data[offset:])
+		}
+		if err != nil {
+			if ferr := w.Flush(); ferr != nil {
+				log.Fatal(ferr)
+			}
+			switch e := err.(type) {
+			case dynamic.ColferError:
+				err = dynamic.ColferError(offset + int(e))
+			default:
+				if err == io.EOF {
+					log.Fatalf("colf: incomplete serial at byte %d", offset)
+				}
+			}
+			log.Fatalf("colf: serial at byte %d: %s", offset, err)
+		}
+		offset += n
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
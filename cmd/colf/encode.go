@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pascaldekloe/colfer/dynamic"
+)
+
+// encodeCmd executes the encode command.
+func encodeCmd(args []string) {
+	flags := flag.NewFlagSet("encode", flag.ExitOnError)
+	input := flags.String("i", "", "Reads the JSON from a `file` instead of the standard input.")
+	output := flags.String("o", "", "Writes the data to a `file` instead of the standard output.")
+	encoding := flags.String("e", "binary", "Sets the output `encoding`. The options are binary, hex and\n    \tbase64. Hex and base64 put each serial on a separate line.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] encode [ encode options ] struct [ file ... ]\n\n")
+		os.Stderr.WriteString("Encode writes JSON documents as Colfer data, one serial per document.\n")
+		os.Stderr.WriteString("The serials are concatenated in order of appearance.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var lineEnc func([]byte) string
+	switch strings.ToLower(*encoding) {
+	case "binary", "raw":
+	case "hex":
+		lineEnc = hex.EncodeToString
+	case "base64":
+		lineEnc = base64.StdEncoding.EncodeToString
+	default:
+		log.Fatalf("colf: unsupported encoding %q", *encoding)
+	}
+
+	schema, err := dynamic.NewSchema(parsePackages(flags.Args()[1:]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	t, err := schema.Lookup(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := io.Reader(os.Stdin)
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	w := bufio.NewWriter(out)
+
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+	var serial []byte
+	for n := 1; ; n++ {
+		var doc interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatalf("colf: JSON document %d: %s", n, err)
+		}
+
+		v, err := schema.FromJSON(t, doc)
+		if err != nil {
+			log.Fatalf("colf: JSON document %d: %s", n, err)
+		}
+		serial, err = schema.MarshalAppend(serial[:0], t, v)
+		if err != nil {
+			log.Fatalf("colf: JSON document %d: %s", n, err)
+		}
+		report.Printf("Encoded JSON document %d as %d bytes", n, len(serial))
+
+		if lineEnc == nil {
+			w.Write(serial)
+		} else {
+			w.WriteString(lineEnc(serial))
+			w.WriteByte('\n')
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// graphCmd executes the graph command.
+func graphCmd(args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Prints the graph as a JSON object instead of DOT.")
+	pkgLevel := flags.Bool("packages", false, "Prints the package references instead of the struct references.\n    \tJSON always has both.")
+	rootList := flags.String("roots", "", "Sets a comma separated `list` of qualified struct names as the\n    \tentry points for the reachability analysis. The default is all\n    \tstructs which are not referenced from outside of their cycle.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] graph [ graph options ] [ file ... ]\n\n")
+		os.Stderr.WriteString("Graph prints the reference graph of the schemas in the DOT language of\n")
+		os.Stderr.WriteString("Graphviz. Each node has the fan-in, which is the number of other\n")
+		os.Stderr.WriteString("definitions which reference it. Cycles are red and structs which can\n")
+		os.Stderr.WriteString("not be reached from the roots are dashed.\n\n")
+		os.Stderr.WriteString("\tcolf graph schemas | dot -Tsvg > schemas.svg\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	packages := parsePackages(flags.Args())
+	var roots []*colfer.Struct
+	for _, name := range strings.Split(*rootList, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		s := lookupStruct(packages, name)
+		if s == nil {
+			log.Fatalf("colf: root %q not found", name)
+		}
+		roots = append(roots, s)
+	}
+	g := colfer.Graph(packages, roots)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(g); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if *pkgLevel {
+		writePackageDOT(w, g)
+	} else {
+		writeStructDOT(w, g)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// lookupStruct returns the definition with the qualified name, if any.
+func lookupStruct(packages colfer.Packages, name string) *colfer.Struct {
+	for _, p := range packages {
+		for _, s := range p.Structs {
+			if s.String() == name {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// writeStructDOT prints the struct references with a cluster per package.
+func writeStructDOT(w *bufio.Writer, g *colfer.RefGraph) {
+	cycleOf := cycleIndex(g.Cycles)
+
+	w.WriteString("digraph colfer {\n\tnode [shape=box];\n")
+	var pkg string
+	for _, n := range g.Structs {
+		i := strings.LastIndexByte(n.Struct, '.')
+		if p := n.Struct[:i]; p != pkg {
+			if pkg != "" {
+				w.WriteString("\t}\n")
+			}
+			pkg = p
+			fmt.Fprintf(w, "\tsubgraph %q {\n\t\tlabel=%q;\n", "cluster_"+pkg, pkg)
+		}
+
+		attrs := fmt.Sprintf("label=\"%s\\nfan-in %d\"", n.Struct[i+1:], n.FanIn)
+		if n.Cyclic {
+			attrs += ", color=red"
+		}
+		if n.Unreachable {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(w, "\t\t%q [%s];\n", n.Struct, attrs)
+	}
+	if pkg != "" {
+		w.WriteString("\t}\n")
+	}
+
+	for _, n := range g.Structs {
+		for i, ref := range n.Refs {
+			attrs := fmt.Sprintf("label=%q", strings.Join(n.Fields[i], ", "))
+			if sameCycle(cycleOf, n.Struct, ref) {
+				attrs += ", color=red"
+			}
+			fmt.Fprintf(w, "\t%q -> %q [%s];\n", n.Struct, ref, attrs)
+		}
+	}
+	w.WriteString("}\n")
+}
+
+// writePackageDOT prints the package references.
+func writePackageDOT(w *bufio.Writer, g *colfer.RefGraph) {
+	cycleOf := cycleIndex(g.PackageCycles)
+
+	w.WriteString("digraph colfer {\n\tnode [shape=box];\n")
+	for _, n := range g.Packages {
+		attrs := fmt.Sprintf("label=\"%s\\nfan-in %d\"", n.Package, n.FanIn)
+		if n.Cyclic {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(w, "\t%q [%s];\n", n.Package, attrs)
+	}
+	for _, n := range g.Packages {
+		for _, ref := range n.Refs {
+			if sameCycle(cycleOf, n.Package, ref) {
+				fmt.Fprintf(w, "\t%q -> %q [color=red];\n", n.Package, ref)
+			} else {
+				fmt.Fprintf(w, "\t%q -> %q;\n", n.Package, ref)
+			}
+		}
+	}
+	w.WriteString("}\n")
+}
+
+// cycleIndex maps each name to the index of its cycle.
+func cycleIndex(cycles [][]string) map[string]int {
+	m := make(map[string]int)
+	for i, c := range cycles {
+		for _, name := range c {
+			m[name] = i
+		}
+	}
+	return m
+}
+
+// sameCycle returns whether both names are in the same cycle.
+func sameCycle(cycleOf map[string]int, a, b string) bool {
+	i, ok := cycleOf[a]
+	j, ok2 := cycleOf[b]
+	return ok && ok2 && i == j
+}
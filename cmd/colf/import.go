@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pascaldekloe/colfer/gotype"
+	"github.com/pascaldekloe/colfer/proto"
+)
+
+// importCmd executes the import command.
+func importCmd(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	strict := flags.Bool("strict", false, "Exits 1 when any construct was omitted or mapped with loss.")
+	typeList := flags.String("types", "", "Selects a comma separated `list` of Go struct types. The default\nincludes all exported struct types.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] import [ import options ] file ...\n\n")
+		os.Stderr.WriteString("Import converts Protocol Buffers schemas into Colfer schemas. Each\n")
+		os.Stderr.WriteString("file is written to the base directory with the colf extension.\n")
+		os.Stderr.WriteString("Directory operands are read as a Go package instead. The struct types\n")
+		os.Stderr.WriteString("are written, including their references, to a file named after the\n")
+		os.Stderr.WriteString("directory.\n")
+		os.Stderr.WriteString("Constructs without Colfer equivalent are reported with their position\n")
+		os.Stderr.WriteString("to the standard error.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := os.MkdirAll(*basedir, os.ModeDir|os.ModePerm); err != nil {
+		log.Fatal(err)
+	}
+
+	var typeNames []string
+	for _, name := range strings.Split(*typeList, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			typeNames = append(typeNames, name)
+		}
+	}
+
+	var issueCount int
+	for _, file := range flags.Args() {
+		var schema []byte
+		var dest string
+		if info, err := os.Stat(file); err != nil {
+			log.Fatal(err)
+		} else if info.IsDir() {
+			var issues []gotype.Issue
+			schema, issues, err = gotype.Convert(file, typeNames...)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, issue := range issues {
+				log.Print(issue)
+			}
+			issueCount += len(issues)
+
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			dest = filepath.Join(*basedir, filepath.Base(abs)+".colf")
+		} else {
+			src, err := ioutil.ReadFile(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			var issues []proto.Issue
+			schema, issues, err = proto.Convert(file, src)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, issue := range issues {
+				log.Print(issue)
+			}
+			issueCount += len(issues)
+
+			base := filepath.Base(file)
+			dest = filepath.Join(*basedir, strings.TrimSuffix(base, filepath.Ext(base))+".colf")
+		}
+
+		if err := ioutil.WriteFile(dest, schema, 0666); err != nil {
+			log.Fatal(err)
+		}
+		report.Printf("Converted %s to %s", file, dest)
+	}
+
+	if *strict && issueCount != 0 {
+		os.Exit(1)
+	}
+}
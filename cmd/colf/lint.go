@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// lintCmd executes the lint command.
+func lintCmd(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Prints the findings as a JSON array.")
+	skip := flags.String("skip", "", "Disables a comma separated `list` of rules.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] lint [ lint options ] [ file ... ]\n\n")
+		os.Stderr.WriteString("Lint checks schemas for style and portability issues. Each finding is\n")
+		os.Stderr.WriteString("printed on a line as \"file:line:column: subject: message [rule]\". The\n")
+		os.Stderr.WriteString("rules are " + colfer.LintKeyword + ", " + colfer.LintCase + ", " + colfer.LintDoc + ", " + colfer.LintRecursion + " and " + colfer.LintJSInt + ".\n")
+		os.Stderr.WriteString("The command exits 1 when any findings remain.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	skipped := make(map[string]bool)
+	for _, rule := range strings.Split(*skip, ",") {
+		rule = strings.TrimSpace(rule)
+		switch rule {
+		case "":
+			continue
+		case colfer.LintKeyword, colfer.LintCase, colfer.LintDoc, colfer.LintRecursion, colfer.LintJSInt:
+			skipped[rule] = true
+		default:
+			os.Stderr.WriteString("colf: unknown lint rule " + strconv.Quote(rule) + "\n")
+			os.Exit(2)
+		}
+	}
+
+	findings := make([]*colfer.Finding, 0)
+	for _, f := range colfer.Lint(parsePackages(flags.Args())) {
+		if !skipped[f.Rule] {
+			findings = append(findings, f)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(findings); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, f := range findings {
+			if _, err := os.Stdout.WriteString(f.String() + "\n"); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	if len(findings) != 0 {
+		os.Exit(1)
+	}
+}
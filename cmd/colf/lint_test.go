@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colf-lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema := "package demo\n\n// Point is documented.\ntype point struct {\n\tx int32\n\tid int64\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "demo.colf"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, exit := runColf(t, dir, "lint", "demo.colf")
+	if exit != 1 {
+		t.Errorf("lint got exit status %d, want 1; stderr: %s", exit, stderr)
+	}
+	if !strings.HasPrefix(stdout, "demo.colf:6:2: demo.point.id: ") || !strings.HasSuffix(stdout, " [js-int]\n") {
+		t.Errorf("lint got output %q, want the js-int finding at line 6, column 2", stdout)
+	}
+
+	if stdout, stderr, exit := runColf(t, dir, "lint", "-skip", "doc, js-int", "demo.colf"); exit != 0 || stdout != "" {
+		t.Errorf("lint with skip got exit status %d and output %q; stderr: %s", exit, stdout, stderr)
+	}
+
+	_, stderr, exit = runColf(t, dir, "lint", "-skip", "js-int,jsint", "demo.colf")
+	if exit != 2 || !strings.Contains(stderr, `unknown lint rule "jsint"`) {
+		t.Errorf("lint with an unknown rule got exit status %d and error %q, want 2 and the rule name", exit, stderr)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/pascaldekloe/colfer/lsp"
+)
+
+// lspCmd executes the lsp command.
+func lspCmd(args []string) {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] lsp\n\n")
+		os.Stderr.WriteString("Lsp runs a Language Server Protocol service on the standard input and\n")
+		os.Stderr.WriteString("output for editor integration. The service provides diagnostics,\n")
+		os.Stderr.WriteString("go-to-definition, hover, completion and formatting.\n")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
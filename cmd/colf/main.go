@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/pascaldekloe/colfer"
+	"github.com/pascaldekloe/colfer/plugin"
 )
 
 var (
@@ -18,10 +19,15 @@ var (
 	format  = flag.Bool("f", false, "Normalizes schemas on the fly.")
 	verbose = flag.Bool("v", false, "Enables verbose reporting to the standard error.")
 
-	sizeMax = flag.String("s", "16 * 1024 * 1024", "Sets the default upper limit for serial byte sizes. The\n    \t`expression` is applied to the target language under the name\n    \tColferSizeMax.")
-	listMax = flag.String("l", "64 * 1024", "Sets the default upper limit for the number of elements in a\n    \tlist. The `expression` is applied to the target language under\n    \tthe name ColferListMax.")
+	sizeMax = flag.String("s", colfer.DefaultSizeMax, "Sets the default upper limit for serial byte sizes. The\n    \t`expression` is applied to the target language under the name\n    \tColferSizeMax. Schema directives take precedence.")
+	listMax = flag.String("l", colfer.DefaultListMax, "Sets the default upper limit for the number of elements in a\n    \tlist. The `expression` is applied to the target language under\n    \tthe name ColferListMax. Schema directives take precedence.")
 
 	superClass = flag.String("x", "", "Makes all generated classes extend a super `class`. Use slash as\n    \ta package separator. Java only.")
+	zeroCopy   = flag.Bool("z", false, "Makes unmarshalled text and binaries share memory with the serial\n    \tdata, instead of copying. See Unmarshal of the generated code for\n    \tthe lifetime rules. Go only.")
+	reuse      = flag.Bool("r", false, "Makes Unmarshal recycle the lists, binaries and nested data\n    \tstructures of previous content, and adds a sync.Pool per data\n    \tstructure. Go only.")
+	valueTypes = flag.Bool("e", false, "Embeds data structures as values instead of pointers in lists\n    \tand fields, with the elements of a list in one allocation. Fields\n    \twhich would contain themselves remain pointers. Go only.")
+
+	check = flag.Bool("check", false, "Compares the generated code with the files in the base directory\n    \tinstead of writing. Differences are printed as a unified diff.\n    \tGenerated files which are no longer produced count as stale.")
 )
 
 var report = log.New(ioutil.Discard, "", 0)
@@ -34,50 +40,101 @@ func main() {
 		report.SetOutput(os.Stderr)
 	}
 
-	var files []string
-	switch args := flag.Args(); len(args) {
-	case 0:
+	if flag.NArg() == 0 {
 		flag.Usage()
 		os.Exit(2)
-	case 1:
-		files = []string{"."}
-	default:
-		files = args[1:]
-	}
-
-	// select language
-	var gen func(string, colfer.Packages) error
-	switch lang := flag.Arg(0); strings.ToLower(lang) {
-	case "c":
-		report.Println("Set up for C")
-		gen = colfer.GenerateC
-		if *superClass != "" {
-			log.Fatal("colf: super class not supported with C")
-		}
+	}
 
-	case "go":
-		report.Println("Set up for Go")
-		gen = colfer.GenerateGo
-		if *superClass != "" {
-			log.Fatal("colf: super class not supported with Go")
-		}
+	switch strings.ToLower(flag.Arg(0)) {
+	case "decode":
+		decodeCmd(flag.Args()[1:])
+		return
+	case "encode":
+		encodeCmd(flag.Args()[1:])
+		return
+	case "dump":
+		dumpCmd(flag.Args()[1:])
+		return
+	case "lint":
+		lintCmd(flag.Args()[1:])
+		return
+	case "import":
+		importCmd(flag.Args()[1:])
+		return
+	case "lsp":
+		lspCmd(flag.Args()[1:])
+		return
+	case "build":
+		buildCmd(flag.Args()[1:])
+		return
+	case "size":
+		sizeCmd(flag.Args()[1:])
+		return
+	case "graph":
+		graphCmd(flag.Args()[1:])
+		return
+	}
 
-	case "java":
-		report.Println("Set up for Java")
-		gen = colfer.GenerateJava
+	lang := flag.Arg(0)
+	files := schemaFiles(flag.Args()[1:])
+	formatFiles(files)
+	opts := options(lang, *prefix, *sizeMax, *listMax, *superClass)
+	opts.ZeroCopy = *zeroCopy
+	opts.Reuse = *reuse
+	opts.ValueTypes = *valueTypes
+	sources, err := colfer.Compile(files, nil, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	case "javascript", "js", "ecmascript":
-		report.Println("Set up for ECMAScript")
-		gen = colfer.GenerateECMA
-		if *superClass != "" {
-			log.Fatal("colf: super class not supported with ECMAScript")
+	if *check {
+		if stale := checkSources(*basedir, sources); stale != 0 {
+			log.Printf("colf: %d stale files in %s", stale, *basedir)
+			os.Exit(1)
 		}
+		return
+	}
+
+	if err := sources.Write(*basedir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// options returns the compilation settings, with plugins for languages
+// without built-in support.
+func options(lang, prefix, sizeMax, listMax, superClass string) colfer.Options {
+	opts := colfer.Options{
+		Lang:       lang,
+		Prefix:     prefix,
+		SizeMax:    sizeMax,
+		ListMax:    listMax,
+		SuperClass: superClass,
+	}
+	if colfer.LangSources(lang) != nil {
+		report.Println("Set up for", lang)
+		return opts
+	}
 
-	default:
+	file, err := plugin.Lookup(lang)
+	if err != nil {
 		log.Fatalf("colf: unsupported language %q", lang)
 	}
+	report.Println("Set up for plugin", file)
+	opts.Generator = func(packages colfer.Packages) (colfer.Sources, error) {
+		return plugin.Run(lang, packages)
+	}
+	return opts
+}
+
+// schemaFiles resolves the operands into a clean file set. Directories are
+// scanned for files with the colf extension. No operands includes the
+// working directory.
+func schemaFiles(args []string) []string {
+	files := append([]string(nil), args...)
+	if len(files) == 0 {
+		files = []string{"."}
+	}
 
-	// resolve clean file set
 	var writeIndex int
 	for i := 0; i < len(files); i++ {
 		f := files[i]
@@ -110,37 +167,58 @@ func main() {
 	}
 	files = files[:writeIndex]
 	report.Println("Found schema files", strings.Join(files, ", "))
+	return files
+}
 
+// parsePackages returns the schema definitions from the operands with the
+// options applied.
+func parsePackages(args []string) colfer.Packages {
+	packages := loadPackages(schemaFiles(args))
+	for _, p := range packages {
+		p.Name = path.Join(*prefix, p.Name)
+		if p.SizeMax == "" {
+			p.SizeMax = *sizeMax
+		}
+		if p.ListMax == "" {
+			p.ListMax = *listMax
+		}
+		p.SuperClass = *superClass
+		p.ZeroCopy = *zeroCopy
+		p.Reuse = *reuse
+		p.ValueTypes = *valueTypes
+	}
+	return packages
+}
+
+// loadPackages returns the schema definitions from files.
+func loadPackages(files []string) colfer.Packages {
+	formatFiles(files)
 	packages, err := colfer.ParseFiles(files)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	if *format {
-		for _, file := range files {
-			changed, err := colfer.Format(file)
-			if err != nil {
-				log.Fatal(err)
-			}
-			if changed {
-				log.Println("colf: formatted", file)
-			}
-		}
-	}
-
 	if len(packages) == 0 {
 		log.Fatal("colf: no struct definitons found")
 	}
+	return packages
+}
 
-	for _, p := range packages {
-		p.Name = path.Join(*prefix, p.Name)
-		p.SizeMax = *sizeMax
-		p.ListMax = *listMax
-		p.SuperClass = *superClass
+// formatFiles normalizes the schema files when requested with option -f.
+func formatFiles(files []string) {
+	if !*format {
+		return
 	}
-
-	if err := gen(*basedir, packages); err != nil {
-		log.Fatal(err)
+	if *check {
+		log.Fatal("colf: schema normalization (-f) not allowed in check mode")
+	}
+	for _, file := range files {
+		changed, err := colfer.Format(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if changed {
+			log.Println("colf: formatted", file)
+		}
 	}
 }
 
@@ -157,6 +235,29 @@ func init() {
 	help := bold + "NAME\n\t" + cmd + clear + " \u2014 compile Colfer schemas\n\n"
 	help += bold + "SYNOPSIS\n\t" + cmd + clear
 	help += " [ " + underline + "options" + clear + " ] " + underline + "language" + clear
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "decode" + clear
+	help += " [ " + underline + "decode options" + clear + " ] " + underline + "struct" + clear
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "encode" + clear
+	help += " [ " + underline + "encode options" + clear + " ] " + underline + "struct" + clear
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "dump" + clear
+	help += " [ " + underline + "dump options" + clear + " ] [ " + underline + "struct" + clear
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ] ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "lint" + clear
+	help += " [ " + underline + "lint options" + clear + " ]"
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "import" + clear
+	help += " [ " + underline + "import options" + clear + " ] " + underline + "file" + clear + " " + underline + "..." + clear + "\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "lsp" + clear + "\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "build" + clear
+	help += " [ " + underline + "build options" + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "size" + clear
+	help += " [ " + underline + "size options" + clear + " ]"
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " [ " + underline + "options" + clear + " ] " + bold + "graph" + clear
+	help += " [ " + underline + "graph options" + clear + " ]"
 	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n\n"
 	help += bold + "DESCRIPTION\n\t" + clear
 	help += "Generates source code for a " + underline + "language" + clear + ". The options are: "
@@ -165,23 +266,57 @@ func init() {
 	help += "\tThe " + underline + "file" + clear + " operands specify the input. Directories are scanned for\n"
 	help += "\tfiles with the colf extension. If " + underline + "file" + clear + " is absent, " + cmd + " includes\n"
 	help += "\tthe working directory.\n"
-	help += "\tA package can have multiple schema files.\n\n"
+	help += "\tA package can have multiple schema files.\n"
+	help += "\tThe " + bold + "doc" + clear + " target writes a reference in Markdown to file Colfer.md.\n"
+	help += "\tThe " + bold + "HTML" + clear + " target writes the same reference to file Colfer.html.\n"
+	help += "\tThe " + bold + "proto" + clear + " and " + bold + "fbs" + clear + " targets write equivalent Protocol Buffers\n"
+	help += "\t(proto3) and FlatBuffers schemas to files Colfer.proto and Colfer.fbs.\n"
+	help += "\tThe " + bold + "jsonschema" + clear + " target writes a JSON Schema for each struct, conform\n"
+	help += "\tthe decode and encode mapping, to files named " + underline + "struct" + clear + ".schema.json.\n"
+	help += "\tAny other " + underline + "language" + clear + " is delegated to a plugin executable named\n"
+	help += "\tcolf-gen-" + underline + "language" + clear + ", in lower case, as found in PATH. Plugins get the\n"
+	help += "\tschemas in Colfer format on the standard input and they reply with\n"
+	help += "\tthe generated files. See package plugin for the protocol.\n\n"
+	help += "\tThe " + bold + "decode" + clear + " command prints Colfer data as JSON conform the data\n"
+	help += "\tstructure named " + underline + "struct" + clear + ". Run " + cmd + " decode -h for the decode options.\n"
+	help += "\tThe " + bold + "encode" + clear + " command does the inverse with JSON documents as input.\n"
+	help += "\tRun " + cmd + " encode -h for the encode options.\n"
+	help += "\tThe " + bold + "dump" + clear + " command prints an annotated hex listing of Colfer data\n"
+	help += "\tfor troubleshooting. Run " + cmd + " dump -h for the dump options.\n"
+	help += "\tThe " + bold + "lint" + clear + " command checks schemas for style and portability issues.\n"
+	help += "\tRun " + cmd + " lint -h for the lint options.\n"
+	help += "\tThe " + bold + "import" + clear + " command converts Protocol Buffers schemas into Colfer\n"
+	help += "\tschemas. Directory operands convert the struct types of a Go package.\n"
+	help += "\tRun " + cmd + " import -h for the import options.\n"
+	help += "\tThe " + bold + "lsp" + clear + " command serves the Language Server Protocol on the standard\n"
+	help += "\tinput and output for editor integration.\n"
+	help += "\tThe " + bold + "build" + clear + " command compiles all targets from a project configuration\n"
+	help += "\tfile, colf.json by default. Run " + cmd + " build -h for the file format.\n"
+	help += "\tThe " + bold + "size" + clear + " command prints the serial size range of each struct.\n"
+	help += "\tRun " + cmd + " size -h for the size options.\n"
+	help += "\tThe " + bold + "graph" + clear + " command prints the reference graph in DOT or JSON, with\n"
+	help += "\tcycles, unreachable structs and the fan-in per definition.\n"
+	help += "\tRun " + cmd + " graph -h for the graph options.\n\n"
 	help += bold + "OPTIONS\n" + clear
 
 	tail := "\n" + bold + "EXIT STATUS" + clear + "\n"
-	tail += "\tThe command exits 0 on succes, 1 on compilation failure and 2\n"
-	tail += "\twhen invoked without arguments.\n"
+	tail += "\tThe command exits 0 on succes, 1 on compilation failure or stale\n"
+	tail += "\tcode in check mode, and 2 when invoked without arguments.\n"
 	tail += "\n" + bold + "EXAMPLES" + clear + "\n"
 	tail += "\tCompile ./io.colf with compact limits as C:\n\n"
 	tail += "\t\t" + cmd + " -b src -s 2048 -l 96 C io.colf\n\n"
 	tail += "\tCompile ./api/*.colf in package com.example as Java:\n\n"
-	tail += "\t\t" + cmd + " -p com/example -x com/example/Parent Java api\n"
+	tail += "\t\t" + cmd + " -p com/example -x com/example/Parent Java api\n\n"
+	tail += "\tVerify that the Go code for ./api/*.colf is up to date:\n\n"
+	tail += "\t\t" + cmd + " -check Go api\n\n"
+	tail += "\tCompile the targets in ./colf.json:\n\n"
+	tail += "\t\t" + cmd + " build\n"
 	tail += "\n" + bold + "BUGS" + clear + "\n"
 	tail += "\tReport bugs at https://github.com/pascaldekloe/colfer/issues\n\n"
 	tail += "\tText validation is not part of the marshalling and unmarshalling\n"
 	tail += "\tprocess. C and Go just pass any malformed UTF-8 characters. Java\n"
 	tail += "\tand JavaScript replace unmappable content with the '?' character\n"
-	tail += "\t(ASCII 63).\n\n"
+	tail += "\t(ASCII 63) on marshal, and malformed UTF-8 with U+FFFD on unmarshal.\n\n"
 	tail += bold + "SEE ALSO\n\t" + clear + "protoc(1)\n"
 
 	flag.Usage = func() {
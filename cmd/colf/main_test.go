@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+// TestMain runs the test binary as colf(1) when so requested by runColf.
+func TestMain(m *testing.M) {
+	if os.Getenv("COLF_MAIN_TEST") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runColf executes the command in dir, and it returns the standard output,
+// the standard error and the exit status.
+func runColf(t *testing.T, dir string, args ...string) (stdout, stderr string, exit int) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "COLF_MAIN_TEST=1")
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	err := cmd.Run()
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatal(err)
+		}
+		status, ok := exitErr.Sys().(syscall.WaitStatus)
+		if !ok {
+			t.Fatal(err)
+		}
+		exit = status.ExitStatus()
+	}
+	return outBuf.String(), errBuf.String(), exit
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+// structSize is the JSON representation of a size analysis.
+type structSize struct {
+	Struct    string `json:"struct"`
+	Min       int    `json:"min"`
+	Max       int    `json:"max"`
+	Fixed     bool   `json:"fixed"`
+	Unbounded bool   `json:"unbounded"`
+}
+
+// sizeCmd executes the size command.
+func sizeCmd(args []string) {
+	flags := flag.NewFlagSet("size", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Prints the analysis as a JSON array.")
+	flags.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " [ options ] size [ size options ] [ file ... ]\n\n")
+		os.Stderr.WriteString("Size prints the minimum and the maximum serial size in bytes of each\n")
+		os.Stderr.WriteString("struct. The maximum is fixed when the struct has no text, no binaries\n")
+		os.Stderr.WriteString("and no lists. Otherwise the list and size limits apply. Recursive\n")
+		os.Stderr.WriteString("structs are unbounded, except for the size limit.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	sizes := make([]*structSize, 0)
+	for _, p := range parsePackages(flags.Args()) {
+		for _, s := range p.Structs {
+			b, err := s.SizeBounds()
+			if err != nil {
+				log.Fatal(err)
+			}
+			sizes = append(sizes, &structSize{
+				Struct:    s.String(),
+				Min:       b.Min,
+				Max:       b.Max,
+				Fixed:     b.Fixed,
+				Unbounded: b.Unbounded,
+			})
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(sizes); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprint(w, "STRUCT\tMIN\tMAX\tBOUND\n")
+	for _, s := range sizes {
+		bound := "limits"
+		switch {
+		case s.Fixed:
+			bound = "fixed"
+		case s.Unbounded:
+			bound = "recursion"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", s.Struct, s.Min, s.Max, bound)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
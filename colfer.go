@@ -4,6 +4,9 @@ package colfer
 import (
 	"bytes"
 	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
 	"sort"
 	"strings"
 )
@@ -40,6 +43,72 @@ func (p Packages) HasTimestamp() bool {
 	return false
 }
 
+// HasFloat returns whether any of the packages has one or more floating point
+// fields.
+func (p Packages) HasFloat() bool {
+	for _, o := range p {
+		if o.HasFloat() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNumber returns whether any of the packages has one or more integer or
+// floating point fields.
+func (p Packages) HasNumber() bool {
+	for _, o := range p {
+		if o.HasNumber() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasText returns whether any of the packages has one or more text fields.
+func (p Packages) HasText() bool {
+	for _, o := range p {
+		if o.HasText() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBinary returns whether any of the packages has one or more binary fields.
+func (p Packages) HasBinary() bool {
+	for _, o := range p {
+		if o.HasBinary() {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalLimit returns the value of a limit expression, like "64 * 1024".
+// The empty expression evaluates to def.
+func EvalLimit(expr string, def int) (int, error) {
+	if strings.TrimSpace(expr) == "" {
+		return def, nil
+	}
+
+	tv, err := types.Eval(token.NewFileSet(), nil, token.NoPos, expr)
+	if err != nil {
+		return 0, err
+	}
+	if tv.Value == nil {
+		return 0, fmt.Errorf("expression %q is not constant", expr)
+	}
+	v, exact := constant.Int64Val(constant.ToInt(tv.Value))
+	if !exact || int64(int(v)) != v {
+		return 0, fmt.Errorf("expression %q is not an integer", expr)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("expression %q is not positive", expr)
+	}
+	return int(v), nil
+}
+
 // Package is a named definition bundle.
 type Package struct {
 	// Name is the identification token.
@@ -52,14 +121,33 @@ type Package struct {
 	Structs []*Struct
 	// SchemaFiles are the source filenames.
 	SchemaFiles []string
+	// Pos is the location of Name in the first schema file.
+	Pos token.Position
 	// SizeMax is the uper limit expression.
 	SizeMax string
 	// ListMax is the uper limit expression.
 	ListMax string
+	// SizeMaxDirective is set when SizeMax is from the schema.
+	SizeMaxDirective bool
+	// ListMaxDirective is set when ListMax is from the schema.
+	ListMaxDirective bool
+	// SizeMaxNative is the language specific name of SizeMax.
+	SizeMaxNative string
+	// ListMaxNative is the language specific name of ListMax.
+	ListMaxNative string
 	// SuperClass is the fully qualified path.
 	SuperClass string
 	// SuperClassNative is the language specific SuperClass.
 	SuperClassNative string
+	// ZeroCopy makes unmarshalled text and binaries share memory with the
+	// serial data.
+	ZeroCopy bool
+	// Reuse makes unmarshalling recycle the memory of lists, binaries and
+	// nested data structures from previous content.
+	Reuse bool
+	// ValueTypes makes data structure references values instead of
+	// pointers, where possible.
+	ValueTypes bool
 }
 
 // DocText returns the documentation lines prefixed with ident.
@@ -118,6 +206,47 @@ func (p *Package) HasTimestamp() bool {
 	return false
 }
 
+// HasNumber returns whether p has one or more integer or floating point
+// fields.
+func (p *Package) HasNumber() bool {
+	for _, s := range p.Structs {
+		if s.HasNumber() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasInt64 returns whether p has one or more 64-bit integer fields.
+func (p *Package) HasInt64() bool {
+	for _, s := range p.Structs {
+		if s.HasInt64() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasText returns whether p has one or more text fields.
+func (p *Package) HasText() bool {
+	for _, s := range p.Structs {
+		if s.HasText() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBinary returns whether p has one or more binary fields.
+func (p *Package) HasBinary() bool {
+	for _, s := range p.Structs {
+		if s.HasBinary() {
+			return true
+		}
+	}
+	return false
+}
+
 // HasList returns whether p has one or more list fields.
 func (p *Package) HasList() bool {
 	for _, s := range p.Structs {
@@ -141,6 +270,20 @@ type Struct struct {
 	Fields []*Field
 	// SchemaFile is the source filename.
 	SchemaFile string
+	// Pos is the location of Name in the schema file.
+	Pos token.Position
+	// SizeMax is the uper limit expression for the struct, if any. The
+	// package limit applies otherwise.
+	SizeMax string
+	// ListMax is the uper limit expression for the struct, if any. The
+	// package limit applies otherwise.
+	ListMax string
+	// SizeMaxNative is the language specific name of the size limit
+	// which applies.
+	SizeMaxNative string
+	// ListMaxNative is the language specific name of the list limit
+	// which applies.
+	ListMaxNative string
 }
 
 // NameTitle returns the identification token in title case.
@@ -148,6 +291,24 @@ func (s *Struct) NameTitle() string {
 	return strings.Title(s.Name)
 }
 
+// SizeMaxExpr returns the upper limit expression for serial byte sizes which
+// applies.
+func (s *Struct) SizeMaxExpr() string {
+	if s.SizeMax != "" {
+		return s.SizeMax
+	}
+	return s.Pkg.SizeMax
+}
+
+// ListMaxExpr returns the upper limit expression for the number of elements
+// in a list which applies.
+func (s *Struct) ListMaxExpr() string {
+	if s.ListMax != "" {
+		return s.ListMax
+	}
+	return s.Pkg.ListMax
+}
+
 // DocText returns the documentation lines prefixed with ident.
 func (s *Struct) DocText(indent string) string {
 	return docText(s.Docs, indent)
@@ -168,6 +329,28 @@ func (s *Struct) HasFloat() bool {
 	return false
 }
 
+// HasNumber returns whether s has one or more integer or floating point
+// fields.
+func (s *Struct) HasNumber() bool {
+	for _, f := range s.Fields {
+		switch f.Type {
+		case "uint8", "uint16", "uint32", "uint64", "int32", "int64", "float32", "float64":
+			return true
+		}
+	}
+	return false
+}
+
+// HasInt64 returns whether s has one or more 64-bit integer fields.
+func (s *Struct) HasInt64() bool {
+	for _, f := range s.Fields {
+		if f.Type == "uint64" || f.Type == "int64" {
+			return true
+		}
+	}
+	return false
+}
+
 // HasText returns whether s has one or more text fields.
 func (s *Struct) HasText() bool {
 	for _, f := range s.Fields {
@@ -238,6 +421,10 @@ type Field struct {
 	TypeRef *Struct
 	// TypeList flags whether the datatype is a list.
 	TypeList bool
+	// Pos is the location of Name in the schema file.
+	Pos token.Position
+	// TypePos is the location of Type in the schema file.
+	TypePos token.Position
 }
 
 // NameTitle returns the identification token in title case.
@@ -0,0 +1,156 @@
+package colfer
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Options are the compilation settings, conform the colf(1) flags.
+type Options struct {
+	// Lang is the target language, as in the colf(1) synopsis.
+	Lang string
+	// Generator overrides Lang when set.
+	Generator func(Packages) (Sources, error)
+
+	// Prefix is prepended to the package names. Use slash as a
+	// separator when nesting.
+	Prefix string
+	// SizeMax is the upper limit expression for serial byte sizes.
+	// The zero value defaults to DefaultSizeMax. Schema directives take
+	// precedence.
+	SizeMax string
+	// ListMax is the upper limit expression for the number of elements
+	// in a list. The zero value defaults to DefaultListMax. Schema
+	// directives take precedence.
+	ListMax string
+	// SuperClass makes all generated classes extend a super class. Use
+	// slash as a package separator. Java only.
+	SuperClass string
+	// ZeroCopy makes unmarshalled text and binaries share memory with the
+	// serial data, instead of copying. Go only.
+	ZeroCopy bool
+	// Reuse makes Unmarshal recycle the memory of lists, binaries and
+	// nested data structures from previous content. Go only.
+	Reuse bool
+	// ValueTypes makes data structure lists and fields values instead of
+	// pointers, except for fields which would contain themselves. Go only.
+	ValueTypes bool
+}
+
+// Default limit expressions, conform the colf(1) defaults.
+const (
+	DefaultSizeMax = "16 * 1024 * 1024"
+	DefaultListMax = "64 * 1024"
+)
+
+// LangSources returns the code generator for lang, or nil when lang is not
+// supported. The name is case insensitive.
+func LangSources(lang string) func(Packages) (Sources, error) {
+	switch strings.ToLower(lang) {
+	case "c":
+		return CSources
+	case "go":
+		return GoSources
+	case "java":
+		return JavaSources
+	case "javascript", "js", "ecmascript":
+		return ECMASources
+	case "doc", "markdown", "md":
+		return MarkdownSources
+	case "html":
+		return HTMLSources
+	case "proto", "protobuf":
+		return ProtoSources
+	case "fbs", "flatbuffers":
+		return FlatBuffersSources
+	case "jsonschema", "json-schema":
+		return JSONSchemaSources
+	}
+	return nil
+}
+
+// Compile returns the generated files for the schema files. The content of
+// files is read from sources when present, like ParseSources does. Nothing
+// is written to disk.
+func Compile(files []string, sources map[string][]byte, opts Options) (Sources, error) {
+	gen := opts.Generator
+	if gen == nil {
+		gen = LangSources(opts.Lang)
+		if gen == nil {
+			return nil, fmt.Errorf("colf: unsupported language %q", opts.Lang)
+		}
+	}
+
+	if opts.SuperClass != "" {
+		switch strings.ToLower(opts.Lang) {
+		case "c":
+			return nil, errors.New("colf: super class not supported with C")
+		case "go":
+			return nil, errors.New("colf: super class not supported with Go")
+		case "javascript", "js", "ecmascript":
+			return nil, errors.New("colf: super class not supported with ECMAScript")
+		}
+	}
+	if opts.ZeroCopy {
+		switch strings.ToLower(opts.Lang) {
+		case "c":
+			return nil, errors.New("colf: zero-copy not supported with C")
+		case "java":
+			return nil, errors.New("colf: zero-copy not supported with Java")
+		case "javascript", "js", "ecmascript":
+			return nil, errors.New("colf: zero-copy not supported with ECMAScript")
+		}
+	}
+	if opts.Reuse {
+		switch strings.ToLower(opts.Lang) {
+		case "c":
+			return nil, errors.New("colf: reuse not supported with C")
+		case "java":
+			return nil, errors.New("colf: reuse not supported with Java")
+		case "javascript", "js", "ecmascript":
+			return nil, errors.New("colf: reuse not supported with ECMAScript")
+		}
+	}
+	if opts.ValueTypes {
+		switch strings.ToLower(opts.Lang) {
+		case "c":
+			return nil, errors.New("colf: value types not supported with C")
+		case "java":
+			return nil, errors.New("colf: value types not supported with Java")
+		case "javascript", "js", "ecmascript":
+			return nil, errors.New("colf: value types not supported with ECMAScript")
+		}
+	}
+
+	packages, err := ParseSources(files, sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, errors.New("colf: no struct definitons found")
+	}
+
+	for _, p := range packages {
+		p.Name = path.Join(opts.Prefix, p.Name)
+		p.SizeMax = firstOf(p.SizeMax, opts.SizeMax, DefaultSizeMax)
+		p.ListMax = firstOf(p.ListMax, opts.ListMax, DefaultListMax)
+		p.SuperClass = opts.SuperClass
+		p.ZeroCopy = opts.ZeroCopy
+		p.Reuse = opts.Reuse
+		p.ValueTypes = opts.ValueTypes
+	}
+
+	return gen(packages)
+}
+
+// firstOf returns the first non-empty value.
+func firstOf(values ...string) string {
+	for _, s := range values {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package colfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const compileTestSchema = `package demo
+
+type point struct {
+	x int32
+}
+`
+
+func compileTestSources() map[string][]byte {
+	return map[string][]byte{"a.colf": []byte(compileTestSchema)}
+}
+
+func TestCompileLangs(t *testing.T) {
+	golden := []struct {
+		lang   string
+		paths  []string
+		marker string
+	}{
+		{"C", []string{"Colfer.c", "Colfer.h"}, "typedef struct example_demo_point example_demo_point;"},
+		{"Go", []string{"example/demo/Colfer.go"}, "type Point struct {"},
+		{"Java", []string{"example/demo/ColferJSON.java", "example/demo/Point.java"}, "public class Point implements Serializable {"},
+		{"JavaScript", []string{"Colfer.js"}, "this.Point = function(init) {"},
+		{"md", []string{"Colfer.md"}, "* [point](#example/demo.point)"},
+		{"html", []string{"Colfer.html"}, `<a href="#example%2fdemo.point">point</a>`},
+		{"proto", []string{"example/demo/Colfer.proto"}, "message Point {"},
+		{"fbs", []string{"example/demo/Colfer.fbs"}, "table Point {"},
+		{"jsonschema", []string{"example/demo/point.schema.json"}, `"title": "example/demo.point",`},
+	}
+	for _, gold := range golden {
+		sources, err := Compile([]string{"a.colf"}, compileTestSources(), Options{Lang: gold.lang, Prefix: "example"})
+		if err != nil {
+			t.Errorf("%s: %s", gold.lang, err)
+			continue
+		}
+		if got := sources.Paths(); !reflect.DeepEqual(got, gold.paths) {
+			t.Errorf("%s: got paths %q, want %q", gold.lang, got, gold.paths)
+			continue
+		}
+
+		var found bool
+		for _, src := range sources {
+			if strings.Contains(string(src), gold.marker) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: no %q in output", gold.lang, gold.marker)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	golden := []struct {
+		opts Options
+		want string
+	}{
+		{Options{Lang: "COBOL"}, `colf: unsupported language "COBOL"`},
+		{Options{}, `colf: unsupported language ""`},
+		{Options{Lang: "C", SuperClass: "a/B"}, "colf: super class not supported with C"},
+		{Options{Lang: "go", SuperClass: "a/B"}, "colf: super class not supported with Go"},
+		{Options{Lang: "js", SuperClass: "a/B"}, "colf: super class not supported with ECMAScript"},
+		{Options{Lang: "C", ZeroCopy: true}, "colf: zero-copy not supported with C"},
+		{Options{Lang: "Java", ZeroCopy: true}, "colf: zero-copy not supported with Java"},
+		{Options{Lang: "JavaScript", ZeroCopy: true}, "colf: zero-copy not supported with ECMAScript"},
+		{Options{Lang: "C", Reuse: true}, "colf: reuse not supported with C"},
+		{Options{Lang: "Java", Reuse: true}, "colf: reuse not supported with Java"},
+		{Options{Lang: "ecmascript", Reuse: true}, "colf: reuse not supported with ECMAScript"},
+		{Options{Lang: "C", ValueTypes: true}, "colf: value types not supported with C"},
+		{Options{Lang: "Java", ValueTypes: true}, "colf: value types not supported with Java"},
+		{Options{Lang: "JavaScript", ValueTypes: true}, "colf: value types not supported with ECMAScript"},
+	}
+	for _, gold := range golden {
+		_, err := Compile([]string{"a.colf"}, compileTestSources(), gold.opts)
+		if err == nil || err.Error() != gold.want {
+			t.Errorf("%+v: got error %v, want %q", gold.opts, err, gold.want)
+		}
+	}
+
+	_, err := Compile(nil, nil, Options{Lang: "Go"})
+	if err == nil || !strings.Contains(err.Error(), "no struct definitons") {
+		t.Errorf("got error %v without schema files", err)
+	}
+
+	_, err = Compile([]string{"a.colf"}, map[string][]byte{"a.colf": []byte("package demo\n\ntype x struct {\n\ty nope\n}\n")}, Options{Lang: "Go"})
+	if err == nil {
+		t.Error("no error for an unknown datatype")
+	}
+}
+
+func TestCompileOptions(t *testing.T) {
+	golden := []struct {
+		schema           string
+		opts             Options
+		sizeMax, listMax string
+	}{
+		{compileTestSchema, Options{}, DefaultSizeMax, DefaultListMax},
+		{compileTestSchema, Options{SizeMax: "1024", ListMax: "99"}, "1024", "99"},
+		{compileTestSchema, Options{SizeMax: "1024"}, "1024", DefaultListMax},
+		{"// Package demo has directives.\n//colf:sizemax 2048\n//colf:listmax 7\n" + compileTestSchema,
+			Options{SizeMax: "1024", ListMax: "99"}, "2048", "7"},
+		{"// Package demo has a directive.\n//colf:listmax 7\n" + compileTestSchema,
+			Options{}, DefaultSizeMax, "7"},
+	}
+	for _, gold := range golden {
+		var got Packages
+		gold.opts.Generator = func(packages Packages) (Sources, error) {
+			got = packages
+			return Sources{"x": nil}, nil
+		}
+		gold.opts.Prefix = "a/b"
+		gold.opts.Reuse = true
+
+		_, err := Compile([]string{"a.colf"}, map[string][]byte{"a.colf": []byte(gold.schema)}, gold.opts)
+		if err != nil {
+			t.Errorf("%+v: %s", gold.opts, err)
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("%+v: got %d packages, want 1", gold.opts, len(got))
+			continue
+		}
+		p := got[0]
+		if p.SizeMax != gold.sizeMax || p.ListMax != gold.listMax {
+			t.Errorf("%+v: got limits %q and %q, want %q and %q", gold.opts, p.SizeMax, p.ListMax, gold.sizeMax, gold.listMax)
+		}
+		if p.Name != "a/b/demo" {
+			t.Errorf("%+v: got package name %q, want with prefix", gold.opts, p.Name)
+		}
+		if !p.Reuse || p.ZeroCopy || p.ValueTypes {
+			t.Errorf("%+v: options not passed to the package", gold.opts)
+		}
+	}
+}
+
+func TestFirstOf(t *testing.T) {
+	golden := []struct {
+		values []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{""}, ""},
+		{[]string{"", "", "c"}, "c"},
+		{[]string{"a", "b"}, "a"},
+		{[]string{"", "b", ""}, "b"},
+	}
+	for _, gold := range golden {
+		if got := firstOf(gold.values...); got != gold.want {
+			t.Errorf("%q: got %q, want %q", gold.values, got, gold.want)
+		}
+	}
+}
+
+func TestSourcesWrite(t *testing.T) {
+	sources := Sources{
+		"z.txt":         []byte("last"),
+		"a/b/Colfer.go": []byte("nested"),
+		"a/Colfer.go":   []byte("parent"),
+	}
+	want := []string{"a/Colfer.go", "a/b/Colfer.go", "z.txt"}
+	if got := sources.Paths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got paths %q, want %q", got, want)
+	}
+
+	dir, err := ioutil.TempDir("", "colfer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := sources.Write(dir); err != nil {
+		t.Fatal("write error:", err)
+	}
+	for path, content := range sources {
+		got, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(got) != string(content) {
+			t.Errorf("%s: got %q, want %q", path, got, content)
+		}
+	}
+
+	// files in place of a directory
+	if err := (Sources{"z.txt/x": nil}).Write(dir); err == nil {
+		t.Error("no error for a parent directory which is a file")
+	}
+}
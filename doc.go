@@ -0,0 +1,378 @@
+package colfer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// docLangs are the code generation targets in order of appearance.
+var docLangs = []string{"Go", "Java", "C", "JavaScript"}
+
+// docRoot is the documentation view.
+type docRoot struct {
+	// Langs are the code generation targets.
+	Langs    []string
+	Packages []*docPackage
+}
+
+// docPackage is the documentation view of a Package.
+type docPackage struct {
+	*Package
+	Structs []*docStruct
+}
+
+// docStruct is the documentation view of a Struct.
+type docStruct struct {
+	*Struct
+	// Natives are the type names in order of docLangs.
+	Natives []string
+	Fields  []*docField
+}
+
+// docField is the documentation view of a Field.
+type docField struct {
+	*Field
+	// Limit is the upper limit expression, if any.
+	Limit string
+	// Natives are the declarations in order of docLangs.
+	Natives []string
+}
+
+// GenerateMarkdown writes the reference documentation into file "Colfer.md".
+func GenerateMarkdown(basedir string, packages Packages) error {
+	sources, err := MarkdownSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// MarkdownSources returns the documentation of GenerateMarkdown in memory.
+func MarkdownSources(packages Packages) (Sources, error) {
+	t := template.New("markdown")
+	t.Funcs(template.FuncMap{"cell": markdownCell})
+	template.Must(t.Parse(markdownDoc))
+
+	return docSources("Colfer.md", packages, t.Execute)
+}
+
+// GenerateHTML writes the reference documentation into file "Colfer.html".
+func GenerateHTML(basedir string, packages Packages) error {
+	sources, err := HTMLSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// HTMLSources returns the documentation of GenerateHTML in memory.
+func HTMLSources(packages Packages) (Sources, error) {
+	t := htmltemplate.New("html")
+	htmltemplate.Must(t.Parse(htmlDoc))
+
+	return docSources("Colfer.html", packages, t.Execute)
+}
+
+func docSources(filename string, packages Packages, execute func(w io.Writer, data interface{}) error) (Sources, error) {
+	var buf bytes.Buffer
+	if err := execute(&buf, docView(packages)); err != nil {
+		return nil, err
+	}
+	return Sources{filename: buf.Bytes()}, nil
+}
+
+// Natives returns the declarations of each struct and each field in langs,
+// which is the order of the values. The packages are not modified.
+func Natives(packages Packages) (langs []string, structs map[*Struct][]string, fields map[*Field][]string) {
+	structs = make(map[*Struct][]string)
+	fields = make(map[*Field][]string)
+	for _, p := range docView(packages).Packages {
+		for _, s := range p.Structs {
+			structs[s.Struct] = s.Natives
+			for _, f := range s.Fields {
+				fields[f.Field] = f.Natives
+			}
+		}
+	}
+	return docLangs, structs, fields
+}
+
+// docView maps the packages, including the native names of each language.
+func docView(packages Packages) *docRoot {
+	view := &docRoot{Langs: docLangs}
+	structs := make(map[*Struct]*docStruct)
+	fields := make(map[*Field]*docField)
+	for _, p := range packages {
+		pv := &docPackage{Package: p}
+		view.Packages = append(view.Packages, pv)
+
+		for _, s := range p.Structs {
+			sv := &docStruct{Struct: s}
+			pv.Structs = append(pv.Structs, sv)
+			structs[s] = sv
+
+			for _, f := range s.Fields {
+				fv := &docField{Field: f}
+				switch {
+				case f.TypeList:
+					fv.Limit = s.ListMaxExpr() + " elements"
+				case f.Type == "text" || f.Type == "binary":
+					fv.Limit = s.SizeMaxExpr() + " bytes"
+				}
+				sv.Fields = append(sv.Fields, fv)
+				fields[f] = fv
+			}
+		}
+	}
+
+	// The generators set the native names on the same fields, so they
+	// run on a copy. Each language is read before the next one runs.
+	copies, structOrigs, fieldOrigs := copyPackages(packages)
+	for _, lang := range docLangs {
+		switch lang {
+		case "Go":
+			goNatives(copies)
+		case "Java":
+			javaNatives(copies)
+		case "C":
+			cNatives(copies)
+		case "JavaScript":
+			ecmaNatives(copies)
+		}
+
+		for _, p := range copies {
+			for _, s := range p.Structs {
+				sv := structs[structOrigs[s]]
+				sv.Natives = append(sv.Natives, docStructNative(lang, s))
+				for _, f := range s.Fields {
+					fv := fields[fieldOrigs[f]]
+					fv.Natives = append(fv.Natives, docFieldNative(lang, f))
+				}
+			}
+		}
+	}
+
+	return view
+}
+
+// copyPackages returns a deep copy of packages, with the original of each
+// struct and each field copy.
+func copyPackages(packages Packages) (copies Packages, structs map[*Struct]*Struct, fields map[*Field]*Field) {
+	structs = make(map[*Struct]*Struct)
+	fields = make(map[*Field]*Field)
+	copyOf := make(map[*Struct]*Struct)
+	for _, p := range packages {
+		pc := new(Package)
+		*pc = *p
+		pc.Structs = make([]*Struct, len(p.Structs))
+		copies = append(copies, pc)
+
+		for i, s := range p.Structs {
+			sc := new(Struct)
+			*sc = *s
+			sc.Pkg = pc
+			pc.Structs[i] = sc
+			structs[sc] = s
+			copyOf[s] = sc
+		}
+	}
+
+	// references resolve once all structs are copied
+	for _, p := range copies {
+		for _, s := range p.Structs {
+			orig := s.Fields
+			s.Fields = make([]*Field, len(orig))
+			for i, f := range orig {
+				fc := new(Field)
+				*fc = *f
+				fc.Struct = s
+				if c, ok := copyOf[f.TypeRef]; ok {
+					fc.TypeRef = c
+				}
+				s.Fields[i] = fc
+				fields[fc] = f
+			}
+		}
+	}
+	return copies, structs, fields
+}
+
+// docStructNative returns the type name of s in lang.
+func docStructNative(lang string, s *Struct) string {
+	switch lang {
+	case "C":
+		return s.NameNative
+	default:
+		return s.Pkg.NameNative + "." + s.NameTitle()
+	}
+}
+
+// docFieldNative returns the declaration of f in lang.
+func docFieldNative(lang string, f *Field) string {
+	switch lang {
+	case "Go":
+		typ := f.TypeNative
+		if f.TypeRef != nil {
+			typ = "*" + typ
+		}
+		if f.TypeList {
+			typ = "[]" + typ
+		}
+		return f.NameTitle() + " " + typ
+
+	case "Java":
+		typ := f.TypeNative
+		if f.TypeList {
+			typ += "[]"
+		}
+		return typ + " " + f.NameNative
+
+	case "C":
+		var typ string
+		switch {
+		case f.TypeList && f.TypeRef != nil:
+			typ = "struct { " + f.TypeRef.NameNative + "* list; size_t len; }"
+		case f.TypeList:
+			elem := f.TypeNative
+			if f.Type == "float32" || f.Type == "float64" {
+				elem = map[string]string{"float32": "float", "float64": "double"}[f.Type]
+			}
+			typ = "struct { " + elem + "* list; size_t len; }"
+		case f.Type == "timestamp":
+			typ = "struct timespec"
+		case f.TypeRef != nil:
+			typ = f.TypeRef.NameNative + "*"
+		default:
+			typ = f.TypeNative
+		}
+		return typ + " " + f.NameNative
+
+	default: // JavaScript
+		var typ string
+		switch f.Type {
+		case "bool":
+			typ = "boolean"
+		case "uint8", "uint16", "uint32", "uint64", "int32", "int64", "float32", "float64":
+			typ = "number"
+		case "timestamp":
+			typ = "Date"
+		case "text":
+			typ = "string"
+		case "binary":
+			typ = "Uint8Array"
+		default:
+			typ = f.TypeRef.Pkg.NameNative + "." + f.TypeRef.NameTitle()
+		}
+		if f.TypeList {
+			switch f.Type {
+			case "float32":
+				typ = "Float32Array"
+			case "float64":
+				typ = "Float64Array"
+			default:
+				typ += "[]"
+			}
+		}
+		return f.NameNative + " " + typ
+	}
+}
+
+// markdownCell returns text as a single line, fit for a table cell.
+func markdownCell(text string) string {
+	text = strings.Replace(strings.TrimSpace(text), "\n", " ", -1)
+	return strings.Replace(text, "|", "\\|", -1)
+}
+
+const markdownDoc = `<!-- Code generated by colf(1); DO NOT EDIT. -->
+
+# Colfer Reference
+{{range .Packages}}
+<a id="{{.Name}}"></a>
+## Package {{.Name}}
+{{with .DocText ""}}
+{{.}}
+{{end}}
+The schema files are {{.SchemaFileList}}. Serials are limited to {{.SizeMax}} bytes
+and lists are limited to {{.ListMax}} elements.
+{{range .Structs}}
+* [{{.Name}}](#{{.String}})
+{{- end}}
+{{range .Structs}}
+<a id="{{.String}}"></a>
+### Struct {{.String}}
+{{with .DocText ""}}
+{{.}}
+{{end}}
+A serial takes 1 byte minimum and
+{{- if .FixedSizeMax}} {{.FixedSizeMax}} bytes maximum.{{else if .SizeMax}} has a variable size up to {{.SizeMax}} bytes.{{else}} has a variable size up to the limit.{{end}}
+
+| Index | Name | Type | Limit | Description |
+|------:|------|------|-------|-------------|
+{{- range .Fields}}
+| {{.Index}} | {{.Name}} | {{if .TypeList}}[]{{end}}{{if .TypeRef}}[{{.Type}}](#{{.TypeRef.String}}){{else}}{{.Type}}{{end}} | {{.Limit}} | {{cell (.DocText "")}} |
+{{- end}}
+
+| Name |{{range $.Langs}} {{.}} |{{end}}
+|------|{{range $.Langs}}------|{{end}}
+| _struct_ |{{range .Natives}} ` + "`{{.}}`" + ` |{{end}}
+{{- range .Fields}}
+| {{.Name}} |{{range .Natives}} ` + "`{{.}}`" + ` |{{end}}
+{{- end}}
+{{end}}{{end}}`
+
+const htmlDoc = `<!DOCTYPE html>
+<!-- Code generated by colf(1); DO NOT EDIT. -->
+<html>
+<head>
+<meta charset="utf-8">
+<title>Colfer Reference</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+td.index { text-align: right; }
+code { font-size: 90%; }
+</style>
+</head>
+<body>
+<h1>Colfer Reference</h1>
+<ul>
+{{- range .Packages}}
+<li><a href="#{{.Name}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{range .Packages}}
+<h2 id="{{.Name}}">Package {{.Name}}</h2>
+{{with .DocText ""}}<p>{{.}}</p>{{end}}
+<p>The schema files are {{.SchemaFileList}}. Serials are limited to {{.SizeMax}} bytes
+and lists are limited to {{.ListMax}} elements.</p>
+<ul>
+{{- range .Structs}}
+<li><a href="#{{.String}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{range .Structs}}
+<h3 id="{{.String}}">Struct {{.String}}</h3>
+{{with .DocText ""}}<p>{{.}}</p>{{end}}
+<p>A serial takes 1 byte minimum and
+{{- if .FixedSizeMax}} {{.FixedSizeMax}} bytes maximum.{{else if .SizeMax}} has a variable size up to {{.SizeMax}} bytes.{{else}} has a variable size up to the limit.{{end}}</p>
+<table>
+<tr><th>Index</th><th>Name</th><th>Type</th><th>Limit</th><th>Description</th></tr>
+{{- range .Fields}}
+<tr><td class="index">{{.Index}}</td><td>{{.Name}}</td><td>{{if .TypeList}}[]{{end}}{{if .TypeRef}}<a href="#{{.TypeRef.String}}">{{.Type}}</a>{{else}}{{.Type}}{{end}}</td><td>{{.Limit}}</td><td>{{.DocText ""}}</td></tr>
+{{- end}}
+</table>
+<table>
+<tr><th>Name</th>{{range $.Langs}}<th>{{.}}</th>{{end}}</tr>
+<tr><td><em>struct</em></td>{{range .Natives}}<td><code>{{.}}</code></td>{{end}}</tr>
+{{- range .Fields}}
+<tr><td>{{.Name}}</td>{{range .Natives}}<td><code>{{.}}</code></td>{{end}}</tr>
+{{- end}}
+</table>
+{{end}}{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,81 @@
+package colfer
+
+import "testing"
+
+func TestMarkdownSources(t *testing.T) {
+	verifyGoldenSources(t, MarkdownSources, "testdata/markdown")
+}
+
+func TestHTMLSources(t *testing.T) {
+	verifyGoldenSources(t, HTMLSources, "testdata/html")
+}
+
+func TestNatives(t *testing.T) {
+	packages, err := ParseFiles(goldenSchemas)
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+	// trace modification with a marker
+	for _, p := range packages {
+		p.NameNative = "mark"
+		for _, s := range p.Structs {
+			s.NameNative = "mark"
+			for _, f := range s.Fields {
+				f.NameNative, f.TypeNative = "mark", "mark"
+			}
+		}
+	}
+
+	langs, structs, fields := Natives(packages)
+	if want := []string{"Go", "Java", "C", "JavaScript"}; len(langs) != len(want) {
+		t.Fatalf("got languages %q, want %q", langs, want)
+	}
+
+	for _, p := range packages {
+		if p.NameNative != "mark" {
+			t.Errorf("package %s native name changed to %q", p.Name, p.NameNative)
+		}
+		for _, s := range p.Structs {
+			if s.NameNative != "mark" {
+				t.Errorf("struct %s native name changed to %q", s, s.NameNative)
+			}
+			if got := structs[s]; len(got) != len(langs) {
+				t.Errorf("struct %s: got natives %q", s, got)
+			}
+			for _, f := range s.Fields {
+				if f.NameNative != "mark" || f.TypeNative != "mark" {
+					t.Errorf("field %s native names changed to %q and %q", f, f.NameNative, f.TypeNative)
+				}
+				if got := fields[f]; len(got) != len(langs) {
+					t.Errorf("field %s: got natives %q", f, got)
+				}
+			}
+		}
+	}
+
+	golden := []struct {
+		field string
+		want  [4]string
+	}{
+		{"gen.o.u8", [4]string{"U8 uint8", "byte u8", "uint8_t u8", "u8 number"}},
+		{"gen.o.os", [4]string{"Os []*O", "O[] os", "struct { gen_o* list; size_t len; } os", "os gen.O[]"}},
+		{"void.class.public", [4]string{"Public []*static.Int", "static_.Int[] public_", "struct { static_int* list; size_t len; } public", "public static.Int[]"}},
+	}
+	for _, gold := range golden {
+		var found bool
+		for f, natives := range fields {
+			if f.String() != gold.field {
+				continue
+			}
+			found = true
+			for i, want := range gold.want {
+				if natives[i] != want {
+					t.Errorf("%s: got %s declaration %q, want %q", gold.field, langs[i], natives[i], want)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: no natives", gold.field)
+		}
+	}
+}
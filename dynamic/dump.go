@@ -0,0 +1,363 @@
+package dynamic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// dumpWidth is the number of bytes per listing line.
+const dumpWidth = 8
+
+// Dump writes an annotated hex listing of data conform the definition of t
+// to w. Each line has the byte offset, the bytes in hexadecimal and a
+// description. The listing ends with the first serial, or at the point where
+// decoding failed. The return is the number of bytes read with the decoding
+// error, if any, as Unmarshal would have it.
+func (s *Schema) Dump(w io.Writer, t *colfer.Struct, data []byte) (int, error) {
+	d := dumper{decoder: decoder{schema: s, data: data}, w: w}
+	err := d.dumpStruct(t)
+	if err == io.EOF && len(data) >= s.SizeMax(t) {
+		err = ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", t, s.SizeMax(t)))
+	}
+	if d.err != nil {
+		return d.i, d.err
+	}
+	return d.i, err
+}
+
+// DumpRaw writes a hex listing of data to w without schema. Colfer data is not
+// self-describing. The field values are guessed with the header index order
+// and the length encoding of text, binary and lists. Nested data structures
+// show as content bytes. The listing ends at the first struct end which is
+// not part of a value. The error return options are io.EOF and ColferError.
+func DumpRaw(w io.Writer, data []byte) (int, error) {
+	d := dumper{decoder: decoder{data: data}, w: w}
+	err := d.dumpRaw()
+	if d.err != nil {
+		return d.i, d.err
+	}
+	return d.i, err
+}
+
+// dumper is the listing state.
+type dumper struct {
+	decoder
+	w io.Writer
+	// err is the first write error.
+	err error
+	// depth is the data structure nesting level.
+	depth int
+}
+
+// line lists the bytes read since start with a description. Without any bytes
+// the address is omitted.
+func (d *dumper) line(start int, format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+
+	end := d.i
+	if end < start {
+		end = start
+	}
+	desc := strings.Repeat("  ", d.depth) + fmt.Sprintf(format, args...)
+
+	var buf []byte
+	for offset := start; ; offset += dumpWidth {
+		chunk := d.data[offset:end]
+		if len(chunk) > dumpWidth {
+			chunk = chunk[:dumpWidth]
+		}
+
+		if offset < end {
+			buf = append(buf, fmt.Sprintf("%06x ", offset)...)
+		} else {
+			// no bytes to address
+			buf = append(buf, "       "...)
+		}
+		for i := 0; i < dumpWidth; i++ {
+			if i < len(chunk) {
+				buf = append(buf, fmt.Sprintf(" %02x", chunk[i])...)
+			} else {
+				buf = append(buf, "   "...)
+			}
+		}
+		if desc != "" {
+			buf = append(buf, "  "...)
+			buf = append(buf, desc...)
+			desc = ""
+		} else {
+			buf = bytes.TrimRight(buf, " ")
+		}
+		buf = append(buf, '\n')
+
+		if offset+dumpWidth >= end {
+			break
+		}
+	}
+
+	_, d.err = d.w.Write(buf)
+}
+
+// fail lists the bytes since start with the cause of err.
+func (d *dumper) fail(start int, err error) error {
+	switch e := err.(type) {
+	case ColferError:
+		d.line(start, "^ mismatch at byte %d", int(e))
+	default:
+		if err == io.EOF {
+			// include the incomplete remainder
+			d.i = len(d.data)
+			d.line(start, "^ end of data")
+		} else {
+			d.line(start, "^ %s", err)
+		}
+	}
+	return err
+}
+
+func (d *dumper) dumpStruct(t *colfer.Struct) error {
+	start := d.i
+	sizeMax, listMax := d.schema.SizeMax(t), d.schema.ListMax(t)
+
+	next := 0 // minimum field index
+	for {
+		at := d.i
+		header, err := d.byte()
+		if err != nil {
+			return d.fail(at, err)
+		}
+
+		if header == 0x7f {
+			d.line(at, "end of %s, %s", t, plural(d.i-start, "byte"))
+			if d.i-start >= sizeMax {
+				return d.fail(d.i, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", t, sizeMax)))
+			}
+			return nil
+		}
+
+		index := int(header & 0x7f)
+		if index >= len(t.Fields) {
+			d.line(at, "header 0x%02x: no field %d in %s", header, index, t)
+			return d.fail(d.i, ColferError(at))
+		}
+		f := t.Fields[index]
+		if index < next {
+			d.line(at, "header 0x%02x: field %d %s out of order", header, index, f.Name)
+			return d.fail(d.i, ColferError(at))
+		}
+		next = index + 1
+		flag := header&0x80 != 0
+		if flag && !hasFlag(f) {
+			d.line(at, "header 0x%02x: field %d %s with flag 0x80 for %s", header, index, f.Name, typeName(f))
+			return d.fail(d.i, ColferError(at))
+		}
+
+		if err := d.dumpField(at, f, flag, sizeMax, listMax); err != nil {
+			return err
+		}
+	}
+}
+
+func (d *dumper) dumpField(at int, f *colfer.Field, flag bool, sizeMax, listMax int) error {
+	label := fmt.Sprintf("field %d %s (%s)", f.Index, f.Name, typeName(f))
+
+	if f.TypeRef != nil && !f.TypeList {
+		d.line(at, "%s", label)
+		d.depth++
+		err := d.dumpStruct(f.TypeRef)
+		d.depth--
+		return err
+	}
+
+	if !f.TypeList || f.Type == "float32" || f.Type == "float64" {
+		v, err := d.readField(f, flag, sizeMax, listMax)
+		if err != nil {
+			return d.fail(at, err)
+		}
+		d.line(at, "%s%s: %s", label, encodingNote(f, flag), dumpValue(v))
+		return nil
+	}
+
+	l, err := d.length(f, listMax, "elements")
+	if err != nil {
+		return d.fail(at, err)
+	}
+	d.line(at, "%s: %s", label, plural(l, "element"))
+
+	d.depth++
+	defer func() { d.depth-- }()
+
+	elem := *f
+	elem.TypeList = false
+	for i := 0; i < l; i++ {
+		at := d.i
+		if f.TypeRef != nil {
+			d.line(at, "[%d]", i)
+			d.depth++
+			err := d.dumpStruct(f.TypeRef)
+			d.depth--
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := d.readField(&elem, false, sizeMax, listMax)
+		if err != nil {
+			return d.fail(at, err)
+		}
+		d.line(at, "[%d] %s", i, dumpValue(v))
+	}
+	return nil
+}
+
+// typeName returns the schema notation.
+func typeName(f *colfer.Field) string {
+	name := f.Type
+	if f.TypeRef != nil && f.TypeRef.Pkg != f.Struct.Pkg {
+		name = f.TypeRef.String()
+	}
+	if f.TypeList {
+		return "[]" + name
+	}
+	return name
+}
+
+// encodingNote describes the effect of the header flag, if any.
+func encodingNote(f *colfer.Field, flag bool) string {
+	if f.TypeList {
+		return ""
+	}
+	switch f.Type {
+	case "uint16":
+		if flag {
+			return ", 1 byte"
+		}
+		return ", 2 bytes"
+	case "uint32", "uint64":
+		if flag {
+			return ", fixed size"
+		}
+		return ", varint"
+	case "int32", "int64":
+		if flag {
+			return ", negative varint"
+		}
+		return ", varint"
+	case "timestamp":
+		if flag {
+			return ", 64-bit seconds"
+		}
+		return ", 32-bit seconds"
+	}
+	return ""
+}
+
+// dumpValue returns a description of a readField result.
+func dumpValue(v interface{}) string {
+	switch x := v.(type) {
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case string:
+		return plural(len(x), "byte") + " " + quotePrefix(x)
+	case []byte:
+		return plural(len(x), "byte")
+	case []float32:
+		return fmt.Sprintf("%s %v", plural(len(x), "element"), x)
+	case []float64:
+		return fmt.Sprintf("%s %v", plural(len(x), "element"), x)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return strconv.Itoa(n) + " " + unit + "s"
+}
+
+// quotePrefix returns s in Go notation, with an ellipsis when too long.
+func quotePrefix(s string) string {
+	const max = 40
+	if len(s) <= max {
+		return strconv.Quote(s)
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return strconv.Quote(s[:cut]) + "…"
+}
+
+func (d *dumper) dumpRaw() error {
+	next := 0 // minimum field index
+	for d.i < len(d.data) {
+		at := d.i
+		header := d.data[d.i]
+		d.i++
+
+		if header == 0x7f {
+			d.line(at, "end of struct, %s", plural(d.i, "byte"))
+			return nil
+		}
+		index := int(header & 0x7f)
+		if index < next {
+			d.line(at, "header 0x%02x: field %d out of order", header, index)
+			return d.fail(d.i, ColferError(at))
+		}
+		next = index + 1
+		desc := fmt.Sprintf("field %d", index)
+		if header&0x80 != 0 {
+			desc += " with flag 0x80"
+		}
+
+		valueAt := d.i
+		if valueAt >= len(d.data) {
+			return d.fail(at, io.EOF)
+		}
+		x, err := d.varint()
+		end := d.i + int(x)
+		switch {
+		case err == nil && x != 0 && x < uint64(len(d.data)) && end < len(d.data) && d.plausibleHeader(end, index):
+			d.line(at, "%s: length %d", desc, x)
+			d.depth++
+			content := d.data[d.i:end]
+			start := d.i
+			d.i = end
+			if utf8.Valid(content) {
+				d.line(start, "%s", quotePrefix(string(content)))
+			} else {
+				d.line(start, "content")
+			}
+			d.depth--
+
+		case d.plausibleHeader(valueAt, index):
+			// no value, i.e., a boolean
+			d.i = valueAt
+			d.line(at, "%s", desc)
+
+		case err != nil:
+			return d.fail(at, err)
+
+		default:
+			d.line(at, "%s: varint %d", desc, x)
+		}
+	}
+	return d.fail(d.i, io.EOF)
+}
+
+// plausibleHeader returns whether the byte at i may follow a field with index.
+func (d *dumper) plausibleHeader(i, index int) bool {
+	b := d.data[i]
+	return b == 0x7f || int(b&0x7f) > index
+}
@@ -0,0 +1,159 @@
+// Package dynamic implements Colfer serialization without generated code.
+//
+// Data structures are represented as map[string]interface{}, keyed by the
+// field names from the schema. The Colfer datatypes map to the following
+// Go types.
+//
+//	bool       bool
+//	uint8      uint8
+//	uint16     uint16
+//	uint32     uint32
+//	uint64     uint64
+//	int32      int32
+//	int64      int64
+//	float32    float32
+//	float64    float64
+//	timestamp  time.Time
+//	text       string
+//	binary     []byte
+//	struct     map[string]interface{}
+//
+// Lists are slices of the respective type, i.e. []float32, []float64,
+// []string, [][]byte and []map[string]interface{}.
+package dynamic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// Defaults apply when the schema has no limit expression.
+var (
+	// DefaultSizeMax is the default upper limit for serial byte sizes.
+	DefaultSizeMax, _ = EvalLimit(colfer.DefaultSizeMax, 0)
+	// DefaultListMax is the default upper limit for the number of elements in a list.
+	DefaultListMax, _ = EvalLimit(colfer.DefaultListMax, 0)
+)
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// Schema is the runtime representation of package definitions.
+type Schema struct {
+	// Packages are the definitions in use.
+	Packages colfer.Packages
+
+	limits map[*colfer.Struct]limits
+}
+
+// limits are the evaluated upper limits of a struct.
+type limits struct {
+	sizeMax, listMax int
+}
+
+// NewSchema returns the runtime representation of packages. The SizeMax
+// and ListMax expressions of each package and each struct must be constant
+// integers, like the command-line options of colf(1).
+func NewSchema(packages colfer.Packages) (*Schema, error) {
+	s := &Schema{
+		Packages: packages,
+		limits:   make(map[*colfer.Struct]limits),
+	}
+
+	for _, p := range packages {
+		for _, t := range p.Structs {
+			sizeMax, err := EvalLimit(t.SizeMaxExpr(), DefaultSizeMax)
+			if err != nil {
+				return nil, fmt.Errorf("colfer: struct %s size maximum: %s", t, err)
+			}
+			listMax, err := EvalLimit(t.ListMaxExpr(), DefaultListMax)
+			if err != nil {
+				return nil, fmt.Errorf("colfer: struct %s list maximum: %s", t, err)
+			}
+			s.limits[t] = limits{sizeMax, listMax}
+		}
+	}
+
+	return s, nil
+}
+
+// EvalLimit returns the value of a limit expression, like "64 * 1024".
+// The empty expression evaluates to def.
+func EvalLimit(expr string, def int) (int, error) {
+	return colfer.EvalLimit(expr, def)
+}
+
+// Lookup returns the struct definition. Name can be qualified with the
+// package, as in "gen.o". The unqualified form applies when the name is
+// unique. Case only matters to resolve ambiguity.
+func (s *Schema) Lookup(name string) (*colfer.Struct, error) {
+	var exact, folded []*colfer.Struct
+	for _, p := range s.Packages {
+		for _, t := range p.Structs {
+			for _, n := range []string{t.String(), t.Name} {
+				if n == name {
+					exact = append(exact, t)
+					break
+				}
+				if strings.EqualFold(n, name) {
+					folded = append(folded, t)
+					break
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(exact) == 1:
+		return exact[0], nil
+	case len(exact) == 0 && len(folded) == 1:
+		return folded[0], nil
+	case len(exact) == 0 && len(folded) == 0:
+		return nil, fmt.Errorf("colfer: struct %q not found", name)
+	}
+
+	var names []string
+	for _, t := range append(exact, folded...) {
+		names = append(names, t.String())
+	}
+	return nil, fmt.Errorf("colfer: struct %q is ambiguous; use one of %s", name, strings.Join(names, ", "))
+}
+
+// SizeMax returns the upper limit for serial byte sizes of t.
+func (s *Schema) SizeMax(t *colfer.Struct) int {
+	return s.limitsOf(t).sizeMax
+}
+
+// ListMax returns the upper limit for the number of elements in a list of t.
+func (s *Schema) ListMax(t *colfer.Struct) int {
+	return s.limitsOf(t).listMax
+}
+
+func (s *Schema) limitsOf(t *colfer.Struct) limits {
+	l, ok := s.limits[t]
+	if !ok {
+		return limits{DefaultSizeMax, DefaultListMax}
+	}
+	return l
+}
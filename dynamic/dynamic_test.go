@@ -0,0 +1,460 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+func testSchema(t *testing.T) (*Schema, *colfer.Struct) {
+	packages, err := colfer.ParseFiles([]string{"../testdata/test.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSchema(packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.Lookup("gen.o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, o
+}
+
+// newO returns gen.o with field values.
+func newO(fields map[string]interface{}) map[string]interface{} {
+	o := map[string]interface{}{
+		"b":    false,
+		"u32":  uint32(0),
+		"u64":  uint64(0),
+		"i32":  int32(0),
+		"i64":  int64(0),
+		"f32":  float32(0),
+		"f64":  float64(0),
+		"t":    time.Time{},
+		"s":    "",
+		"a":    []byte(nil),
+		"o":    nil,
+		"os":   []map[string]interface{}(nil),
+		"ss":   []string(nil),
+		"as":   [][]byte(nil),
+		"u8":   uint8(0),
+		"u16":  uint16(0),
+		"f32s": []float32(nil),
+		"f64s": []float64(nil),
+	}
+	for k, v := range fields {
+		o[k] = v
+	}
+	return o
+}
+
+type golden struct {
+	serial string
+	object map[string]interface{}
+}
+
+func newGoldenCases() []golden {
+	return []golden{
+		{"7f", newO(nil)},
+		{"007f", newO(map[string]interface{}{"b": true})},
+		{"01ffff037f", newO(map[string]interface{}{"u32": uint32(math.MaxUint16)})},
+		{"81ffffffff7f", newO(map[string]interface{}{"u32": uint32(math.MaxUint32)})},
+		{"02ffffffff0f7f", newO(map[string]interface{}{"u64": uint64(math.MaxUint32)})},
+		{"82ffffffffffffffff7f", newO(map[string]interface{}{"u64": uint64(math.MaxUint64)})},
+		{"83017f", newO(map[string]interface{}{"i32": int32(-1)})},
+		{"03ffffffff077f", newO(map[string]interface{}{"i32": int32(math.MaxInt32)})},
+		{"8380808080087f", newO(map[string]interface{}{"i32": int32(math.MinInt32)})},
+		{"04ffffffffffffffff7f7f", newO(map[string]interface{}{"i64": int64(math.MaxInt64)})},
+		{"848080808080808080807f", newO(map[string]interface{}{"i64": int64(math.MinInt64)})},
+		{"057f7fffff7f", newO(map[string]interface{}{"f32": float32(math.MaxFloat32)})},
+		{"067fefffffffffffff7f", newO(map[string]interface{}{"f64": math.MaxFloat64})},
+		{"0755ef312a2e5da4e77f", newO(map[string]interface{}{"t": time.Unix(1441739050, 777888999).In(time.UTC)})},
+		{"87fffff82457de8000000003e97f", newO(map[string]interface{}{"t": time.Unix(-864e10, 1001).In(time.UTC)})},
+		{"0809c280e0a080f09080807f", newO(map[string]interface{}{"s": "\u0080\u0800\U00010000"})},
+		{"090202007f", newO(map[string]interface{}{"a": []byte{2, 0}})},
+		{"0a007f7f", newO(map[string]interface{}{"o": newO(map[string]interface{}{"b": true})})},
+		{"0b027f7f7f", newO(map[string]interface{}{"os": []map[string]interface{}{newO(nil), newO(nil)}})},
+		{"0c0300016101627f", newO(map[string]interface{}{"ss": []string{"", "a", "b"}})},
+		{"0d0201000201027f", newO(map[string]interface{}{"as": [][]byte{{0}, {1, 2}}})},
+		{"0eff7f", newO(map[string]interface{}{"u8": uint8(math.MaxUint8)})},
+		{"8f017f", newO(map[string]interface{}{"u16": uint16(1)})},
+		{"0fffff7f", newO(map[string]interface{}{"u16": uint16(math.MaxUint16)})},
+		{"1002000000003f8000007f", newO(map[string]interface{}{"f32s": []float32{0, 1}})},
+		{"11014058c000000000007f", newO(map[string]interface{}{"f64s": []float64{99}})},
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range newGoldenCases() {
+		data, err := s.Marshal(o, gold.object)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(data); got != gold.serial {
+			t.Errorf("got 0x%s, want 0x%s", got, gold.serial)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.UnmarshalBinary(o, data)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, gold.object) {
+			t.Errorf("0x%s: got %#v, want %#v", gold.serial, got, gold.object)
+		}
+	}
+}
+
+// TestUnmarshalTruncate verifies that 32-bit varints which exceed their
+// datatype decode the same as with the generated code.
+func TestUnmarshalTruncate(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range []golden{
+		{"0181808080107f", newO(map[string]interface{}{"u32": uint32(1)})},
+		{"01ff808080808080808080007f", newO(map[string]interface{}{"u32": uint32(0x7f)})},
+		{"0380808080087f", newO(map[string]interface{}{"i32": int32(math.MinInt32)})},
+		{"83818080801f7f", newO(map[string]interface{}{"i32": int32(0x0fffffff)})},
+	} {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.UnmarshalBinary(o, data)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, gold.object) {
+			t.Errorf("0x%s: got %#v, want %#v", gold.serial, got, gold.object)
+		}
+	}
+}
+
+func TestUnmarshalEOF(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range data {
+			incomplete := data[:i]
+			if _, _, err := s.Unmarshal(o, incomplete); err != io.EOF {
+				t.Errorf("0x%s: got error %T: %q", hex.EncodeToString(incomplete), err, err)
+			}
+		}
+	}
+}
+
+func TestUnmarshalSizeMax(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for sizeMax := 1; sizeMax <= len(data); sizeMax++ {
+			s.limits[o] = limits{sizeMax, DefaultListMax}
+
+			part := data[:sizeMax]
+			switch _, _, err := s.Unmarshal(o, part); err.(type) {
+			case ColferMax:
+				continue // pass
+			case nil:
+				t.Errorf("0x%s: no error with size maximum %d", hex.EncodeToString(part), sizeMax)
+			default:
+				t.Errorf("0x%s: got error %T with size maximum %d: %q", hex.EncodeToString(part), err, sizeMax, err)
+			}
+		}
+	}
+}
+
+func TestUnmarshalMismatch(t *testing.T) {
+	s, o := testSchema(t)
+
+	for serial, want := range map[string]ColferError{
+		"807f":     0, // flagged bool
+		"0e0100":   2, // unknown header
+		"0a0e017e": 3, // nested end
+	} {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err = s.Unmarshal(o, data)
+		if err != want {
+			t.Errorf("0x%s: got error %v, want %v", serial, err, want)
+		}
+	}
+}
+
+func TestMarshalMismatch(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, v := range []map[string]interface{}{
+		{"u8": 1},
+		{"os": []interface{}{}},
+		{"unknown": true},
+		{"o": map[string]interface{}{"s": []byte("A")}},
+	} {
+		if _, err := s.Marshal(o, v); err == nil {
+			t.Errorf("%v: no error", v)
+		}
+	}
+}
+
+func TestMarshalMax(t *testing.T) {
+	s, o := testSchema(t)
+	s.limits[o] = limits{16, 2}
+
+	for _, v := range []map[string]interface{}{
+		{"s": strings.Repeat("A", 17)},
+		{"s": strings.Repeat("A", 14)},
+		{"ss": []string{"", "", ""}},
+		{"o": map[string]interface{}{"as": [][]byte{make([]byte, 17)}}},
+	} {
+		if _, err := s.Marshal(o, v); err == nil {
+			t.Errorf("%v: no error", v)
+		} else if _, ok := err.(ColferMax); !ok {
+			t.Errorf("%v: got error %T, want ColferMax", v, err)
+		}
+	}
+}
+
+func TestLimitDirectives(t *testing.T) {
+	const src = `// Package a has limits.
+//colf:sizemax 4096
+package a
+
+//colf:listmax 8
+type big struct {
+	l []text
+}
+
+// Small is tiny.
+//
+//colf:sizemax 64
+type small struct {
+	b binary
+}
+`
+	packages, err := colfer.ParseSources([]string{"a.colf"}, map[string][]byte{"a.colf": []byte(src)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSchema(packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	big, small := packages[0].Structs[0], packages[0].Structs[1]
+
+	if got := s.SizeMax(big); got != 4096 {
+		t.Errorf("got size maximum %d for big, want package directive 4096", got)
+	}
+	if got := s.ListMax(big); got != 8 {
+		t.Errorf("got list maximum %d for big, want struct directive 8", got)
+	}
+	if got := s.SizeMax(small); got != 64 {
+		t.Errorf("got size maximum %d for small, want struct directive 64", got)
+	}
+	if got := s.ListMax(small); got != DefaultListMax {
+		t.Errorf("got list maximum %d for small, want default %d", got, DefaultListMax)
+	}
+	if want := []string{"// Small is tiny."}; !reflect.DeepEqual(small.Docs, want) {
+		t.Errorf("got docs %q, want %q", small.Docs, want)
+	}
+
+	if _, err := s.Marshal(small, map[string]interface{}{"b": make([]byte, 65)}); err == nil {
+		t.Error("marshal beyond struct directive: no error")
+	} else if _, ok := err.(ColferMax); !ok {
+		t.Errorf("marshal beyond struct directive: got error %T, want ColferMax", err)
+	}
+}
+
+func TestEvalLimit(t *testing.T) {
+	for expr, want := range map[string]int{
+		"":                 7,
+		"16 * 1024 * 1024": 16 * 1024 * 1024,
+		"1 << 10":          1024,
+		"(2 + 3) * 4":      20,
+	} {
+		got, err := EvalLimit(expr, 7)
+		if err != nil {
+			t.Errorf("%q: %s", expr, err)
+		} else if got != want {
+			t.Errorf("%q: got %d, want %d", expr, got, want)
+		}
+	}
+
+	for _, expr := range []string{"x", "1.5", "0", "-1", "Integer.MAX_VALUE"} {
+		if _, err := EvalLimit(expr, 7); err == nil {
+			t.Errorf("%q: no error", expr)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range newGoldenCases() {
+		doc, err := s.AppendJSON(nil, o, gold.object)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		v, err := s.ParseJSON(o, doc)
+		if err != nil {
+			t.Errorf("0x%s: %s: %s", gold.serial, doc, err)
+			continue
+		}
+		data, err := s.Marshal(o, v)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(data); got != gold.serial {
+			t.Errorf("%s: got 0x%s, want 0x%s", doc, got, gold.serial)
+		}
+	}
+}
+
+// TestJSONCanonical verifies the notation shared with the generated code.
+func TestJSONCanonical(t *testing.T) {
+	s, o := testSchema(t)
+
+	for _, gold := range []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"f32", float32(math.SmallestNonzeroFloat32), "1e-45"},
+		{"f32", float32(math.MaxFloat32), "3.4028235e+38"},
+		{"f32", float32(0.1), "0.1"},
+		{"f32", float32(1e-7), "1e-7"},
+		{"f64", math.Copysign(0, -1), "0"},
+		{"f64", 1e21, "1e+21"},
+		{"f64", 123456789012345680000.0, "123456789012345680000"},
+		{"f64", 0.000001, "0.000001"},
+		{"f64", math.Inf(-1), `"-Infinity"`},
+		{"t", time.Unix(1441739050, 1e8), `"2015-09-08T19:04:10.1Z"`},
+		{"t", time.Unix(864e10, 1000), `"275760-09-13T00:00:00.000001Z"`},
+		{"s", "\"\\\b\f\n\r\t\x1f\x7f/<>\u2028", `"\"\\\b\f\n\r\t\u001f` + "\x7f/<>\u2028\""},
+		{"s", "\xffA\xe2\x80", "\"\uFFFDA\uFFFD\uFFFD\""},
+	} {
+		doc, err := s.AppendJSON(nil, o, newO(map[string]interface{}{gold.name: gold.value}))
+		if err != nil {
+			t.Errorf("%s %#v: %s", gold.name, gold.value, err)
+			continue
+		}
+		want := `"` + gold.name + `":` + gold.want + ","
+		if !bytes.Contains(doc, []byte(want)) {
+			t.Errorf("%s %#v: got %s, want %s", gold.name, gold.value, doc, want)
+		}
+	}
+}
+
+func TestParseJSONError(t *testing.T) {
+	s, o := testSchema(t)
+
+	for doc, want := range map[string]string{
+		`{"u8": 256}`:                   "colfer: $.u8: ",
+		`{"os": [{}, {"u16": -1}]}`:     "colfer: $.os[1].u16: ",
+		`{"i64": 9007199254740993}`:     "colfer: $.i64: ",
+		`{"o": {"x": 1}}`:               "colfer: $.o: ",
+		`{"t": "yesterday"}`:            "colfer: $.t: ",
+		`{"ss": ["a", 1]}`:              "colfer: $.ss[1]: ",
+		`{"b": true} {"b": false}`:      "colfer: ",
+		`{"f32s": [1, "Infinity", {}]}`: "colfer: $.f32s[2]: ",
+	} {
+		_, err := s.ParseJSON(o, []byte(doc))
+		if err == nil {
+			t.Errorf("%s: no error", doc)
+		} else if !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: got error %q, want prefix %q", doc, err, want)
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	s, o := testSchema(t)
+
+	serials := []string{"807f", "0e0100", "0a0e017e", "0a0e01", "0c02"}
+	for _, gold := range newGoldenCases() {
+		serials = append(serials, gold.serial)
+	}
+
+	for _, serial := range serials {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, wantN, wantErr := s.Unmarshal(o, data)
+		var buf bytes.Buffer
+		n, err := s.Dump(&buf, o, data)
+		if err != wantErr {
+			t.Errorf("0x%s: got error %v, want %v", serial, err, wantErr)
+		}
+		if err == nil && n != wantN {
+			t.Errorf("0x%s: got %d bytes read, want %d", serial, n, wantN)
+		}
+		if buf.Len() == 0 {
+			t.Errorf("0x%s: no listing", serial)
+		}
+	}
+}
+
+// TestDumpMismatch verifies that the marker line has no address of its own.
+func TestDumpMismatch(t *testing.T) {
+	s, o := testSchema(t)
+
+	data, err := hex.DecodeString("0e0100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := s.Dump(&buf, o, data)
+	if err != ColferError(2) {
+		t.Errorf("got error %v, want ColferError 2", err)
+	}
+	if n != 3 {
+		t.Errorf("got %d bytes read, want 3", n)
+	}
+	const want = `000000  0e 01                    field 14 u8 (uint8): 1
+000002  00                       header 0x00: field 0 b out of order
+                                 ^ mismatch at byte 2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got listing:\n%s\nwant:\n%s", got, want)
+	}
+}
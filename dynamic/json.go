@@ -0,0 +1,632 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// The JSON representation has an object per data structure, with the field
+// names from the schema as keys, in order of appearance. All fields are
+// present. The Colfer datatypes map to the following JSON values.
+//
+//	bool       true or false
+//	uint8      number
+//	uint16     number
+//	uint32     number
+//	uint64     string with the decimal value
+//	int32      number
+//	int64      string with the decimal value
+//	float32    number, or the string "NaN", "Infinity" or "-Infinity"
+//	float64    number, or the string "NaN", "Infinity" or "-Infinity"
+//	timestamp  string in RFC 3339 format with nanoseconds, or null
+//	text       string
+//	binary     string with the standard base64 encoding, including padding
+//	struct     object, or null
+//
+// Lists are arrays of the respective type.
+// The 64-bit integers are strings because JSON numbers commonly get parsed as
+// IEEE 754 doubles, which can only hold 53 bits of precision.
+//
+// The representation is canonical, i.e., the generated code of all languages
+// produces the same bytes for the same data. There is no whitespace. Numbers
+// follow the notation of ECMAScript, with the shortest digits which parse back
+// into the same value at the precision of the datatype, and negative zero as
+// 0. Timestamps are in UTC, with a fraction of up to 9 digits without trailing
+// zeros, if any, and with at least 4 digits for the year. Strings escape the
+// quotation mark, the reverse solidus and the control characters only, as the
+// two-character sequence if any, and as lower-case \u00XX otherwise. Malformed
+// UTF-8 is replaced with U+FFFD, per byte.
+
+// AppendJSON appends the JSON representation of v conform the definition of
+// t to buf.
+func (s *Schema) AppendJSON(buf []byte, t *colfer.Struct, v map[string]interface{}) ([]byte, error) {
+	if err := checkNames(t, v); err != nil {
+		return nil, err
+	}
+
+	buf = append(buf, '{')
+	for i, f := range t.Fields {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, f.Name)
+		buf = append(buf, ':')
+
+		value, ok := v[f.Name]
+		if !ok || value == nil {
+			value = zeroValue(f)
+		}
+
+		var err error
+		if f.TypeList {
+			buf, err = s.appendJSONList(buf, f, value)
+		} else {
+			buf, err = s.appendJSONValue(buf, f, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+func (s *Schema) appendJSONList(buf []byte, f *colfer.Field, v interface{}) ([]byte, error) {
+	buf = append(buf, '[')
+	switch f.Type {
+	case "float32":
+		a, ok := v.([]float32)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		for i, x := range a {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONFloat(buf, float64(x), 32)
+		}
+
+	case "float64":
+		a, ok := v.([]float64)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		for i, x := range a {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONFloat(buf, x, 64)
+		}
+
+	case "text":
+		a, ok := v.([]string)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		for i, x := range a {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, x)
+		}
+
+	case "binary":
+		a, ok := v.([][]byte)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		for i, x := range a {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, base64.StdEncoding.EncodeToString(x))
+		}
+
+	default:
+		a, ok := v.([]map[string]interface{})
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		for i, x := range a {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			if x == nil {
+				x = map[string]interface{}{}
+			}
+			var err error
+			buf, err = s.AppendJSON(buf, f.TypeRef, x)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return append(buf, ']'), nil
+}
+
+func (s *Schema) appendJSONValue(buf []byte, f *colfer.Field, v interface{}) ([]byte, error) {
+	switch x := v.(type) {
+	case bool:
+		if f.Type == "bool" {
+			return strconv.AppendBool(buf, x), nil
+		}
+	case uint8:
+		if f.Type == "uint8" {
+			return strconv.AppendUint(buf, uint64(x), 10), nil
+		}
+	case uint16:
+		if f.Type == "uint16" {
+			return strconv.AppendUint(buf, uint64(x), 10), nil
+		}
+	case uint32:
+		if f.Type == "uint32" {
+			return strconv.AppendUint(buf, uint64(x), 10), nil
+		}
+	case uint64:
+		if f.Type == "uint64" {
+			buf = append(buf, '"')
+			buf = strconv.AppendUint(buf, x, 10)
+			return append(buf, '"'), nil
+		}
+	case int32:
+		if f.Type == "int32" {
+			return strconv.AppendInt(buf, int64(x), 10), nil
+		}
+	case int64:
+		if f.Type == "int64" {
+			buf = append(buf, '"')
+			buf = strconv.AppendInt(buf, x, 10)
+			return append(buf, '"'), nil
+		}
+	case float32:
+		if f.Type == "float32" {
+			return appendJSONFloat(buf, float64(x), 32), nil
+		}
+	case float64:
+		if f.Type == "float64" {
+			return appendJSONFloat(buf, x, 64), nil
+		}
+	case time.Time:
+		if f.Type == "timestamp" {
+			if x.IsZero() {
+				return append(buf, "null"...), nil
+			}
+			return appendJSONString(buf, x.UTC().Format(time.RFC3339Nano)), nil
+		}
+	case string:
+		if f.Type == "text" {
+			return appendJSONString(buf, x), nil
+		}
+	case []byte:
+		if f.Type == "binary" {
+			return appendJSONString(buf, base64.StdEncoding.EncodeToString(x)), nil
+		}
+	case map[string]interface{}:
+		if f.TypeRef != nil {
+			if x == nil {
+				return append(buf, "null"...), nil
+			}
+			return s.AppendJSON(buf, f.TypeRef, x)
+		}
+	case nil:
+		if f.TypeRef != nil {
+			return append(buf, "null"...), nil
+		}
+	}
+	return nil, typeMismatch(f, v)
+}
+
+// appendJSONFloat encodes the shortest representation which parses back
+// into the same value with bitSize, in ECMAScript notation.
+func appendJSONFloat(buf []byte, x float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(x):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(x, 1):
+		return append(buf, `"Infinity"`...)
+	case math.IsInf(x, -1):
+		return append(buf, `"-Infinity"`...)
+	case x == 0:
+		return append(buf, '0') // including negative zero
+	}
+
+	// compare with the precision of bitSize
+	abs := math.Abs(x)
+	format := byte('f')
+	if bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) || bitSize == 64 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, x, format, -1, bitSize)
+	if format == 'e' {
+		// exponent without leading zero, like e-7 instead of e-07
+		if n := len(buf); buf[n-4] == 'e' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString encodes s with the escapes of ECMAScript JSON.stringify.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\uFFFD"...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < ' ' {
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+
+// ParseJSON returns the value of a JSON document conform the definition of
+// t. All fields of t are present in the result, like with Unmarshal. Null
+// values and absent properties are interpreted as the zero value. Any error
+// names the JSON path of the offending value, as in "$.os[2].u8".
+func (s *Schema) ParseJSON(t *colfer.Struct, data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("colfer: malformed JSON: %s", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("colfer: data continuation after JSON document at byte %d", dec.InputOffset())
+	}
+	return s.FromJSON(t, doc)
+}
+
+// FromJSON returns the value of a document, as decoded by encoding/json with
+// UseNumber enabled, conform the definition of t. See ParseJSON for details.
+func (s *Schema) FromJSON(t *colfer.Struct, doc interface{}) (map[string]interface{}, error) {
+	o, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, jsonError("$", "got %s, want object for struct %s", jsonKind(doc), t)
+	}
+	return s.fromJSONStruct("$", t, o)
+}
+
+func jsonError(path, format string, args ...interface{}) error {
+	return fmt.Errorf("colfer: %s: %s", path, fmt.Sprintf(format, args...))
+}
+
+// jsonKind returns the JSON type name of v.
+func jsonKind(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean " + strconv.FormatBool(v)
+	case json.Number:
+		return "number " + v.String()
+	case float64:
+		return "number " + strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return "string " + strconv.Quote(v)
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("Go type %T", v)
+	}
+}
+
+func (s *Schema) fromJSONStruct(path string, t *colfer.Struct, doc map[string]interface{}) (map[string]interface{}, error) {
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fieldByName(t, name) == nil {
+			return nil, jsonError(path, "struct %s has no field %q", t, name)
+		}
+	}
+
+	sizeMax, listMax := s.SizeMax(t), s.ListMax(t)
+	v := make(map[string]interface{}, len(t.Fields))
+	for _, f := range t.Fields {
+		x, ok := doc[f.Name]
+		if !ok || x == nil {
+			v[f.Name] = zeroValue(f)
+			continue
+		}
+
+		var err error
+		if f.TypeList {
+			v[f.Name], err = s.fromJSONList(path+"."+f.Name, f, x, sizeMax, listMax)
+		} else {
+			v[f.Name], err = s.fromJSONValue(path+"."+f.Name, f, x, sizeMax)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (s *Schema) fromJSONList(path string, f *colfer.Field, doc interface{}, sizeMax, listMax int) (interface{}, error) {
+	a, ok := doc.([]interface{})
+	if !ok {
+		return nil, jsonError(path, "got %s, want array for []%s", jsonKind(doc), f.Type)
+	}
+	if len(a) > listMax {
+		return nil, jsonError(path, "%d elements exceed the limit of %d", len(a), listMax)
+	}
+
+	var list reflect.Value
+	switch f.Type {
+	case "float32":
+		list = reflect.ValueOf(make([]float32, len(a)))
+	case "float64":
+		list = reflect.ValueOf(make([]float64, len(a)))
+	case "text":
+		list = reflect.ValueOf(make([]string, len(a)))
+	case "binary":
+		list = reflect.ValueOf(make([][]byte, len(a)))
+	default:
+		list = reflect.ValueOf(make([]map[string]interface{}, len(a)))
+	}
+
+	for i, x := range a {
+		elementPath := path + "[" + strconv.Itoa(i) + "]"
+
+		var v interface{}
+		var err error
+		switch {
+		case f.TypeRef != nil:
+			if x == nil {
+				x = map[string]interface{}{}
+			}
+			o, ok := x.(map[string]interface{})
+			if !ok {
+				return nil, jsonError(elementPath, "got %s, want object for struct %s", jsonKind(x), f.TypeRef)
+			}
+			v, err = s.fromJSONStruct(elementPath, f.TypeRef, o)
+		case x == nil:
+			continue // zero value
+		default:
+			v, err = s.fromJSONValue(elementPath, f, x, sizeMax)
+		}
+		if err != nil {
+			return nil, err
+		}
+		list.Index(i).Set(reflect.ValueOf(v))
+	}
+	return list.Interface(), nil
+}
+
+func (s *Schema) fromJSONValue(path string, f *colfer.Field, doc interface{}, sizeMax int) (interface{}, error) {
+	switch f.Type {
+	case "bool":
+		if v, ok := doc.(bool); ok {
+			return v, nil
+		}
+
+	case "uint8", "uint16", "uint32", "int32":
+		n, ok := doc.(json.Number)
+		if !ok {
+			break
+		}
+		switch f.Type {
+		case "uint8":
+			x, err := strconv.ParseUint(n.String(), 10, 8)
+			if err != nil {
+				return nil, jsonNumberError(path, f, n, err)
+			}
+			return uint8(x), nil
+		case "uint16":
+			x, err := strconv.ParseUint(n.String(), 10, 16)
+			if err != nil {
+				return nil, jsonNumberError(path, f, n, err)
+			}
+			return uint16(x), nil
+		case "uint32":
+			x, err := strconv.ParseUint(n.String(), 10, 32)
+			if err != nil {
+				return nil, jsonNumberError(path, f, n, err)
+			}
+			return uint32(x), nil
+		default:
+			x, err := strconv.ParseInt(n.String(), 10, 32)
+			if err != nil {
+				return nil, jsonNumberError(path, f, n, err)
+			}
+			return int32(x), nil
+		}
+
+	case "uint64", "int64":
+		var text string
+		switch v := doc.(type) {
+		case string:
+			text = v
+		case json.Number:
+			text = v.String()
+			// The JSON number may have passed an IEEE 754 double already.
+			if x, err := strconv.ParseInt(text, 10, 64); err == nil && (x > maxSafeInteger || x < -maxSafeInteger) {
+				return nil, jsonError(path, "number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string for %s", text, f.Type)
+			}
+			if x, err := strconv.ParseUint(text, 10, 64); err == nil && x > maxSafeInteger {
+				return nil, jsonError(path, "number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string for %s", text, f.Type)
+			}
+		default:
+			return nil, jsonError(path, "got %s, want string or number for %s", jsonKind(doc), f.Type)
+		}
+		if f.Type == "int64" {
+			x, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return nil, jsonNumberError(path, f, json.Number(text), err)
+			}
+			return x, nil
+		}
+		x, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, jsonNumberError(path, f, json.Number(text), err)
+		}
+		return x, nil
+
+	case "float32", "float64":
+		bitSize := 32
+		if f.Type == "float64" {
+			bitSize = 64
+		}
+
+		var x float64
+		switch v := doc.(type) {
+		case json.Number:
+			var err error
+			x, err = strconv.ParseFloat(v.String(), bitSize)
+			if err != nil {
+				return nil, jsonError(path, "number %s out of range for %s", v, f.Type)
+			}
+		case string:
+			switch v {
+			case "NaN":
+				x = math.NaN()
+			case "Infinity":
+				x = math.Inf(1)
+			case "-Infinity":
+				x = math.Inf(-1)
+			default:
+				return nil, jsonError(path, "got %s, want number, \"NaN\", \"Infinity\" or \"-Infinity\" for %s", jsonKind(doc), f.Type)
+			}
+		default:
+			return nil, jsonError(path, "got %s, want number for %s", jsonKind(doc), f.Type)
+		}
+		if bitSize == 32 {
+			return float32(x), nil
+		}
+		return x, nil
+
+	case "timestamp":
+		v, ok := doc.(string)
+		if !ok {
+			break
+		}
+		x, err := parseTimestamp(v)
+		if err != nil {
+			return nil, jsonError(path, "malformed RFC 3339 timestamp %q", v)
+		}
+		return x, nil
+
+	case "text":
+		v, ok := doc.(string)
+		if !ok {
+			break
+		}
+		if len(v) > sizeMax {
+			return nil, jsonError(path, "%d UTF-8 bytes exceed the limit of %d", len(v), sizeMax)
+		}
+		return v, nil
+
+	case "binary":
+		v, ok := doc.(string)
+		if !ok {
+			break
+		}
+		enc := base64.StdEncoding
+		if !strings.HasSuffix(v, "=") && len(v)%4 != 0 {
+			enc = base64.RawStdEncoding
+		}
+		x, err := enc.DecodeString(v)
+		if err != nil {
+			return nil, jsonError(path, "malformed base64: %s", err)
+		}
+		if len(x) > sizeMax {
+			return nil, jsonError(path, "%d bytes exceed the limit of %d", len(x), sizeMax)
+		}
+		return x, nil
+
+	default:
+		o, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, jsonError(path, "got %s, want object for struct %s", jsonKind(doc), f.TypeRef)
+		}
+		return s.fromJSONStruct(path, f.TypeRef, o)
+	}
+
+	return nil, jsonError(path, "got %s, want %s", jsonKind(doc), f.Type)
+}
+
+// maxSafeInteger is Number.MAX_SAFE_INTEGER from ECMAScript.
+const maxSafeInteger = 1<<53 - 1
+
+// parseTimestamp parses RFC 3339 with support for the years beyond 0000–9999,
+// as formatted by package time.
+func parseTimestamp(s string) (time.Time, error) {
+	i := strings.IndexByte(s, '-')
+	if i == 0 {
+		i = strings.IndexByte(s[1:], '-') + 1
+	}
+	if i <= 0 || i == 4 {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t.UTC(), err
+	}
+
+	year, err := strconv.Atoi(s[:i])
+	if err != nil || (year >= 0 && year <= 9999) {
+		return time.Time{}, fmt.Errorf("malformed year %q", s[:i])
+	}
+	// The Gregorian calendar repeats itself every 400 years.
+	base := 2000 + year%400
+	if year < 0 {
+		base += 400
+	}
+	t, err := time.Parse(time.RFC3339Nano, strconv.Itoa(base)+s[i:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC().AddDate(year-base, 0, 0), nil
+}
+
+func jsonNumberError(path string, f *colfer.Field, n json.Number, err error) error {
+	if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+		return jsonError(path, "value %s overflows %s", n, f.Type)
+	}
+	if strings.HasPrefix(f.Type, "uint") && strings.HasPrefix(n.String(), "-") {
+		return jsonError(path, "negative value %s for %s", n, f.Type)
+	}
+	return jsonError(path, "value %s is not an integer for %s", n, f.Type)
+}
@@ -0,0 +1,347 @@
+package dynamic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+var intconv = binary.BigEndian
+
+// Marshal encodes v as Colfer conform the definition of t.
+// Absent fields and nil values encode as the zero value.
+// The error return option is ColferMax for limit breaches. Any other error
+// signals a mismatch between v and t.
+func (s *Schema) Marshal(t *colfer.Struct, v map[string]interface{}) ([]byte, error) {
+	return s.MarshalAppend(nil, t, v)
+}
+
+// MarshalAppend is like Marshal, but it appends the serial to buf.
+func (s *Schema) MarshalAppend(buf []byte, t *colfer.Struct, v map[string]interface{}) ([]byte, error) {
+	return s.marshalStruct(buf, t, v)
+}
+
+func (s *Schema) marshalStruct(buf []byte, t *colfer.Struct, v map[string]interface{}) ([]byte, error) {
+	if err := checkNames(t, v); err != nil {
+		return nil, err
+	}
+	sizeMax, listMax := s.SizeMax(t), s.ListMax(t)
+
+	start := len(buf)
+	for _, f := range t.Fields {
+		value, ok := v[f.Name]
+		if !ok || value == nil {
+			continue
+		}
+
+		var err error
+		buf, err = s.marshalField(buf, f, value, sizeMax, listMax)
+		if err != nil {
+			return nil, err
+		}
+	}
+	buf = append(buf, 0x7f)
+
+	if l := len(buf) - start; l > sizeMax {
+		return nil, ColferMax(fmt.Sprintf("colfer: struct %s exceeds %d bytes", t, sizeMax))
+	}
+	return buf, nil
+}
+
+// checkNames verifies that all keys in v are fields of t.
+func checkNames(t *colfer.Struct, v map[string]interface{}) error {
+	var unknown []string
+	for name := range v {
+		if fieldByName(t, name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("colfer: struct %s has no field %q", t, unknown[0])
+}
+
+func fieldByName(t *colfer.Struct, name string) *colfer.Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func typeMismatch(f *colfer.Field, v interface{}) error {
+	want := f.Type
+	if f.TypeList {
+		want = "[]" + want
+	}
+	return fmt.Errorf("colfer: field %s of type %s got Go type %T", f, want, v)
+}
+
+func (s *Schema) marshalField(buf []byte, f *colfer.Field, v interface{}, sizeMax, listMax int) ([]byte, error) {
+	header := byte(f.Index)
+
+	if f.TypeList {
+		switch f.Type {
+		case "float32":
+			a, ok := v.([]float32)
+			if !ok {
+				return nil, typeMismatch(f, v)
+			}
+			if len(a) == 0 {
+				return buf, nil
+			}
+			if len(a) > listMax {
+				return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, listMax))
+			}
+			buf = appendVarint(append(buf, header), uint64(len(a)))
+			for _, x := range a {
+				buf = appendUint32(buf, math.Float32bits(x))
+			}
+
+		case "float64":
+			a, ok := v.([]float64)
+			if !ok {
+				return nil, typeMismatch(f, v)
+			}
+			if len(a) == 0 {
+				return buf, nil
+			}
+			if len(a) > listMax {
+				return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, listMax))
+			}
+			buf = appendVarint(append(buf, header), uint64(len(a)))
+			for _, x := range a {
+				buf = appendUint64(buf, math.Float64bits(x))
+			}
+
+		case "text":
+			a, ok := v.([]string)
+			if !ok {
+				return nil, typeMismatch(f, v)
+			}
+			if len(a) == 0 {
+				return buf, nil
+			}
+			if len(a) > listMax {
+				return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, listMax))
+			}
+			buf = appendVarint(append(buf, header), uint64(len(a)))
+			for _, x := range a {
+				if len(x) > sizeMax {
+					return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, sizeMax))
+				}
+				buf = append(appendVarint(buf, uint64(len(x))), x...)
+			}
+
+		case "binary":
+			a, ok := v.([][]byte)
+			if !ok {
+				return nil, typeMismatch(f, v)
+			}
+			if len(a) == 0 {
+				return buf, nil
+			}
+			if len(a) > listMax {
+				return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, listMax))
+			}
+			buf = appendVarint(append(buf, header), uint64(len(a)))
+			for _, x := range a {
+				if len(x) > sizeMax {
+					return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, sizeMax))
+				}
+				buf = append(appendVarint(buf, uint64(len(x))), x...)
+			}
+
+		default:
+			a, ok := v.([]map[string]interface{})
+			if !ok {
+				return nil, typeMismatch(f, v)
+			}
+			if len(a) == 0 {
+				return buf, nil
+			}
+			if len(a) > listMax {
+				return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, listMax))
+			}
+			buf = appendVarint(append(buf, header), uint64(len(a)))
+			for _, x := range a {
+				var err error
+				buf, err = s.marshalStruct(buf, f.TypeRef, x)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return buf, nil
+	}
+
+	switch f.Type {
+	case "bool":
+		x, ok := v.(bool)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x {
+			buf = append(buf, header)
+		}
+
+	case "uint8":
+		x, ok := v.(uint8)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x != 0 {
+			buf = append(buf, header, x)
+		}
+
+	case "uint16":
+		x, ok := v.(uint16)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x >= 1<<8 {
+			buf = append(buf, header, byte(x>>8), byte(x))
+		} else if x != 0 {
+			buf = append(buf, header|0x80, byte(x))
+		}
+
+	case "uint32":
+		x, ok := v.(uint32)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x >= 1<<21 {
+			buf = appendUint32(append(buf, header|0x80), x)
+		} else if x != 0 {
+			buf = appendVarint(append(buf, header), uint64(x))
+		}
+
+	case "uint64":
+		x, ok := v.(uint64)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x >= 1<<49 {
+			buf = appendUint64(append(buf, header|0x80), x)
+		} else if x != 0 {
+			buf = appendVarint(append(buf, header), x)
+		}
+
+	case "int32":
+		x, ok := v.(int32)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x < 0 {
+			buf = appendVarint(append(buf, header|0x80), uint64(^uint32(x)+1))
+		} else if x != 0 {
+			buf = appendVarint(append(buf, header), uint64(x))
+		}
+
+	case "int64":
+		x, ok := v.(int64)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x < 0 {
+			buf = appendVarint(append(buf, header|0x80), ^uint64(x)+1)
+		} else if x != 0 {
+			buf = appendVarint(append(buf, header), uint64(x))
+		}
+
+	case "float32":
+		x, ok := v.(float32)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x != 0 {
+			buf = appendUint32(append(buf, header), math.Float32bits(x))
+		}
+
+	case "float64":
+		x, ok := v.(float64)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x != 0 {
+			buf = appendUint64(append(buf, header), math.Float64bits(x))
+		}
+
+	case "timestamp":
+		x, ok := v.(time.Time)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if !x.IsZero() {
+			s, ns := uint64(x.Unix()), uint32(x.Nanosecond())
+			if s < 1<<32 {
+				buf = appendUint32(append(buf, header), uint32(s))
+			} else {
+				buf = appendUint64(append(buf, header|0x80), s)
+			}
+			buf = appendUint32(buf, ns)
+		}
+
+	case "text":
+		x, ok := v.(string)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if len(x) > sizeMax {
+			return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, sizeMax))
+		}
+		if x != "" {
+			buf = append(appendVarint(append(buf, header), uint64(len(x))), x...)
+		}
+
+	case "binary":
+		x, ok := v.([]byte)
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if len(x) > sizeMax {
+			return nil, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, sizeMax))
+		}
+		if len(x) != 0 {
+			buf = append(appendVarint(append(buf, header), uint64(len(x))), x...)
+		}
+
+	default:
+		x, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, typeMismatch(f, v)
+		}
+		if x != nil {
+			return s.marshalStruct(append(buf, header), f.TypeRef, x)
+		}
+	}
+
+	return buf, nil
+}
+
+// appendVarint encodes x with the 9-byte limit, where the last byte has no
+// continuation flag.
+func appendVarint(buf []byte, x uint64) []byte {
+	for n := 0; x >= 0x80 && n < 8; n++ {
+		buf = append(buf, byte(x|0x80))
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
+
+func appendUint32(buf []byte, x uint32) []byte {
+	return append(buf, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+}
+
+func appendUint64(buf []byte, x uint64) []byte {
+	return append(buf, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32),
+		byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+}
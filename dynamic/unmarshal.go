@@ -0,0 +1,424 @@
+package dynamic
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// Unmarshal decodes data as Colfer conform the definition of t and returns
+// the number of bytes read. All fields of t are present in the result. An
+// absent data structure has the nil value.
+// The error return options are io.EOF, ColferError and ColferMax. The byte
+// index of ColferError is relative to the start of data, also for nested
+// data structures.
+func (s *Schema) Unmarshal(t *colfer.Struct, data []byte) (map[string]interface{}, int, error) {
+	d := decoder{schema: s, data: data}
+	v, err := d.readStruct(t)
+	if err == io.EOF && len(data) >= s.SizeMax(t) {
+		return nil, 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", t, s.SizeMax(t)))
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.i, nil
+}
+
+// UnmarshalBinary is like Unmarshal, but it requires data to contain exactly
+// one serial. The error return options are io.EOF, ColferError, ColferTail
+// and ColferMax.
+func (s *Schema) UnmarshalBinary(t *colfer.Struct, data []byte) (map[string]interface{}, error) {
+	v, i, err := s.Unmarshal(t, data)
+	if err == nil && i < len(data) {
+		return nil, ColferTail(i)
+	}
+	return v, err
+}
+
+// decoder is the read state.
+type decoder struct {
+	schema *Schema
+	data   []byte
+	// i is the read index in data.
+	i int
+}
+
+func (d *decoder) byte() (byte, error) {
+	if d.i >= len(d.data) {
+		return 0, io.EOF
+	}
+	b := d.data[d.i]
+	d.i++
+	return b, nil
+}
+
+// bytes returns the following n bytes.
+func (d *decoder) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.i {
+		return nil, io.EOF
+	}
+	start := d.i
+	d.i += n
+	return d.data[start:d.i], nil
+}
+
+// varint reads an unsigned integer with the 9-byte limit, where the last
+// byte has no continuation flag.
+func (d *decoder) varint() (uint64, error) {
+	var x uint64
+	for shift := uint(0); ; shift += 7 {
+		b, err := d.byte()
+		if err != nil {
+			return 0, err
+		}
+		if b < 0x80 || shift == 56 {
+			return x | uint64(b)<<shift, nil
+		}
+		x |= uint64(b&0x7f) << shift
+	}
+}
+
+// varint32 reads an unsigned integer like the generated code does, without a
+// byte limit. Bits beyond the 32-bit range are discarded.
+func (d *decoder) varint32() (uint32, error) {
+	var x uint32
+	for shift := uint(0); ; shift += 7 {
+		b, err := d.byte()
+		if err != nil {
+			return 0, err
+		}
+		if b < 0x80 {
+			return x | uint32(b)<<shift, nil
+		}
+		x |= uint32(b&0x7f) << shift
+	}
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	b, err := d.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return intconv.Uint32(b), nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	b, err := d.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return intconv.Uint64(b), nil
+}
+
+// length reads a list size or a byte size.
+func (d *decoder) length(f *colfer.Field, max int, unit string) (int, error) {
+	x, err := d.varint()
+	if err != nil {
+		return 0, err
+	}
+	if x > uint64(max) {
+		return 0, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d %s", f, x, max, unit))
+	}
+	return int(x), nil
+}
+
+func (d *decoder) readStruct(t *colfer.Struct) (map[string]interface{}, error) {
+	start := d.i
+	sizeMax, listMax := d.schema.SizeMax(t), d.schema.ListMax(t)
+
+	v := make(map[string]interface{}, len(t.Fields))
+	for _, f := range t.Fields {
+		v[f.Name] = zeroValue(f)
+	}
+
+	header, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range t.Fields {
+		if header&0x7f != byte(f.Index) {
+			continue
+		}
+		flag := header&0x80 != 0
+		if flag && !hasFlag(f) {
+			break // mismatch
+		}
+
+		x, err := d.readField(f, flag, sizeMax, listMax)
+		if err != nil {
+			return nil, err
+		}
+		v[f.Name] = x
+
+		if header, err = d.byte(); err != nil {
+			return nil, err
+		}
+	}
+
+	if header != 0x7f {
+		return nil, ColferError(d.i - 1)
+	}
+	if d.i-start >= sizeMax {
+		return nil, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", t, sizeMax))
+	}
+	return v, nil
+}
+
+// hasFlag returns whether the header of f may have the 0x80 bit set.
+func hasFlag(f *colfer.Field) bool {
+	if f.TypeList {
+		return false
+	}
+	switch f.Type {
+	case "uint16", "uint32", "uint64", "int32", "int64", "timestamp":
+		return true
+	}
+	return false
+}
+
+func zeroValue(f *colfer.Field) interface{} {
+	if f.TypeList {
+		switch f.Type {
+		case "float32":
+			return []float32(nil)
+		case "float64":
+			return []float64(nil)
+		case "text":
+			return []string(nil)
+		case "binary":
+			return [][]byte(nil)
+		default:
+			return []map[string]interface{}(nil)
+		}
+	}
+
+	switch f.Type {
+	case "bool":
+		return false
+	case "uint8":
+		return uint8(0)
+	case "uint16":
+		return uint16(0)
+	case "uint32":
+		return uint32(0)
+	case "uint64":
+		return uint64(0)
+	case "int32":
+		return int32(0)
+	case "int64":
+		return int64(0)
+	case "float32":
+		return float32(0)
+	case "float64":
+		return float64(0)
+	case "timestamp":
+		return time.Time{}
+	case "text":
+		return ""
+	case "binary":
+		return []byte(nil)
+	default:
+		return nil
+	}
+}
+
+func (d *decoder) readField(f *colfer.Field, flag bool, sizeMax, listMax int) (interface{}, error) {
+	if f.TypeList {
+		l, err := d.length(f, listMax, "elements")
+		if err != nil {
+			return nil, err
+		}
+
+		switch f.Type {
+		case "float32":
+			b, err := d.bytes(l * 4)
+			if err != nil {
+				return nil, err
+			}
+			a := make([]float32, l)
+			for i := range a {
+				a[i] = math.Float32frombits(intconv.Uint32(b[i*4:]))
+			}
+			return a, nil
+
+		case "float64":
+			b, err := d.bytes(l * 8)
+			if err != nil {
+				return nil, err
+			}
+			a := make([]float64, l)
+			for i := range a {
+				a[i] = math.Float64frombits(intconv.Uint64(b[i*8:]))
+			}
+			return a, nil
+
+		case "text":
+			a := make([]string, 0, d.capacity(l))
+			for len(a) < l {
+				size, err := d.length(f, sizeMax, "bytes")
+				if err != nil {
+					return nil, err
+				}
+				b, err := d.bytes(size)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, string(b))
+			}
+			return a, nil
+
+		case "binary":
+			a := make([][]byte, 0, d.capacity(l))
+			for len(a) < l {
+				size, err := d.length(f, sizeMax, "bytes")
+				if err != nil {
+					return nil, err
+				}
+				b, err := d.bytes(size)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, append([]byte{}, b...))
+			}
+			return a, nil
+
+		default:
+			a := make([]map[string]interface{}, 0, d.capacity(l))
+			for len(a) < l {
+				o, err := d.readStruct(f.TypeRef)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, o)
+			}
+			return a, nil
+		}
+	}
+
+	switch f.Type {
+	case "bool":
+		return true, nil
+
+	case "uint8":
+		return d.byte()
+
+	case "uint16":
+		if flag {
+			b, err := d.byte()
+			return uint16(b), err
+		}
+		b, err := d.bytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return intconv.Uint16(b), nil
+
+	case "uint32":
+		if flag {
+			return d.uint32()
+		}
+		x, err := d.varint32()
+		if err != nil {
+			return nil, err
+		}
+		return x, nil
+
+	case "uint64":
+		if flag {
+			return d.uint64()
+		}
+		return d.varint()
+
+	case "int32":
+		x, err := d.varint32()
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			return int32(^x + 1), nil
+		}
+		return int32(x), nil
+
+	case "int64":
+		x, err := d.varint()
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			return int64(^x + 1), nil
+		}
+		return int64(x), nil
+
+	case "float32":
+		x, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(x), nil
+
+	case "float64":
+		x, err := d.uint64()
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(x), nil
+
+	case "timestamp":
+		var s int64
+		if flag {
+			x, err := d.uint64()
+			if err != nil {
+				return nil, err
+			}
+			s = int64(x)
+		} else {
+			x, err := d.uint32()
+			if err != nil {
+				return nil, err
+			}
+			s = int64(x)
+		}
+		ns, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(s, int64(ns)).In(time.UTC), nil
+
+	case "text":
+		size, err := d.length(f, sizeMax, "bytes")
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+
+	case "binary":
+		size, err := d.length(f, sizeMax, "bytes")
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+
+	default:
+		return d.readStruct(f.TypeRef)
+	}
+}
+
+// capacity limits preallocation for a list of l elements to the amount of
+// remaining data, as each element takes at least one byte.
+func (d *decoder) capacity(l int) int {
+	if remain := len(d.data) - d.i; l > remain {
+		return remain
+	}
+	return l
+}
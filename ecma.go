@@ -1,8 +1,7 @@
 package colfer
 
 import (
-	"os"
-	"path/filepath"
+	"bytes"
 	"strings"
 	"text/template"
 )
@@ -19,15 +18,26 @@ func IsECMAKeyword(s string) bool {
 	return false
 }
 
-// GenerateECMA writes the code into file "Colfer.js".
-func GenerateECMA(basedir string, packages Packages) error {
+// ecmaNatives sets the ECMAScript names.
+func ecmaNatives(packages Packages) {
 	for _, p := range packages {
 		p.NameNative = strings.Replace(p.Name, "/", "_", -1)
 		if IsECMAKeyword(p.NameNative) {
 			p.NameNative += "_"
 		}
+		p.SizeMaxNative = "colferSizeMax"
+		p.ListMaxNative = "colferListMax"
 
 		for _, s := range p.Structs {
+			s.SizeMaxNative = p.SizeMaxNative
+			if s.SizeMax != "" {
+				s.SizeMaxNative = s.Name + "SizeMax"
+			}
+			s.ListMaxNative = p.ListMaxNative
+			if s.ListMax != "" && s.HasList() {
+				s.ListMaxNative = s.Name + "ListMax"
+			}
+
 			for _, f := range s.Fields {
 				f.NameNative = f.Name
 				if IsECMAKeyword(f.NameNative) {
@@ -36,21 +46,32 @@ func GenerateECMA(basedir string, packages Packages) error {
 			}
 		}
 	}
+}
+
+// GenerateECMA writes the code into file "Colfer.js".
+func GenerateECMA(basedir string, packages Packages) error {
+	sources, err := ECMASources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// ECMASources returns the code of GenerateECMA in memory.
+func ECMASources(packages Packages) (Sources, error) {
+	ecmaNatives(packages)
 
 	t := template.New("ecma-code")
 	template.Must(t.Parse(ecmaCode))
 	template.Must(t.New("marshal").Parse(ecmaMarshal))
 	template.Must(t.New("unmarshal").Parse(ecmaUnmarshal))
+	template.Must(t.New("json").Parse(ecmaJSON))
 
-	if err := os.MkdirAll(basedir, os.ModeDir|os.ModePerm); err != nil {
-		return err
-	}
-	f, err := os.Create(filepath.Join(basedir, "Colfer.js"))
-	if err != nil {
-		return err
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, packages); err != nil {
+		return nil, err
 	}
-	defer f.Close()
-	return t.Execute(f, packages)
+	return Sources{"Colfer.js": buf.Bytes()}, nil
 }
 
 const ecmaCode = `// Code generated by colf(1); DO NOT EDIT.
@@ -67,10 +88,20 @@ var {{.NameNative}} = new function() {
 	}
 
 	// The upper limit for serial byte sizes.
-	var colferSizeMax = {{.SizeMax}};
+	var {{.SizeMaxNative}} = {{.SizeMax}};
 {{- if .HasList}}
 	// The upper limit for the number of elements in a list.
-	var colferListMax = {{.ListMax}};
+	var {{.ListMaxNative}} = {{.ListMax}};
+{{- end}}
+{{- range .Structs}}
+{{- if .SizeMax}}
+	// The upper limit for serial byte sizes of {{.NameTitle}}.
+	var {{.SizeMaxNative}} = {{.SizeMax}};
+{{- end}}
+{{- if and .ListMax .HasList}}
+	// The upper limit for the number of elements in a list of {{.NameTitle}}.
+	var {{.ListMaxNative}} = {{.ListMax}};
+{{- end}}
 {{- end}}
 {{range .Structs}}
 	// Constructor.
@@ -92,8 +123,14 @@ var {{.NameNative}} = new function() {
 
 		for (var p in init) this[p] = init[p];
 	}
+{{- if .FixedSizeMax}}
+
+	// The upper limit for serial byte sizes, regardless of the property values.
+	this.{{.NameTitle}}.colferFixedMax = {{.FixedSizeMax}};
+{{- end}}
 {{template "marshal" .}}
 {{template "unmarshal" .}}
+{{template "json" .}}
 {{end}}
 	// private section
 
@@ -164,16 +201,16 @@ var {{.NameNative}} = new function() {
 			var c = bytes[i++];
 			if (c > 127) {
 				if (c > 191 && c < 224) {
-					c = (i >= bytes.length) ? 63 : (c & 31) << 6 | bytes[i++] & 63;
+					c = (i >= bytes.length) ? 0xfffd : (c & 31) << 6 | bytes[i++] & 63;
 				} else if (c > 223 && c < 240) {
-					c = (i + 1 >= bytes.length) ? 63 : (c & 15) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
+					c = (i + 1 >= bytes.length) ? 0xfffd : (c & 15) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
 				} else if (c > 239 && c < 248) {
-					c = (i+2 >= bytes.length) ? 63 : (c & 7) << 18 | (bytes[i++] & 63) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
-				} else c = 63;
+					c = (i+2 >= bytes.length) ? 0xfffd : (c & 7) << 18 | (bytes[i++] & 63) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
+				} else c = 0xfffd;
 			}
 
 			if (c <= 0xffff) s += String.fromCharCode(c);
-			else if (c > 0x10ffff) s += '?';
+			else if (c > 0x10ffff) s += '\ufffd';
 			else {
 				c -= 0x10000;
 				s += String.fromCharCode(c >> 10 | 0xd800)
@@ -182,6 +219,188 @@ var {{.NameNative}} = new function() {
 		}
 		return s;
 	}
+
+	function jsonFail(path, v, want) {
+		fail('colfer: ' + path + ': got ' + JSON.stringify(v) + ', want ' + want);
+	}
+{{- if .HasList}}
+
+	// Returns the array of a JSON value, with null as an empty array.
+	function fromJSONList(path, v, max) {
+		if (v == null) return [];
+		if (! Array.isArray(v)) jsonFail(path, v, 'array');
+		if (v.length > max)
+			fail('colfer: ' + path + ' length ' + v.length + ' exceeds ' + max + ' elements');
+		return v;
+	}
+{{- end}}
+{{- if .HasNumber}}
+
+	// Returns the integer of a JSON number within range, with null as zero.
+	// Strings with the decimal value are accepted when digits is set.
+	function fromJSONInteger(path, v, min, max, digits) {
+		if (v == null) return 0;
+		if (digits && typeof v === 'string' && /^-?[0-9]+$/.test(v)) v = Number(v);
+		if (typeof v !== 'number' || ! Number.isInteger(v)) jsonFail(path, v, 'integer');
+		if (v < min || v > max) fail('colfer: ' + path + ': ' + v + ' out of range');
+		return v;
+	}
+{{- end}}
+{{- if .HasFloat}}
+
+	// Returns the canonical JSON value of a floating point. 32-bit values get
+	// the Number with the shortest decimals which map back into the same value.
+	// When both neighbours of a precision qualify, then the nearest one wins,
+	// with exact halves to even, like the other languages do.
+	function jsonFloat(x, f32) {
+		if (Number.isNaN(x)) return 'NaN';
+		if (x === Infinity) return 'Infinity';
+		if (x === -Infinity) return '-Infinity';
+		if (! f32 || ! x) return x;
+		x = Math.fround(x);
+		for (var p = 1; p < 9; ++p) {
+			var y = Number(x.toPrecision(p));
+			var ok = Math.fround(y) === x;
+			// exponent of the last digit
+			var q = Number(x.toExponential().split('e')[1]) - p + 1;
+			// toPrecision rounds exact halves away from zero
+			var tie = q < 0 ? Math.abs(x * Math.pow(2, 1 - q)) % 2 === 1 :
+				q < 11 && Math.abs(x) % Number('1e' + q) === Number('5e' + (q - 1));
+			if (ok && ! tie) return y;
+
+			// The neighbour on the other side of x may qualify, as the
+			// gap below a power of two is half the size of the one above.
+			var n = Math.round(y / Math.pow(10, q)) + (y < x ? 1 : -1);
+			var z = Number(n + 'e' + q);
+			if (Math.fround(z) === x && (! ok || n % 2 === 0)) return z;
+			if (ok) return y;
+		}
+		return Number(x.toPrecision(9));
+	}
+
+	// Returns the floating point of a JSON number, or the string "NaN",
+	// "Infinity" or "-Infinity", with null as zero.
+	function fromJSONFloat(path, v, f32) {
+		if (v == null) return 0;
+		switch (v) {
+		case 'NaN':
+			return NaN;
+		case 'Infinity':
+			return Infinity;
+		case '-Infinity':
+			return -Infinity;
+		}
+		if (typeof v !== 'number') jsonFail(path, v, 'number, "NaN", "Infinity" or "-Infinity"');
+		var x = f32 ? Math.fround(v) : v;
+		if (! Number.isFinite(x)) fail('colfer: ' + path + ': ' + v + ' out of range');
+		return x;
+	}
+{{- end}}
+{{- if .HasTimestamp}}
+
+	// Returns the RFC 3339 notation of a timestamp, with the year as in Go, or
+	// null when absent. The fraction has up to 9 digits, without trailing zeros.
+	function jsonTimestamp(t, ns) {
+		if (! t) return null;
+		var y = t.getUTCFullYear();
+		var s = String(Math.abs(y));
+		while (s.length < 4) s = '0' + s;
+		if (y < 0) s = '-' + s;
+		var iso = t.toISOString();
+		s += iso.slice(iso.indexOf('-', 1), iso.indexOf('.'));
+		ns = t.getUTCMilliseconds() * 1E6 + (ns || 0);
+		if (ns) s += '.' + String(ns + 1E9).slice(1).replace(/0+$/, '');
+		return s + 'Z';
+	}
+
+	// Returns the Date and the nanoseconds within the millisecond of an RFC
+	// 3339 string, or null for null.
+	function fromJSONTimestamp(path, v) {
+		if (v == null) return null;
+		var m = typeof v === 'string' && /^(-?[0-9]{4,})-([0-9]{2})-([0-9]{2})T([0-9]{2}):([0-9]{2}):([0-9]{2})(?:\.([0-9]{1,9}))?(?:Z|([+-])([0-9]{2}):([0-9]{2}))$/.exec(v);
+		if (m && m[1].replace('-', '').length > 4 && Math.abs(m[1]) <= 9999) m = null;
+		if (m) {
+			var t = new Date(0);
+			t.setUTCFullYear(Number(m[1]), m[2] - 1, Number(m[3]));
+			t.setUTCHours(Number(m[4]), Number(m[5]), Number(m[6]));
+			if (t.getUTCMonth() != m[2] - 1 || t.getUTCDate() != m[3] || m[4] > 23 || m[5] > 59 || m[6] > 59) m = null;
+		}
+		if (! m) jsonFail(path, v, 'RFC 3339 timestamp');
+
+		var ns = m[7] ? Number((m[7] + '00000000').slice(0, 9)) : 0;
+		var ms = t.getTime() + Math.floor(ns / 1E6);
+		if (m[8]) ms += (m[8] == '-' ? 6E4 : -6E4) * (m[9] * 60 + Number(m[10]));
+		if (! (ms >= -864E13 && ms <= 864E13))
+			fail('colfer: ' + path + ': ' + v + ' exceeds ECMA Date range');
+		return [new Date(ms), ns % 1E6];
+	}
+{{- end}}
+{{- if .HasText}}
+
+	// Returns the text with U+FFFD for any unpaired surrogates.
+	function jsonText(s) {
+		if (s == null) return '';
+		return s.replace(/[\ud800-\udbff][\udc00-\udfff]|[\ud800-\udfff]/g, function(c) {
+			return c.length == 2 ? c : '\ufffd';
+		});
+	}
+
+	// Returns the text of a JSON string, with null as the empty string.
+	function fromJSONText(path, v, max) {
+		if (v == null) return '';
+		if (typeof v !== 'string') jsonFail(path, v, 'string');
+		if (v.length * 3 > max && encodeUTF8(v).length > max)
+			fail('colfer: ' + path + ' size ' + encodeUTF8(v).length + ' exceeds ' + max + ' UTF-8 bytes');
+		return v;
+	}
+{{- end}}
+{{- if .HasBinary}}
+
+	var base64 = 'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/';
+
+	// Returns the standard base64 encoding, including padding.
+	function jsonBinary(bytes) {
+		if (! bytes) return '';
+		var s = '';
+		for (var i = 0; i < bytes.length; i += 3) {
+			var n = bytes[i] << 16 | bytes[i + 1] << 8 | bytes[i + 2];
+			s += base64[n >> 18] + base64[n >> 12 & 63];
+			s += i + 1 < bytes.length ? base64[n >> 6 & 63] : '=';
+			s += i + 2 < bytes.length ? base64[n & 63] : '=';
+		}
+		return s;
+	}
+
+	// Returns the bytes of a JSON string with the standard base64 encoding,
+	// with or without padding, with null as empty.
+	function fromJSONBinary(path, v, max) {
+		if (v == null) return new Uint8Array(0);
+		if (typeof v !== 'string' || ! /^[A-Za-z0-9+/]*={0,2}$/.test(v)
+			|| (v.indexOf('=') >= 0 ? v.length % 4 : v.length % 4 == 1))
+			jsonFail(path, v, 'standard base64');
+		v = v.replace(/=+$/, '');
+
+		var size = v.length * 3 >> 2;
+		if (size > max) fail('colfer: ' + path + ' size ' + size + ' exceeds ' + max + ' bytes');
+		var bytes = new Uint8Array(size);
+		for (var i = 0, bi = 0, n = 0; i < v.length; ++i) {
+			n = n << 6 | base64.indexOf(v[i]);
+			if ((i & 3) == 3) {
+				bytes[bi++] = n >> 16;
+				bytes[bi++] = n >> 8;
+				bytes[bi++] = n;
+				n = 0;
+			}
+		}
+		if ((i & 3) == 2) {
+			bytes[bi] = n >> 4;
+		} else if ((i & 3) == 3) {
+			bytes[bi++] = n >> 10;
+			bytes[bi] = n >> 2;
+		}
+		return bytes;
+	}
+{{- end}}
 }
 
 // NodeJS:
@@ -194,9 +413,16 @@ const ecmaMarshal = `
 	// All null entries in property {{.NameNative}} will be replaced with {{if eq .Type "text"}}an empty String{{else if eq .Type "binary"}}an empty Array{{else}}a new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}{{end}}.
 {{- end}}{{end}}{{end}}
 	this.{{.NameTitle}}.prototype.marshal = function(buf) {
-		if (! buf || !buf.length) buf = new Uint8Array(colferSizeMax);
-		var i = 0;
-		var view = new DataView(buf.buffer);
+		if (! buf || !buf.length) buf = new Uint8Array({{.SizeMaxNative}});
+		return buf.subarray(0, this.marshalTo(buf, 0));
+	}
+
+	// Serializes the object into an Uint8Array at index offset, and returns
+	// the end index. Nested objects are written into the same buffer, such
+	// that each byte is written only once. The view is optional.
+	this.{{.NameTitle}}.prototype.marshalTo = function(buf, offset, view) {
+		var i = offset;
+		if (! view) view = new DataView(buf.buffer, buf.byteOffset, buf.byteLength);
 
 {{range .Fields}}{{if eq .Type "bool"}}
 		if (this.{{.NameNative}})
@@ -283,8 +509,8 @@ const ecmaMarshal = `
  {{- if .TypeList}}
 		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
 			var a = this.{{.NameNative}};
-			if (a.length > colferListMax)
-				fail('colfer: {{.String}} length exceeds colferListMax');
+			if (a.length > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length exceeds {{.Struct.ListMaxNative}}');
 			buf[i++] = {{.Index}};
 			i = encodeVarint(buf, i, a.length);
 			a.forEach(function(f, fi) {
@@ -307,8 +533,8 @@ const ecmaMarshal = `
  {{- if .TypeList}}
 		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
 			var a = this.{{.NameNative}};
-			if (a.length > colferListMax)
-				fail('colfer: {{.String}} length exceeds colferListMax');
+			if (a.length > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length exceeds {{.Struct.ListMaxNative}}');
 			buf[i++] = {{.Index}};
 			i = encodeVarint(buf, i, a.length);
 			a.forEach(function(f) {
@@ -368,8 +594,8 @@ const ecmaMarshal = `
  {{- if .TypeList}}
 		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
 			var a = this.{{.NameNative}};
-			if (a.length > colferListMax)
-				fail('colfer: {{.String}} length exceeds colferListMax');
+			if (a.length > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length exceeds {{.Struct.ListMaxNative}}');
 			buf[i++] = {{.Index}};
 			i = encodeVarint(buf, i, a.length);
 
@@ -397,8 +623,8 @@ const ecmaMarshal = `
  {{- if .TypeList}}
 		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
 			var a = this.{{.NameNative}};
-			if (a.length > colferListMax)
-				fail('colfer: {{.String}} length exceeds colferListMax');
+			if (a.length > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length exceeds {{.Struct.ListMaxNative}}');
 			buf[i++] = {{.Index}};
 			i = encodeVarint(buf, i, a.length);
 			a.forEach(function(b, bi) {
@@ -423,8 +649,8 @@ const ecmaMarshal = `
 {{else if .TypeList}}
 		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
 			var a = this.{{.NameNative}};
-			if (a.length > colferListMax)
-				fail('colfer: {{.String}} length exceeds colferListMax');
+			if (a.length > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length exceeds {{.Struct.ListMaxNative}}');
 			buf[i++] = {{.Index}};
 			i = encodeVarint(buf, i, a.length);
 			a.forEach(function(v, vi) {
@@ -432,24 +658,20 @@ const ecmaMarshal = `
 					v = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}();
 					a[vi] = v;
 				}
-				var b = v.marshal();
-				buf.set(b, i);
-				i += b.length;
+				i = v.marshalTo(buf, i, view);
 			});
 		}
 {{else}}
 		if (this.{{.NameNative}}) {
 			buf[i++] = {{.Index}};
-			var b = this.{{.NameNative}}.marshal();
-			buf.set(b, i);
-			i += b.length;
+			i = this.{{.NameNative}}.marshalTo(buf, i, view);
 		}
 {{end}}{{end}}
 
 		buf[i++] = 127;
-		if (i >= colferSizeMax)
-			fail('colfer: {{.String}} serial size ' + size + ' exceeds ' + colferListMax + ' bytes');
-		return buf.subarray(0, i);
+		if (i - offset >= {{.SizeMaxNative}})
+			fail('colfer: {{.String}} serial size ' + (i - offset) + ' exceeds ' + {{.SizeMaxNative}} + ' bytes');
+		return i;
 	}`
 
 const ecmaUnmarshal = `
@@ -558,8 +780,8 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) fail('colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER');
-			if (l > colferListMax)
-				fail('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			if (l > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length ' + l + ' exceeds ' + {{.Struct.ListMaxNative}} + ' elements');
 			if (i + l * 4 > data.length) fail(EOF);
 
 			this.{{.NameNative}} = new Float32Array(l);
@@ -579,8 +801,8 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) fail('colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER');
-			if (l > colferListMax)
-				fail('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			if (l > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length ' + l + ' exceeds ' + {{.Struct.ListMaxNative}} + ' elements');
 			if (i + l * 8 > data.length) fail(EOF);
 
 			this.{{.NameNative}} = new Float64Array(l);
@@ -626,16 +848,16 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) fail('colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER');
-			if (l > colferListMax)
-				fail('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			if (l > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length ' + l + ' exceeds ' + {{.Struct.ListMaxNative}} + ' elements');
 
 			this.{{.NameNative}} = new Array(l);
 			for (var n = 0; n < l; ++n) {
 				var size = readVarint();
 				if (size < 0)
 					fail('colfer: {{.String}} element ' + this.{{.NameNative}}.length + ' size exceeds Number.MAX_SAFE_INTEGER');
-				else if (size > colferSizeMax)
-					fail('colfer: {{.String}} element ' + this.{{.NameNative}}.length + ' size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes');
+				else if (size > {{.Struct.SizeMaxNative}})
+					fail('colfer: {{.String}} element ' + this.{{.NameNative}}.length + ' size ' + size + ' exceeds ' + {{.Struct.SizeMaxNative}} + ' UTF-8 bytes');
 
 				var start = i;
 				i += size;
@@ -646,8 +868,8 @@ const ecmaUnmarshal = `
 			var size = readVarint();
 			if (size < 0)
 				fail('colfer: {{.String}} size exceeds Number.MAX_SAFE_INTEGER');
-			else if (size > colferSizeMax)
-				fail('colfer: {{.String}} size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes');
+			else if (size > {{.Struct.SizeMaxNative}})
+				fail('colfer: {{.String}} size ' + size + ' exceeds ' + {{.Struct.SizeMaxNative}} + ' UTF-8 bytes');
 
 			var start = i;
 			i += size;
@@ -661,16 +883,16 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) fail('colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER');
-			if (l > colferListMax)
-				fail('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			if (l > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length ' + l + ' exceeds ' + {{.Struct.ListMaxNative}} + ' elements');
 
 			this.{{.NameNative}} = new Array(l);
 			for (var n = 0; n < l; ++n) {
 				var size = readVarint();
 				if (size < 0)
 					fail('colfer: {{.String}} element ' + this.{{.NameNative}}.length + ' size exceeds Number.MAX_SAFE_INTEGER');
-				else if (size > colferSizeMax)
-					fail('colfer: {{.String}} element ' + this.{{.NameNative}}.length + ' size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes');
+				else if (size > {{.Struct.SizeMaxNative}})
+					fail('colfer: {{.String}} element ' + this.{{.NameNative}}.length + ' size ' + size + ' exceeds ' + {{.Struct.SizeMaxNative}} + ' UTF-8 bytes');
 
 				var start = i;
 				i += size;
@@ -681,8 +903,8 @@ const ecmaUnmarshal = `
 			var size = readVarint();
 			if (size < 0)
 				fail('colfer: {{.String}} size exceeds Number.MAX_SAFE_INTEGER');
-			else if (size > colferSizeMax)
-				fail('colfer: {{.String}} size ' + size + ' exceeds ' + colferSizeMax + ' bytes');
+			else if (size > {{.Struct.SizeMaxNative}})
+				fail('colfer: {{.String}} size ' + size + ' exceeds ' + {{.Struct.SizeMaxNative}} + ' bytes');
 
 			var start = i;
 			i += size;
@@ -695,8 +917,8 @@ const ecmaUnmarshal = `
 		if (header == {{.Index}}) {
 			var l = readVarint();
 			if (l < 0) fail('colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER');
-			if (l > colferListMax)
-				fail('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			if (l > {{.Struct.ListMaxNative}})
+				fail('colfer: {{.String}} length ' + l + ' exceeds ' + {{.Struct.ListMaxNative}} + ' elements');
 
 			for (var n = 0; n < l; ++n) {
 				var o = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}();
@@ -714,7 +936,115 @@ const ecmaUnmarshal = `
 		}
 {{end}}{{end}}
 		if (header != 127) fail('colfer: unknown header at byte ' + (i - 1));
-		if (i > colferSizeMax)
-			fail('colfer: {{.String}} serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes');
+		if (i > {{.SizeMaxNative}})
+			fail('colfer: {{.String}} serial size ' + size + ' exceeds ' + {{.SizeMaxNative}} + ' bytes');
 		return i;
 	}`
+
+const ecmaJSON = `
+	// Returns a plain object with the canonical JSON values, as used by
+	// JSON.stringify. The 64-bit integers are strings, binaries are base64
+	// and timestamps are RFC 3339 with nanoseconds.
+{{- range .Fields}}{{if and .TypeList .TypeRef}}
+	// Any null entries in property {{.NameNative}} are represented as a new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}.
+{{- end}}{{end}}
+	this.{{.NameTitle}}.prototype.toJSON = function() {
+		return {
+{{- range .Fields}}
+			'{{.Name}}':
+{{- if .TypeList}}
+ {{- if eq .Type "float32" "float64"}} Array.prototype.map.call(this.{{.NameNative}} || [], function(x) {
+				return jsonFloat(x{{if eq .Type "float32"}}, true{{end}});
+			})
+ {{- else if eq .Type "text"}} (this.{{.NameNative}} || []).map(function(s) {
+				return jsonText(s);
+			})
+ {{- else if eq .Type "binary"}} (this.{{.NameNative}} || []).map(function(b) {
+				return jsonBinary(b);
+			})
+ {{- else}} (this.{{.NameNative}} || []).map(function(o) {
+				return (o || new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}()).toJSON();
+			})
+ {{- end}}
+{{- else if eq .Type "bool"}} !! this.{{.NameNative}}
+{{- else if eq .Type "uint64" "int64"}} String(this.{{.NameNative}} || 0)
+{{- else if eq .Type "float32"}} jsonFloat(this.{{.NameNative}}, true)
+{{- else if eq .Type "float64"}} jsonFloat(this.{{.NameNative}})
+{{- else if eq .Type "timestamp"}} jsonTimestamp(this.{{.NameNative}}, this.{{.NameNative}}_ns)
+{{- else if eq .Type "text"}} jsonText(this.{{.NameNative}})
+{{- else if eq .Type "binary"}} jsonBinary(this.{{.NameNative}})
+{{- else if .TypeRef}} this.{{.NameNative}} ? this.{{.NameNative}}.toJSON() : null
+{{- else}} this.{{.NameNative}} || 0
+{{- end}},
+{{- end}}
+		};
+	}
+
+	// Sets the properties from a value of JSON.parse, conform toJSON, and
+	// returns the object. Absent properties and null values read as zero.
+	this.{{.NameTitle}}.prototype.fromJSON = function(json) {
+		if (json === null || typeof json !== 'object' || Array.isArray(json))
+			jsonFail('{{.String}}', json, 'object');
+		for (var p in json) switch (p) {
+{{- range .Fields}}
+		case '{{.Name}}':
+{{- end}}
+			break;
+		default:
+			fail('colfer: {{.String}} has no field ' + JSON.stringify(p));
+		}
+{{range .Fields}}
+{{- if .TypeList}}
+		var a = fromJSONList('{{.String}}', json['{{.Name}}'], {{.Struct.ListMaxNative}});
+ {{- if eq .Type "float32" "float64"}}
+		this.{{.NameNative}} = new {{if eq .Type "float32"}}Float32Array{{else}}Float64Array{{end}}(a.length);
+		for (var n = 0; n < a.length; ++n)
+			this.{{.NameNative}}[n] = fromJSONFloat('{{.String}}[' + n + ']', a[n]{{if eq .Type "float32"}}, true{{end}});
+ {{- else if eq .Type "text"}}
+		this.{{.NameNative}} = a.map(function(s, n) {
+			return fromJSONText('{{.String}}[' + n + ']', s, {{.Struct.SizeMaxNative}});
+		});
+ {{- else if eq .Type "binary"}}
+		this.{{.NameNative}} = a.map(function(s, n) {
+			return fromJSONBinary('{{.String}}[' + n + ']', s, {{.Struct.SizeMaxNative}});
+		});
+ {{- else}}
+		this.{{.NameNative}} = a.map(function(o) {
+			return new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}().fromJSON(o == null ? {} : o);
+		});
+ {{- end}}
+{{- else if eq .Type "bool"}}
+		var v = json['{{.Name}}'];
+		if (v != null && typeof v !== 'boolean') jsonFail('{{.String}}', v, 'boolean');
+		this.{{.NameNative}} = !! v;
+{{- else if eq .Type "uint8"}}
+		this.{{.NameNative}} = fromJSONInteger('{{.String}}', json['{{.Name}}'], 0, 255);
+{{- else if eq .Type "uint16"}}
+		this.{{.NameNative}} = fromJSONInteger('{{.String}}', json['{{.Name}}'], 0, 65535);
+{{- else if eq .Type "uint32"}}
+		this.{{.NameNative}} = fromJSONInteger('{{.String}}', json['{{.Name}}'], 0, 4294967295);
+{{- else if eq .Type "uint64"}}
+		this.{{.NameNative}} = fromJSONInteger('{{.String}}', json['{{.Name}}'], 0, Number.MAX_SAFE_INTEGER, true);
+{{- else if eq .Type "int32"}}
+		this.{{.NameNative}} = fromJSONInteger('{{.String}}', json['{{.Name}}'], -2147483648, 2147483647);
+{{- else if eq .Type "int64"}}
+		this.{{.NameNative}} = fromJSONInteger('{{.String}}', json['{{.Name}}'], Number.MIN_SAFE_INTEGER, Number.MAX_SAFE_INTEGER, true);
+{{- else if eq .Type "float32"}}
+		this.{{.NameNative}} = fromJSONFloat('{{.String}}', json['{{.Name}}'], true);
+{{- else if eq .Type "float64"}}
+		this.{{.NameNative}} = fromJSONFloat('{{.String}}', json['{{.Name}}']);
+{{- else if eq .Type "timestamp"}}
+		var t = fromJSONTimestamp('{{.String}}', json['{{.Name}}']);
+		this.{{.NameNative}} = t ? t[0] : null;
+		this.{{.NameNative}}_ns = t ? t[1] : 0;
+{{- else if eq .Type "text"}}
+		this.{{.NameNative}} = fromJSONText('{{.String}}', json['{{.Name}}'], {{.Struct.SizeMaxNative}});
+{{- else if eq .Type "binary"}}
+		this.{{.NameNative}} = fromJSONBinary('{{.String}}', json['{{.Name}}'], {{.Struct.SizeMaxNative}});
+{{- else}}
+		var o = json['{{.Name}}'];
+		this.{{.NameNative}} = o == null ? null : new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}().fromJSON(o);
+{{- end}}
+{{end}}
+		return this;
+	}`
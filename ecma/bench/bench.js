@@ -25,6 +25,20 @@ testData.forEach(function(o, i) {
 // Reusable instance.
 var buffer = new Uint8Array(1024);
 
+// Nested test data with lists on each level.
+function newTree(depth, width) {
+	var o = new Colfer.tree.Node({name: 'node', depth: depth, weights: [1, 2]});
+	if (depth) {
+		o.children = [];
+		for (var i = 0; i < width; i++) o.children.push(newTree(depth - 1, width));
+		o.first = newTree(depth - 1, 1);
+	}
+	return o;
+}
+var testTree = newTree(4, 6);
+var testTreeColfer = testTree.marshal(new Uint8Array(64 * 1024)).slice();
+var treeBuffer = new Uint8Array(64 * 1024);
+
 var suite = new Benchmark.Suite;
 suite.add('marshal Colfer', function() {
 		testData[0].marshal(buffer);
@@ -32,12 +46,18 @@ suite.add('marshal Colfer', function() {
 		testData[2].marshal(buffer);
 		testData[3].marshal(buffer);
 	})
+	.add('marshal nested Colfer', function() {
+		testTree.marshal(treeBuffer);
+	})
 	.add('unmarshal Colfer', function() {
 		new Colfer.bench.Colfer().unmarshal(testColfer[0]);
 		new Colfer.bench.Colfer().unmarshal(testColfer[1]);
 		new Colfer.bench.Colfer().unmarshal(testColfer[2]);
 		new Colfer.bench.Colfer().unmarshal(testColfer[3]);
 	})
+	.add('unmarshal nested Colfer', function() {
+		new Colfer.tree.Node().unmarshal(testTreeColfer);
+	})
 	.add('marshal JSON', function() {
 		JSON.stringify(testData[0]);
 		JSON.stringify(testData[1]);
@@ -65,8 +65,15 @@ var gen = new function() {
 	// All null entries in property as will be replaced with an empty Array.
 	this.O.prototype.marshal = function(buf) {
 		if (! buf || !buf.length) buf = new Uint8Array(colferSizeMax);
-		var i = 0;
-		var view = new DataView(buf.buffer);
+		return buf.subarray(0, this.marshalTo(buf, 0));
+	}
+
+	// Serializes the object into an Uint8Array at index offset, and returns
+	// the end index. Nested objects are written into the same buffer, such
+	// that each byte is written only once. The view is optional.
+	this.O.prototype.marshalTo = function(buf, offset, view) {
+		var i = offset;
+		if (! view) view = new DataView(buf.buffer, buf.byteOffset, buf.byteLength);
 
 
 		if (this.b)
@@ -203,9 +210,7 @@ var gen = new function() {
 
 		if (this.o) {
 			buf[i++] = 10;
-			var b = this.o.marshal();
-			buf.set(b, i);
-			i += b.length;
+			i = this.o.marshalTo(buf, i, view);
 		}
 
 		if (this.os && this.os.length) {
@@ -219,9 +224,7 @@ var gen = new function() {
 					v = new gen.O();
 					a[vi] = v;
 				}
-				var b = v.marshal();
-				buf.set(b, i);
-				i += b.length;
+				i = v.marshalTo(buf, i, view);
 			});
 		}
 
@@ -309,9 +312,9 @@ var gen = new function() {
 
 
 		buf[i++] = 127;
-		if (i >= colferSizeMax)
-			fail('colfer: gen.o serial size ' + size + ' exceeds ' + colferListMax + ' bytes');
-		return buf.subarray(0, i);
+		if (i - offset >= colferSizeMax)
+			fail('colfer: gen.o serial size ' + (i - offset) + ' exceeds ' + colferSizeMax + ' bytes');
+		return i;
 	}
 
 	// Deserializes the object from an Uint8Array and returns the number of bytes read.
@@ -584,6 +587,131 @@ var gen = new function() {
 		return i;
 	}
 
+	// Returns a plain object with the canonical JSON values, as used by
+	// JSON.stringify. The 64-bit integers are strings, binaries are base64
+	// and timestamps are RFC 3339 with nanoseconds.
+	// Any null entries in property os are represented as a new gen.O.
+	this.O.prototype.toJSON = function() {
+		return {
+			'b': !! this.b,
+			'u32': this.u32 || 0,
+			'u64': String(this.u64 || 0),
+			'i32': this.i32 || 0,
+			'i64': String(this.i64 || 0),
+			'f32': jsonFloat(this.f32, true),
+			'f64': jsonFloat(this.f64),
+			't': jsonTimestamp(this.t, this.t_ns),
+			's': jsonText(this.s),
+			'a': jsonBinary(this.a),
+			'o': this.o ? this.o.toJSON() : null,
+			'os': (this.os || []).map(function(o) {
+				return (o || new gen.O()).toJSON();
+			}),
+			'ss': (this.ss || []).map(function(s) {
+				return jsonText(s);
+			}),
+			'as': (this.as || []).map(function(b) {
+				return jsonBinary(b);
+			}),
+			'u8': this.u8 || 0,
+			'u16': this.u16 || 0,
+			'f32s': Array.prototype.map.call(this.f32s || [], function(x) {
+				return jsonFloat(x, true);
+			}),
+			'f64s': Array.prototype.map.call(this.f64s || [], function(x) {
+				return jsonFloat(x);
+			}),
+		};
+	}
+
+	// Sets the properties from a value of JSON.parse, conform toJSON, and
+	// returns the object. Absent properties and null values read as zero.
+	this.O.prototype.fromJSON = function(json) {
+		if (json === null || typeof json !== 'object' || Array.isArray(json))
+			jsonFail('gen.o', json, 'object');
+		for (var p in json) switch (p) {
+		case 'b':
+		case 'u32':
+		case 'u64':
+		case 'i32':
+		case 'i64':
+		case 'f32':
+		case 'f64':
+		case 't':
+		case 's':
+		case 'a':
+		case 'o':
+		case 'os':
+		case 'ss':
+		case 'as':
+		case 'u8':
+		case 'u16':
+		case 'f32s':
+		case 'f64s':
+			break;
+		default:
+			fail('colfer: gen.o has no field ' + JSON.stringify(p));
+		}
+
+		var v = json['b'];
+		if (v != null && typeof v !== 'boolean') jsonFail('gen.o.b', v, 'boolean');
+		this.b = !! v;
+
+		this.u32 = fromJSONInteger('gen.o.u32', json['u32'], 0, 4294967295);
+
+		this.u64 = fromJSONInteger('gen.o.u64', json['u64'], 0, Number.MAX_SAFE_INTEGER, true);
+
+		this.i32 = fromJSONInteger('gen.o.i32', json['i32'], -2147483648, 2147483647);
+
+		this.i64 = fromJSONInteger('gen.o.i64', json['i64'], Number.MIN_SAFE_INTEGER, Number.MAX_SAFE_INTEGER, true);
+
+		this.f32 = fromJSONFloat('gen.o.f32', json['f32'], true);
+
+		this.f64 = fromJSONFloat('gen.o.f64', json['f64']);
+
+		var t = fromJSONTimestamp('gen.o.t', json['t']);
+		this.t = t ? t[0] : null;
+		this.t_ns = t ? t[1] : 0;
+
+		this.s = fromJSONText('gen.o.s', json['s'], colferSizeMax);
+
+		this.a = fromJSONBinary('gen.o.a', json['a'], colferSizeMax);
+
+		var o = json['o'];
+		this.o = o == null ? null : new gen.O().fromJSON(o);
+
+		var a = fromJSONList('gen.o.os', json['os'], colferListMax);
+		this.os = a.map(function(o) {
+			return new gen.O().fromJSON(o == null ? {} : o);
+		});
+
+		var a = fromJSONList('gen.o.ss', json['ss'], colferListMax);
+		this.ss = a.map(function(s, n) {
+			return fromJSONText('gen.o.ss[' + n + ']', s, colferSizeMax);
+		});
+
+		var a = fromJSONList('gen.o.as', json['as'], colferListMax);
+		this.as = a.map(function(s, n) {
+			return fromJSONBinary('gen.o.as[' + n + ']', s, colferSizeMax);
+		});
+
+		this.u8 = fromJSONInteger('gen.o.u8', json['u8'], 0, 255);
+
+		this.u16 = fromJSONInteger('gen.o.u16', json['u16'], 0, 65535);
+
+		var a = fromJSONList('gen.o.f32s', json['f32s'], colferListMax);
+		this.f32s = new Float32Array(a.length);
+		for (var n = 0; n < a.length; ++n)
+			this.f32s[n] = fromJSONFloat('gen.o.f32s[' + n + ']', a[n], true);
+
+		var a = fromJSONList('gen.o.f64s', json['f64s'], colferListMax);
+		this.f64s = new Float64Array(a.length);
+		for (var n = 0; n < a.length; ++n)
+			this.f64s[n] = fromJSONFloat('gen.o.f64s[' + n + ']', a[n]);
+
+		return this;
+	}
+
 	// private section
 
 	var encodeVarint = function(bytes, i, x) {
@@ -653,16 +781,16 @@ var gen = new function() {
 			var c = bytes[i++];
 			if (c > 127) {
 				if (c > 191 && c < 224) {
-					c = (i >= bytes.length) ? 63 : (c & 31) << 6 | bytes[i++] & 63;
+					c = (i >= bytes.length) ? 0xfffd : (c & 31) << 6 | bytes[i++] & 63;
 				} else if (c > 223 && c < 240) {
-					c = (i + 1 >= bytes.length) ? 63 : (c & 15) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
+					c = (i + 1 >= bytes.length) ? 0xfffd : (c & 15) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
 				} else if (c > 239 && c < 248) {
-					c = (i+2 >= bytes.length) ? 63 : (c & 7) << 18 | (bytes[i++] & 63) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
-				} else c = 63;
+					c = (i+2 >= bytes.length) ? 0xfffd : (c & 7) << 18 | (bytes[i++] & 63) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
+				} else c = 0xfffd;
 			}
 
 			if (c <= 0xffff) s += String.fromCharCode(c);
-			else if (c > 0x10ffff) s += '?';
+			else if (c > 0x10ffff) s += '\ufffd';
 			else {
 				c -= 0x10000;
 				s += String.fromCharCode(c >> 10 | 0xd800)
@@ -671,6 +799,176 @@ var gen = new function() {
 		}
 		return s;
 	}
+
+	function jsonFail(path, v, want) {
+		fail('colfer: ' + path + ': got ' + JSON.stringify(v) + ', want ' + want);
+	}
+
+	// Returns the array of a JSON value, with null as an empty array.
+	function fromJSONList(path, v, max) {
+		if (v == null) return [];
+		if (! Array.isArray(v)) jsonFail(path, v, 'array');
+		if (v.length > max)
+			fail('colfer: ' + path + ' length ' + v.length + ' exceeds ' + max + ' elements');
+		return v;
+	}
+
+	// Returns the integer of a JSON number within range, with null as zero.
+	// Strings with the decimal value are accepted when digits is set.
+	function fromJSONInteger(path, v, min, max, digits) {
+		if (v == null) return 0;
+		if (digits && typeof v === 'string' && /^-?[0-9]+$/.test(v)) v = Number(v);
+		if (typeof v !== 'number' || ! Number.isInteger(v)) jsonFail(path, v, 'integer');
+		if (v < min || v > max) fail('colfer: ' + path + ': ' + v + ' out of range');
+		return v;
+	}
+
+	// Returns the canonical JSON value of a floating point. 32-bit values get
+	// the Number with the shortest decimals which map back into the same value.
+	// When both neighbours of a precision qualify, then the nearest one wins,
+	// with exact halves to even, like the other languages do.
+	function jsonFloat(x, f32) {
+		if (Number.isNaN(x)) return 'NaN';
+		if (x === Infinity) return 'Infinity';
+		if (x === -Infinity) return '-Infinity';
+		if (! f32 || ! x) return x;
+		x = Math.fround(x);
+		for (var p = 1; p < 9; ++p) {
+			var y = Number(x.toPrecision(p));
+			var ok = Math.fround(y) === x;
+			// exponent of the last digit
+			var q = Number(x.toExponential().split('e')[1]) - p + 1;
+			// toPrecision rounds exact halves away from zero
+			var tie = q < 0 ? Math.abs(x * Math.pow(2, 1 - q)) % 2 === 1 :
+				q < 11 && Math.abs(x) % Number('1e' + q) === Number('5e' + (q - 1));
+			if (ok && ! tie) return y;
+
+			// The neighbour on the other side of x may qualify, as the
+			// gap below a power of two is half the size of the one above.
+			var n = Math.round(y / Math.pow(10, q)) + (y < x ? 1 : -1);
+			var z = Number(n + 'e' + q);
+			if (Math.fround(z) === x && (! ok || n % 2 === 0)) return z;
+			if (ok) return y;
+		}
+		return Number(x.toPrecision(9));
+	}
+
+	// Returns the floating point of a JSON number, or the string "NaN",
+	// "Infinity" or "-Infinity", with null as zero.
+	function fromJSONFloat(path, v, f32) {
+		if (v == null) return 0;
+		switch (v) {
+		case 'NaN':
+			return NaN;
+		case 'Infinity':
+			return Infinity;
+		case '-Infinity':
+			return -Infinity;
+		}
+		if (typeof v !== 'number') jsonFail(path, v, 'number, "NaN", "Infinity" or "-Infinity"');
+		var x = f32 ? Math.fround(v) : v;
+		if (! Number.isFinite(x)) fail('colfer: ' + path + ': ' + v + ' out of range');
+		return x;
+	}
+
+	// Returns the RFC 3339 notation of a timestamp, with the year as in Go, or
+	// null when absent. The fraction has up to 9 digits, without trailing zeros.
+	function jsonTimestamp(t, ns) {
+		if (! t) return null;
+		var y = t.getUTCFullYear();
+		var s = String(Math.abs(y));
+		while (s.length < 4) s = '0' + s;
+		if (y < 0) s = '-' + s;
+		var iso = t.toISOString();
+		s += iso.slice(iso.indexOf('-', 1), iso.indexOf('.'));
+		ns = t.getUTCMilliseconds() * 1E6 + (ns || 0);
+		if (ns) s += '.' + String(ns + 1E9).slice(1).replace(/0+$/, '');
+		return s + 'Z';
+	}
+
+	// Returns the Date and the nanoseconds within the millisecond of an RFC
+	// 3339 string, or null for null.
+	function fromJSONTimestamp(path, v) {
+		if (v == null) return null;
+		var m = typeof v === 'string' && /^(-?[0-9]{4,})-([0-9]{2})-([0-9]{2})T([0-9]{2}):([0-9]{2}):([0-9]{2})(?:\.([0-9]{1,9}))?(?:Z|([+-])([0-9]{2}):([0-9]{2}))$/.exec(v);
+		if (m && m[1].replace('-', '').length > 4 && Math.abs(m[1]) <= 9999) m = null;
+		if (m) {
+			var t = new Date(0);
+			t.setUTCFullYear(Number(m[1]), m[2] - 1, Number(m[3]));
+			t.setUTCHours(Number(m[4]), Number(m[5]), Number(m[6]));
+			if (t.getUTCMonth() != m[2] - 1 || t.getUTCDate() != m[3] || m[4] > 23 || m[5] > 59 || m[6] > 59) m = null;
+		}
+		if (! m) jsonFail(path, v, 'RFC 3339 timestamp');
+
+		var ns = m[7] ? Number((m[7] + '00000000').slice(0, 9)) : 0;
+		var ms = t.getTime() + Math.floor(ns / 1E6);
+		if (m[8]) ms += (m[8] == '-' ? 6E4 : -6E4) * (m[9] * 60 + Number(m[10]));
+		if (! (ms >= -864E13 && ms <= 864E13))
+			fail('colfer: ' + path + ': ' + v + ' exceeds ECMA Date range');
+		return [new Date(ms), ns % 1E6];
+	}
+
+	// Returns the text with U+FFFD for any unpaired surrogates.
+	function jsonText(s) {
+		if (s == null) return '';
+		return s.replace(/[\ud800-\udbff][\udc00-\udfff]|[\ud800-\udfff]/g, function(c) {
+			return c.length == 2 ? c : '\ufffd';
+		});
+	}
+
+	// Returns the text of a JSON string, with null as the empty string.
+	function fromJSONText(path, v, max) {
+		if (v == null) return '';
+		if (typeof v !== 'string') jsonFail(path, v, 'string');
+		if (v.length * 3 > max && encodeUTF8(v).length > max)
+			fail('colfer: ' + path + ' size ' + encodeUTF8(v).length + ' exceeds ' + max + ' UTF-8 bytes');
+		return v;
+	}
+
+	var base64 = 'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/';
+
+	// Returns the standard base64 encoding, including padding.
+	function jsonBinary(bytes) {
+		if (! bytes) return '';
+		var s = '';
+		for (var i = 0; i < bytes.length; i += 3) {
+			var n = bytes[i] << 16 | bytes[i + 1] << 8 | bytes[i + 2];
+			s += base64[n >> 18] + base64[n >> 12 & 63];
+			s += i + 1 < bytes.length ? base64[n >> 6 & 63] : '=';
+			s += i + 2 < bytes.length ? base64[n & 63] : '=';
+		}
+		return s;
+	}
+
+	// Returns the bytes of a JSON string with the standard base64 encoding,
+	// with or without padding, with null as empty.
+	function fromJSONBinary(path, v, max) {
+		if (v == null) return new Uint8Array(0);
+		if (typeof v !== 'string' || ! /^[A-Za-z0-9+/]*={0,2}$/.test(v)
+			|| (v.indexOf('=') >= 0 ? v.length % 4 : v.length % 4 == 1))
+			jsonFail(path, v, 'standard base64');
+		v = v.replace(/=+$/, '');
+
+		var size = v.length * 3 >> 2;
+		if (size > max) fail('colfer: ' + path + ' size ' + size + ' exceeds ' + max + ' bytes');
+		var bytes = new Uint8Array(size);
+		for (var i = 0, bi = 0, n = 0; i < v.length; ++i) {
+			n = n << 6 | base64.indexOf(v[i]);
+			if ((i & 3) == 3) {
+				bytes[bi++] = n >> 16;
+				bytes[bi++] = n >> 8;
+				bytes[bi++] = n;
+				n = 0;
+			}
+		}
+		if ((i & 3) == 2) {
+			bytes[bi] = n >> 4;
+		} else if ((i & 3) == 3) {
+			bytes[bi++] = n >> 10;
+			bytes[bi] = n >> 2;
+		}
+		return bytes;
+	}
 }
 
 // NodeJS:
@@ -98,6 +98,87 @@ QUnit.test('unmarshal', function(assert) {
 	}
 });
 
+// Returns the canonical JSON per serial from testdata/json.txt, which must be
+// identical in all languages. Each case has a back flag for whether the JSON
+// maps back into the serial. Numbers beyond 53 bits are omitted.
+function newJSONGoldenCases() {
+	var text;
+	if (typeof require === 'function') {
+		text = require('fs').readFileSync(__dirname + '/../testdata/json.txt', 'utf8');
+	} else {
+		var req = new XMLHttpRequest();
+		req.open('GET', '../testdata/json.txt', false);
+		req.overrideMimeType('text/plain; charset=utf-8');
+		req.send();
+		text = req.responseText;
+	}
+
+	var cases = [];
+	text.split('\n').forEach(function(line) {
+		if (! line || line[0] == '#') return;
+		var fields = /^([0-9a-f]+) (<->|->) ([^]*)$/.exec(line);
+		if (! fields) throw 'malformed JSON golden ' + line;
+		var o = JSON.parse(fields[3]);
+		if (Math.abs(Number(o.u64)) > Number.MAX_SAFE_INTEGER) return;
+		if (Math.abs(Number(o.i64)) > Number.MAX_SAFE_INTEGER) return;
+		cases.push({hex: fields[1], json: fields[3], back: fields[2] == '<->'});
+	});
+	return cases;
+}
+
+QUnit.test('toJSON', function(assert) {
+	var zero;
+	newJSONGoldenCases().forEach(function(gold) {
+		if (gold.hex == '7f') zero = gold.json;
+		try {
+			var o = new gen.O();
+			o.unmarshal(decodeHex(gold.hex));
+			assert.equal(JSON.stringify(o), gold.json, gold.hex);
+		} catch (err) {
+			assert.equal(err, 'no error', gold.hex);
+		}
+	});
+
+	var o = new gen.O({f32: -0, s: 'A\ud800\udc00\udc00', t: new Date(0)});
+	assert.equal(JSON.stringify(o), zero.replace('"t":null', '"t":"1970-01-01T00:00:00Z"').replace('"s":""', '"s":"A\ud800\udc00\ufffd"'), 'edges');
+});
+
+QUnit.test('fromJSON', function(assert) {
+	newJSONGoldenCases().forEach(function(gold) {
+		if (! gold.back) return;
+		try {
+			var o = new gen.O().fromJSON(JSON.parse(gold.json));
+			assert.equal(encodeHex(o.marshal()), gold.hex, gold.json);
+		} catch (err) {
+			assert.equal(err, 'no error', gold.json);
+		}
+	});
+
+	var o = new gen.O().fromJSON({u64: 9007199254740991, a: 'AQI', os: [null], t: '2015-09-08T21:04:10.5+02:00'});
+	assert.equal(o.u64, 9007199254740991, 'u64 number');
+	assert.deepEqual(o.a, new Uint8Array([1, 2]), 'base64 without padding');
+	assert.deepEqual(o.os, [new gen.O()], 'null list entry');
+	assert.equal(o.t.getTime(), 1441739050500, 'timestamp with offset');
+
+	[
+		{u64: '18446744073709551615'},
+		{i64: -9007199254740992},
+		{u8: 256},
+		{u32: 1.5},
+		{f32: 1e39},
+		{f64: 'nan'},
+		{t: '2015-09-08'},
+		{a: '@'},
+		{x: null},
+		{o: {x: null}},
+		[]
+	].forEach(function(json) {
+		assert.throws(function() {
+			new gen.O().fromJSON(json);
+		}, JSON.stringify(json));
+	});
+});
+
 function encodeHex(bytes) {
 	var s = '';
 	if (!bytes) return s;
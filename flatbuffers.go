@@ -0,0 +1,146 @@
+package colfer
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pascaldekloe/name"
+)
+
+// fbsNatives sets the FlatBuffers names and datatypes.
+func fbsNatives(packages Packages) {
+	for _, p := range packages {
+		p.NameNative = strings.Replace(p.Name, "/", ".", -1)
+	}
+
+	for _, p := range packages {
+		for _, s := range p.Structs {
+			s.NameNative = s.NameTitle()
+
+			for _, f := range s.Fields {
+				f.NameNative = name.SnakeCase(f.Name)
+
+				switch f.Type {
+				default:
+					f.TypeNative = f.TypeRef.NameTitle()
+					if f.TypeRef.Pkg != p {
+						f.TypeNative = f.TypeRef.Pkg.NameNative + "." + f.TypeNative
+					}
+				case "bool":
+					f.TypeNative = f.Type
+				case "uint8":
+					f.TypeNative = "ubyte"
+				case "uint16":
+					f.TypeNative = "ushort"
+				case "uint32":
+					f.TypeNative = "uint"
+				case "uint64":
+					f.TypeNative = "ulong"
+				case "int32":
+					f.TypeNative = "int"
+				case "int64":
+					f.TypeNative = "long"
+				case "float32":
+					f.TypeNative = "float"
+				case "float64":
+					f.TypeNative = "double"
+				case "timestamp":
+					f.TypeNative = "ColferTimestamp"
+				case "text":
+					f.TypeNative = "string"
+				case "binary":
+					f.TypeNative = "[ubyte]"
+					if f.TypeList {
+						f.TypeNative = "ColferBinary"
+					}
+				}
+				if f.TypeList {
+					f.TypeNative = "[" + f.TypeNative + "]"
+				}
+			}
+		}
+	}
+}
+
+// GenerateFlatBuffers writes the FlatBuffers definitions into the respective
+// "Colfer.fbs" files. Timestamps map to struct ColferTimestamp and binary
+// list elements map to table ColferBinary, both of which are declared in each
+// namespace that needs one.
+func GenerateFlatBuffers(basedir string, packages Packages) error {
+	sources, err := FlatBuffersSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// FlatBuffersSources returns the definitions of GenerateFlatBuffers in memory.
+func FlatBuffersSources(packages Packages) (Sources, error) {
+	fbsNatives(packages)
+
+	t := template.New("fbs")
+	t.Funcs(template.FuncMap{"hasBinaryList": fbsHasBinaryList})
+	template.Must(t.Parse(fbsCode))
+
+	sources := make(Sources)
+	for _, p := range packages {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, p); err != nil {
+			return nil, err
+		}
+		sources[p.Name+"/Colfer.fbs"] = buf.Bytes()
+	}
+	return sources, nil
+}
+
+// fbsHasBinaryList returns whether p has any binary list fields.
+func fbsHasBinaryList(p *Package) bool {
+	for _, s := range p.Structs {
+		for _, f := range s.Fields {
+			if f.TypeList && f.Type == "binary" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const fbsCode = `// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}}.
+{{if .Refs}}
+{{range .Refs}}include "{{.Name}}/Colfer.fbs";
+{{end}}{{end}}
+{{with .DocText "/// "}}{{.}}
+{{end -}}
+namespace {{.NameNative}};
+{{if .HasTimestamp}}
+/// ColferTimestamp is a point in time with nanosecond precision.
+struct ColferTimestamp {
+	/// Seconds since the Unix epoch.
+	seconds:long;
+	/// Nanoseconds within the second.
+	nanos:uint;
+}
+{{end}}
+{{- if hasBinaryList .}}
+/// ColferBinary wraps binaries, as vectors of vectors are not supported.
+table ColferBinary {
+	bytes:[ubyte];
+}
+{{end}}
+{{- range .Structs}}
+{{with .DocText "/// "}}{{.}}
+{{end -}}
+table {{.NameNative}} {
+{{- range .Fields}}
+{{- with .DocText "\t/// "}}
+{{.}}
+{{- end}}
+	{{.NameNative}}:{{.TypeNative}};
+{{- end}}
+}
+{{end}}
+{{- if eq (len .Structs) 1}}
+root_type {{(index .Structs 0).NameNative}};
+{{end}}`
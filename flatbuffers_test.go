@@ -0,0 +1,7 @@
+package colfer
+
+import "testing"
+
+func TestFlatBuffersSources(t *testing.T) {
+	verifyGoldenSources(t, FlatBuffersSources, "testdata/flatbuffers")
+}
@@ -2,28 +2,30 @@ package colfer
 
 import (
 	"bytes"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"fmt"
 	"strings"
 	"text/template"
 )
 
-// GenerateGo writes the code into file "Colfer.go".
-func GenerateGo(basedir string, packages Packages) error {
-	t := template.New("go-code")
-	template.Must(t.Parse(goCode))
-	template.Must(t.New("marshal-field").Parse(goMarshalField))
-	template.Must(t.New("marshal-field-len").Parse(goMarshalFieldLen))
-	template.Must(t.New("unmarshal-field").Parse(goUnmarshalField))
-	template.Must(t.New("unmarshal-varint").Parse(goUnmarshalVarint))
-
+// goNatives sets the Go package names and datatypes.
+func goNatives(packages Packages) {
 	for _, p := range packages {
 		p.NameNative = p.Name[strings.LastIndexByte(p.Name, '/')+1:]
+		p.SizeMaxNative = "ColferSizeMax"
+		p.ListMaxNative = "ColferListMax"
 	}
 
 	for _, p := range packages {
 		for _, s := range p.Structs {
+			s.SizeMaxNative = p.SizeMaxNative
+			if s.SizeMax != "" {
+				s.SizeMaxNative = s.NameTitle() + "SizeMax"
+			}
+			s.ListMaxNative = p.ListMaxNative
+			if s.ListMax != "" && s.HasList() {
+				s.ListMaxNative = s.NameTitle() + "ListMax"
+			}
+
 			for _, f := range s.Fields {
 				switch f.Type {
 				default:
@@ -44,27 +46,86 @@ func GenerateGo(basedir string, packages Packages) error {
 				}
 			}
 		}
+	}
+}
 
-		var buf bytes.Buffer
-		if err := t.Execute(&buf, p); err != nil {
-			return err
+// ValueRef returns whether f holds a data structure by value, conform
+// Package.ValueTypes. Lists always do. Fields remain a pointer when the value
+// would contain itself, i.e., when the referenced data structure leads back to
+// the parent with fields only.
+func (f *Field) ValueRef() bool {
+	if f.TypeRef == nil || !f.Struct.Pkg.ValueTypes {
+		return false
+	}
+	return f.TypeList || !leadsTo(f.TypeRef, f.Struct, make(map[*Struct]bool))
+}
+
+// HasValueRef returns whether s has one or more fields which hold a data
+// structure by value, not counting lists.
+func (s *Struct) HasValueRef() bool {
+	for _, f := range s.Fields {
+		if !f.TypeList && f.ValueRef() {
+			return true
 		}
+	}
+	return false
+}
 
-		path := filepath.Join(basedir, p.Name)
-		if err := os.MkdirAll(path, 0777); err != nil {
-			return err
+// leadsTo returns whether s contains dst through fields which are no list.
+func leadsTo(s, dst *Struct, seen map[*Struct]bool) bool {
+	if s == dst {
+		return true
+	}
+	if seen[s] {
+		return false
+	}
+	seen[s] = true
+	for _, f := range s.Fields {
+		if f.TypeRef != nil && !f.TypeList && leadsTo(f.TypeRef, dst, seen) {
+			return true
 		}
+	}
+	return false
+}
+
+// GenerateGo writes the code into file "Colfer.go".
+func GenerateGo(basedir string, packages Packages) error {
+	sources, err := GoSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
 
-		path = filepath.Join(path, "Colfer.go")
-		if err := ioutil.WriteFile(path, buf.Bytes(), 0666); err != nil {
-			return err
+// GoSources returns the code of GenerateGo in memory.
+func GoSources(packages Packages) (Sources, error) {
+	t := template.New("go-code")
+	template.Must(t.Parse(goCode))
+	template.Must(t.New("marshal-field").Parse(goMarshalField))
+	template.Must(t.New("marshal-field-len").Parse(goMarshalFieldLen))
+	template.Must(t.New("append-field").Parse(goAppendField))
+	template.Must(t.New("unmarshal-field").Parse(goUnmarshalField))
+	template.Must(t.New("unmarshal-varint").Parse(goUnmarshalVarint))
+	template.Must(t.New("json-append-field").Parse(goJSONAppendField))
+	template.Must(t.New("json-unmarshal-field").Parse(goJSONUnmarshalField))
+
+	goNatives(packages)
+
+	sources := make(Sources)
+	for _, p := range packages {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, p); err != nil {
+			return nil, err
 		}
 
-		if _, err := Format(path); err != nil {
-			return err
+		path := p.Name + "/Colfer.go"
+		clean, err := FormatSource(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("colfer: format %q: %s", path, err)
 		}
+		sources[path] = clean
 	}
-	return nil
+	return sources, nil
 }
 
 const goCode = `{{.DocText "// "}}
@@ -74,15 +135,32 @@ package {{.NameNative}}
 // The compiler used schema file {{.SchemaFileList}}.
 
 import (
+{{- if .HasBinary}}
+	"encoding/base64"
+{{- end}}
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 {{- if .HasFloat}}
 	"math"
 {{- end}}
+{{- if .HasNumber}}
+	"strconv"
+{{- end}}
+{{- if .HasTimestamp}}
+	"strings"
+{{- end}}
+	"sync"
 {{- if .HasTimestamp}}
 	"time"
 {{- end}}
+{{- if .HasText}}
+	"unicode/utf8"
+{{- end}}
+{{- if and .ZeroCopy .HasText}}
+	"unsafe"
+{{- end}}
 {{- range .Refs}}
 	"{{.Name}}"
 {{- end}}
@@ -90,13 +168,34 @@ import (
 
 var intconv = binary.BigEndian
 
+// colferBuffers recycles the encoding space of MarshalBinary.
+var colferBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+{{- if and .ZeroCopy .HasText}}
+
+// colferString returns b as a string without copying. The content of the
+// string changes with any modification of b.
+func colferString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+{{- end}}
+
 // Colfer configuration attributes
 var (
-	// ColferSizeMax is the upper limit for serial byte sizes.
-	ColferSizeMax = {{.SizeMax}}
+	// {{.SizeMaxNative}} is the upper limit for serial byte sizes.
+	{{.SizeMaxNative}} = {{.SizeMax}}
 {{- if .HasList}}
-	// ColferListMax is the upper limit for the number of elements in a list.
-	ColferListMax = {{.ListMax}}
+	// {{.ListMaxNative}} is the upper limit for the number of elements in a list.
+	{{.ListMaxNative}} = {{.ListMax}}
+{{- end}}
+{{- range .Structs}}
+{{- if .SizeMax}}
+	// {{.SizeMaxNative}} is the upper limit for serial byte sizes of {{.NameTitle}}.
+	{{.SizeMaxNative}} = {{.SizeMax}}
+{{- end}}
+{{- if and .ListMax .HasList}}
+	// {{.ListMaxNative}} is the upper limit for the number of elements in a list of {{.NameTitle}}.
+	{{.ListMaxNative}} = {{.ListMax}}
+{{- end}}
 {{- end}}
 )
 
@@ -121,16 +220,232 @@ type ColferTail int
 func (i ColferTail) Error() string {
 	return fmt.Sprintf("colfer: data continuation at byte %d", i)
 }
+{{- if .HasText}}
+
+// colferAppendJSONString appends s as a JSON string, with the escapes of
+// JSON.stringify from ECMAScript. Malformed UTF-8 is replaced with U+FFFD.
+func colferAppendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\uFFFD"...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < ' ' {
+				buf = append(buf, '\\', 'u', '0', '0', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+{{- end}}
+{{- if .HasFloat}}
+
+// colferAppendJSONFloat appends the shortest representation which parses back
+// into the same value with bitSize, in the number notation of ECMAScript.
+func colferAppendJSONFloat(buf []byte, x float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(x):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(x, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(x, -1):
+		return append(buf, "\"-Infinity\""...)
+	case x == 0:
+		return append(buf, '0') // including negative zero
+	}
+
+	// compare with the precision of bitSize
+	abs := math.Abs(x)
+	format := byte('f')
+	if bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) || bitSize == 64 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, x, format, -1, bitSize)
+	if format == 'e' {
+		// exponent without leading zero, like e-7 instead of e-07
+		if n := len(buf); buf[n-4] == 'e' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// colferJSONFloat parses a JSON number, or the string "NaN", "Infinity" or
+// "-Infinity", with bitSize. Null reads as zero.
+func colferJSONFloat(data []byte, bitSize int) (float64, error) {
+	switch string(data) {
+	case "null":
+		return 0, nil
+	case "\"NaN\"":
+		return math.NaN(), nil
+	case "\"Infinity\"":
+		return math.Inf(1), nil
+	case "\"-Infinity\"":
+		return math.Inf(-1), nil
+	}
+	x, err := strconv.ParseFloat(string(data), bitSize)
+	if err != nil {
+		if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("number %s out of range", data)
+		}
+		return 0, fmt.Errorf("got %s, want number, \"NaN\", \"Infinity\" or \"-Infinity\"", data)
+	}
+	return x, nil
+}
+{{- end}}
+{{- if .HasInt64}}
+
+// colferJSONUint64 parses a JSON string with the decimal value, or a number up
+// to Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have lost
+// precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONUint64(data []byte) (uint64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(s, 10, 64)
+	}
+	x, err := strconv.ParseUint(string(data), 10, 64)
+	if err == nil && x > 1<<53-1 {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
+// colferJSONInt64 parses a JSON string with the decimal value, or a number
+// within ±Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have
+// lost precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONInt64(data []byte) (int64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+	x, err := strconv.ParseInt(string(data), 10, 64)
+	if err == nil && (x > 1<<53-1 || x < -(1<<53-1)) {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+{{- end}}
+{{- if .HasTimestamp}}
+
+// colferJSONTime parses a JSON string in RFC 3339 format, including the years
+// beyond 0000–9999, as formatted by package time. Null reads as zero.
+func colferJSONTime(data []byte) (time.Time, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil {
+		return time.Time{}, err
+	}
+
+	i := strings.IndexByte(*s, '-')
+	if i == 0 {
+		i = strings.IndexByte((*s)[1:], '-') + 1
+	}
+	if i <= 0 || i == 4 {
+		t, err := time.Parse(time.RFC3339Nano, *s)
+		return t.UTC(), err
+	}
+
+	year, err := strconv.Atoi((*s)[:i])
+	if err != nil || (year >= 0 && year <= 9999) {
+		return time.Time{}, fmt.Errorf("malformed year %q", (*s)[:i])
+	}
+	// The Gregorian calendar repeats itself every 400 years.
+	base := 2000 + year%400
+	if year < 0 {
+		base += 400
+	}
+	t, err := time.Parse(time.RFC3339Nano, strconv.Itoa(base)+(*s)[i:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC().AddDate(year-base, 0, 0), nil
+}
+{{- end}}
+{{- if .HasBinary}}
+
+// colferAppendJSONBinary appends b as a JSON string with the standard base64
+// encoding, including padding.
+func colferAppendJSONBinary(buf, b []byte) []byte {
+	n := base64.StdEncoding.EncodedLen(len(b))
+	offset := len(buf)
+	if cap(buf)-offset < n+2 {
+		grown := make([]byte, offset, 2*cap(buf)+n+2)
+		copy(grown, buf)
+		buf = grown
+	}
+	buf = buf[:offset+n+2]
+	buf[offset] = '"'
+	base64.StdEncoding.Encode(buf[offset+1:], b)
+	buf[offset+n+1] = '"'
+	return buf
+}
+
+// colferJSONBinary parses a JSON string with the standard base64 encoding,
+// with or without padding. Null and the empty string read as nil.
+func colferJSONBinary(data []byte) ([]byte, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil || *s == "" {
+		return nil, err
+	}
+	enc := base64.StdEncoding
+	if len(*s)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	return enc.DecodeString(*s)
+}
+{{- end}}
 {{range .Structs}}
 {{.DocText "// "}}
 type {{.NameTitle}} struct {
 {{range .Fields}}{{.DocText "\t// "}}
-	{{.NameTitle}}	{{if .TypeList}}[]{{end}}{{if .TypeRef}}*{{end}}{{.TypeNative}}
+	{{.NameTitle}}	{{if .TypeList}}[]{{end}}{{if and .TypeRef (not .ValueRef)}}*{{end}}{{.TypeNative}}
 {{end}}}
-
+{{if .FixedSizeMax}}
+// {{.NameTitle}}FixedMax is the upper limit for serial byte sizes of {{.NameTitle}},
+// regardless of the field values. Buffers of this size need no MarshalLen.
+const {{.NameTitle}}FixedMax = {{.FixedSizeMax}}
+{{end}}
 // MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
-// If the buffer is too small, MarshalTo will panic.
-{{- range .Fields}}{{if and .TypeList .TypeRef}}
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+{{- range .Fields}}{{if and .TypeList .TypeRef (not .ValueRef)}}
 // All nil entries in o.{{.NameTitle}} will be replaced with a new value.
 {{- end}}{{end}}
 func (o *{{.NameTitle}}) MarshalTo(buf []byte) int {
@@ -146,58 +461,219 @@ func (o *{{.NameTitle}}) MarshalTo(buf []byte) int {
 func (o *{{.NameTitle}}) MarshalLen() (int, error) {
 	l := 1
 {{range .Fields}}{{template "marshal-field-len" .}}{{end}}
-	if l > ColferSizeMax {
-		return l, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", ColferSizeMax))
+	if l > {{.SizeMaxNative}} {
+		return l, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", {{.SizeMaxNative}}))
 	}
 	return l, nil
 }
 
 // MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
-{{- range .Fields}}{{if and .TypeList .TypeRef}}
+{{- range .Fields}}{{if and .TypeList .TypeRef (not .ValueRef)}}
 // All nil entries in o.{{.NameTitle}} will be replaced with a new value.
 {{- end}}{{end}}
 // The error return option is {{.Pkg.NameNative}}.ColferMax.
 func (o *{{.NameTitle}}) MarshalBinary() (data []byte, err error) {
-	l, err := o.MarshalLen()
+{{- if .FixedSizeMax}}
+	data, err = o.MarshalAppend(make([]byte, 0, {{.NameTitle}}FixedMax))
 	if err != nil {
 		return nil, err
 	}
-	data = make([]byte, l)
-	o.MarshalTo(data)
 	return data, nil
+{{- else}}
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+{{- end}}
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+{{- range .Fields}}{{if and .TypeList .TypeRef (not .ValueRef)}}
+// All nil entries in o.{{.NameTitle}} will be replaced with a new value.
+{{- end}}{{end}}
+// The error return option is {{.Pkg.NameNative}}.ColferMax, in which case dst is returned as is.
+func (o *{{.NameTitle}}) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+{{range .Fields}}{{template "append-field" .}}{{end}}
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > {{.SizeMaxNative}} {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", {{.SizeMaxNative}}))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+{{- range .Fields}}{{if and .TypeList .TypeRef (not .ValueRef)}}
+// All nil entries in o.{{.NameTitle}} will be replaced with a new value.
+{{- end}}{{end}}
+// The error return options are io.ErrShortBuffer and {{.Pkg.NameNative}}.ColferMax, in which case the content of buf is undefined.
+func (o *{{.NameTitle}}) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
 }
 
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
 // The error return options are io.EOF, {{.Pkg.NameNative}}.ColferError and {{.Pkg.NameNative}}.ColferMax.
+{{- if .Pkg.ZeroCopy}}
+//
+// Text and binaries, including those of any nested data structures, share
+// memory with data, i.e., they are not copied. Data must not be modified for
+// as long as any of the values are in use. Beware of buffer reuse, such as
+// reading the next message into the same slice, as this changes previously
+// decoded strings, which breaks the immutability assumption of Go. Appending
+// to a binary value does not affect data, because capacity is clipped.
+{{- end}}
+{{- if .Pkg.Reuse}}
+//
+// Any previous content of o is discarded, as with Reset. The lists, binaries
+// and nested data structures of the previous content are recycled, i.e., they
+// must not be in use elsewhere. No pointer may be shared amongst the fields and
+// list entries.
+{{- end}}
 func (o *{{.NameTitle}}) Unmarshal(data []byte) (int, error) {
 	if len(data) == 0 {
 		return 0, io.EOF
 	}
+{{- if .Pkg.Reuse}}
+{{- range .Fields}}{{if and .TypeRef (not .TypeList) (not .ValueRef)}}
+	reuse{{.NameTitle}} := o.{{.NameTitle}}
+{{- end}}{{end}}
+	o.Reset()
+{{- end}}
 	header := data[0]
 	i := 1
 {{range .Fields}}{{template "unmarshal-field" .}}{{end}}
 	if header != 0x7f {
 		return 0, ColferError(i - 1)
 	}
-	if i < ColferSizeMax {
+	if i < {{.SizeMaxNative}} {
 		return i, nil
 	}
 eof:
-	if i >= ColferSizeMax {
-		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", ColferSizeMax))
+	if i >= {{.SizeMaxNative}} {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", {{.SizeMaxNative}}))
 	}
 	return 0, io.EOF
 }
 
 // UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
 // The error return options are io.EOF, {{.Pkg.NameNative}}.ColferError, {{.Pkg.NameNative}}.ColferTail and {{.Pkg.NameNative}}.ColferMax.
+{{- if .Pkg.ZeroCopy}}
+// The interface does not permit retention of data, so unlike Unmarshal, the
+// content is copied first, with a single allocation.
+{{- end}}
 func (o *{{.NameTitle}}) UnmarshalBinary(data []byte) error {
+{{- if .Pkg.ZeroCopy}}
+	data = append([]byte(nil), data...)
+{{- end}}
 	i, err := o.Unmarshal(data)
 	if i < len(data) && err == nil {
 		return ColferTail(i)
 	}
 	return err
 }
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+{{- range .Fields}}{{if and .TypeList .TypeRef (not .ValueRef)}}
+// Any nil entries in o.{{.NameTitle}} are written as a new value.
+{{- end}}{{end}}
+func (o *{{.NameTitle}}) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+{{range .Fields}}{{template "json-append-field" .}}{{end}}
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *{{.NameTitle}}) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct {{.String}}: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = {{.NameTitle}}{}
+	for name{{if .Fields}}, raw{{end}} := range doc {
+		switch name {
+{{- range .Fields}}{{template "json-unmarshal-field" .}}{{end}}
+		default:
+			return fmt.Errorf("colfer: struct {{.String}} has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value
+{{- if or .HasList (and .HasBinary (not .Pkg.ZeroCopy))}}, except that
+{{- if and .HasList .HasBinary (not .Pkg.ZeroCopy)}} lists and binaries keep
+{{- else if .HasList}} lists keep{{else}} binaries keep{{end}} their capacity for reuse
+{{- end}}.
+{{- if .HasValueRef}}
+// Nested data structure values are reset likewise.
+{{- end}}
+func (o *{{.NameTitle}}) Reset() {
+{{- if or .HasList (and .HasBinary (not .Pkg.ZeroCopy)) .HasValueRef}}
+	*o = {{.NameTitle}}{
+{{- range .Fields}}{{if or .TypeList (and (eq .Type "binary") (not .Struct.Pkg.ZeroCopy))}}
+		{{.NameTitle}}: o.{{.NameTitle}}[:0],
+{{- else if .ValueRef}}
+		{{.NameTitle}}: o.{{.NameTitle}},
+{{- end}}{{end}}
+	}
+{{- range .Fields}}{{if and .ValueRef (not .TypeList)}}
+	o.{{.NameTitle}}.Reset()
+{{- end}}{{end}}
+{{- else}}
+	*o = {{.NameTitle}}{}
+{{- end}}
+}
+{{- if .Pkg.Reuse}}
+
+var pool{{.NameTitle}} = sync.Pool{New: func() interface{} { return new({{.NameTitle}}) }}
+
+// Get{{.NameTitle}} returns an instance from a pool of free instances.
+// The content is undefined. Unmarshal discards it anyway, or use Reset.
+func Get{{.NameTitle}}() *{{.NameTitle}} {
+	return pool{{.NameTitle}}.Get().(*{{.NameTitle}})
+}
+
+// Put{{.NameTitle}} releases o for reuse by Get{{.NameTitle}}. Neither o nor any
+// of its lists, binaries and nested data structures may be used afterwards.
+func Put{{.NameTitle}}(o *{{.NameTitle}}) {
+	pool{{.NameTitle}}.Put(o)
+}
+{{- end}}
 {{end}}`
 
 const goMarshalField = `{{if eq .Type "bool"}}
@@ -399,6 +875,11 @@ const goMarshalField = `{{if eq .Type "bool"}}
 		}
 		buf[i] = byte(x)
 		i++
+{{- if .ValueRef}}
+		for vi := range o.{{.NameTitle}} {
+			i += o.{{.NameTitle}}[vi].MarshalTo(buf[i:])
+		}
+{{- else}}
 		for vi, v := range o.{{.NameTitle}} {
 			if v == nil {
 				v = new({{.TypeNative}})
@@ -406,6 +887,14 @@ const goMarshalField = `{{if eq .Type "bool"}}
 			}
 			i += v.MarshalTo(buf[i:])
 		}
+{{- end}}
+	}
+{{else if .ValueRef}}
+	// empty data structures are omitted
+	if n := o.{{.NameTitle}}.MarshalTo(buf[i:]); n > 1 {
+		copy(buf[i+1:], buf[i:i+n])
+		buf[i] = {{.Index}}
+		i += n + 1
 	}
 {{else}}
 	if v := o.{{.NameTitle}}; v != nil {
@@ -470,8 +959,8 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 {{else if eq .Type "float32"}}
  {{- if .TypeList}}
 	if x := len(o.{{.NameTitle}}); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+		if x > {{.Struct.ListMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
 		}
 		for l += 2+x*4; x >= 0x80; l++ {
 			x >>= 7
@@ -485,8 +974,8 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 {{else if eq .Type "float64"}}
  {{- if .TypeList}}
 	if x := len(o.{{.NameTitle}}); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+		if x > {{.Struct.ListMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
 		}
 		for l += 2+x*8; x >= 0x80; l++ {
 			x >>= 7
@@ -508,27 +997,27 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 {{else if eq .Type "text" "binary"}}
 	if x := len(o.{{.NameTitle}}); x != 0 {
  {{- if .TypeList}}
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+		if x > {{.Struct.ListMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
 		for _, a := range o.{{.NameTitle}} {
 			x = len(a)
-			if x > ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", ColferSizeMax))
+			if x > {{.Struct.SizeMaxNative}} {
+				return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", {{.Struct.SizeMaxNative}}))
 			}
 			for l += x+1; x >= 0x80; l++ {
 				x >>= 7
 			}
 		}
-		if l >= ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+		if l >= {{.Struct.SizeMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", {{.Struct.SizeMaxNative}}))
 		}
  {{- else}}
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", ColferSizeMax))
+		if x > {{.Struct.SizeMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", {{.Struct.SizeMaxNative}}))
 		}
 		for l += x+2; x >= 0x80; l++ {
 			x >>= 7
@@ -537,27 +1026,38 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 	}
 {{else if .TypeList}}
 	if x := len(o.{{.NameTitle}}); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+		if x > {{.Struct.ListMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
+{{- if .ValueRef}}
+		for vi := range o.{{.NameTitle}} {
+			vl, err := o.{{.NameTitle}}[vi].MarshalLen()
+{{- else}}
 		for _, v := range o.{{.NameTitle}} {
 			if v == nil {
 				l++
 				continue
 			}
 			vl, err := v.MarshalLen()
+{{- end}}
 			if err != nil {
 				return 0, err
 			}
 			l += vl
 		}
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+		if x > {{.Struct.SizeMaxNative}} {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", {{.Struct.SizeMaxNative}}))
 		}
 	}
+{{else if .ValueRef}}
+	if vl, err := o.{{.NameTitle}}.MarshalLen(); err != nil {
+		return 0, err
+	} else if vl > 1 {
+		l += vl + 1
+	}
 {{else}}
 	if v := o.{{.NameTitle}}; v != nil {
 		vl, err := v.MarshalLen()
@@ -568,6 +1068,221 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 	}
 {{end}}`
 
+const goAppendField = `{{if eq .Type "bool"}}
+	if o.{{.NameTitle}} {
+		buf = append(buf, {{.Index}})
+	}
+{{else if eq .Type "uint8"}}
+	if x := o.{{.NameTitle}}; x != 0 {
+		buf = append(buf, {{.Index}}, x)
+	}
+{{else if eq .Type "uint16"}}
+	if x := o.{{.NameTitle}}; x >= 1<<8 {
+		buf = append(buf, {{.Index}}, byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, {{.Index}}|0x80, byte(x))
+	}
+{{else if eq .Type "uint32"}}
+	if x := o.{{.NameTitle}}; x >= 1<<21 {
+		buf = append(buf, {{.Index}}|0x80, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, {{.Index}})
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+{{else if eq .Type "uint64"}}
+	if x := o.{{.NameTitle}}; x >= 1<<49 {
+		buf = append(buf, {{.Index}}|0x80, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, {{.Index}})
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+{{else if eq .Type "int32"}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf = append(buf, {{.Index}})
+		} else {
+			x = ^x + 1
+			buf = append(buf, {{.Index}}|0x80)
+		}
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+{{else if eq .Type "int64"}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf = append(buf, {{.Index}})
+		} else {
+			x = ^x + 1
+			buf = append(buf, {{.Index}}|0x80)
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+{{else if eq .Type "float32"}}
+ {{- if .TypeList}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > {{.Struct.ListMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
+		}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.{{.NameTitle}} {
+			x := math.Float32bits(v)
+			buf = append(buf, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+		}
+	}
+ {{- else}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		x := math.Float32bits(v)
+		buf = append(buf, {{.Index}}, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+ {{- end}}
+{{else if eq .Type "float64"}}
+ {{- if .TypeList}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > {{.Struct.ListMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
+		}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.{{.NameTitle}} {
+			x := math.Float64bits(v)
+			buf = append(buf, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+		}
+	}
+ {{- else}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		x := math.Float64bits(v)
+		buf = append(buf, {{.Index}}, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+ {{- end}}
+{{else if eq .Type "timestamp"}}
+	if v := o.{{.NameTitle}}; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf = append(buf, {{.Index}}, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+		} else {
+			buf = append(buf, {{.Index}}|0x80, byte(s>>56), byte(s>>48), byte(s>>40), byte(s>>32), byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+		}
+		buf = append(buf, byte(ns>>24), byte(ns>>16), byte(ns>>8), byte(ns))
+	}
+{{else if eq .Type "text" "binary"}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+ {{- if .TypeList}}
+		if l > {{.Struct.ListMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
+		}
+ {{- else}}
+		if l > {{.Struct.SizeMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", {{.Struct.SizeMaxNative}}))
+		}
+ {{- end}}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+ {{- if .TypeList}}
+		for _, a := range o.{{.NameTitle}} {
+			if len(a) > {{.Struct.SizeMaxNative}} {
+				return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", {{.Struct.SizeMaxNative}}))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= {{.Struct.SizeMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", {{.Struct.SizeMaxNative}}))
+		}
+ {{- else}}
+		buf = append(buf, o.{{.NameTitle}}...)
+ {{- end}}
+	}
+{{else if .TypeList}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > {{.Struct.ListMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", {{.Struct.ListMaxNative}}))
+		}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+{{- if .ValueRef}}
+		for vi := range o.{{.NameTitle}} {
+			v := &o.{{.NameTitle}}[vi]
+{{- else}}
+		for vi, v := range o.{{.NameTitle}} {
+			if v == nil {
+				v = new({{.TypeNative}})
+				o.{{.NameTitle}}[vi] = v
+			}
+{{- end}}
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= {{.Struct.SizeMaxNative}} {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", {{.Struct.SizeMaxNative}}))
+		}
+	}
+{{else if .ValueRef}}
+	{
+		start := len(buf)
+		var err error
+		if buf, err = o.{{.NameTitle}}.MarshalAppend(append(buf, {{.Index}})); err != nil {
+			return dst, err
+		}
+		if len(buf) == start+2 {
+			// empty data structure
+			buf = buf[:start]
+		}
+	}
+{{else}}
+	if v := o.{{.NameTitle}}; v != nil {
+		buf = append(buf, {{.Index}})
+		var err error
+		if buf, err = v.MarshalAppend(buf); err != nil {
+			return dst, err
+		}
+	}
+{{end}}`
+
 const goUnmarshalField = `{{if eq .Type "bool"}}
 	if header == {{.Index}} {
 		if i >= len(data) {
@@ -806,8 +1521,8 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
  {{- if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x > uint({{.Struct.ListMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, {{.Struct.ListMaxNative}}))
 		}
 
 		l := int(x)
@@ -816,7 +1531,16 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			i = end
 			goto eof
 		}
+{{- if .Struct.Pkg.Reuse}}
+		a := o.{{.NameTitle}}
+		if cap(a) < l {
+			a = make([]float32, l)
+		} else {
+			a = a[:l]
+		}
+{{- else}}
 		a := make([]float32, l)
+{{- end}}
 		for ai := range a {
 			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
 			i += 4
@@ -842,8 +1566,8 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
  {{- if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x > uint({{.Struct.ListMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, {{.Struct.ListMaxNative}}))
 		}
 		l := int(x)
 
@@ -851,7 +1575,16 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			i = end
 			goto eof
 		}
+{{- if .Struct.Pkg.Reuse}}
+		a := o.{{.NameTitle}}
+		if cap(a) < l {
+			a = make([]float64, l)
+		} else {
+			a = a[:l]
+		}
+{{- else}}
 		a := make([]float64, l)
+{{- end}}
 		for ai := range a {
 			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
 			i += 8
@@ -897,16 +1630,25 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
  {{- if .TypeList}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x > uint({{.Struct.ListMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, {{.Struct.ListMaxNative}}))
+		}
+{{- if .Struct.Pkg.Reuse}}
+		a := o.{{.NameTitle}}
+		if l := int(x); cap(a) < l {
+			a = make([]string, l)
+		} else {
+			a = a[:l]
 		}
+{{- else}}
 		a := make([]string, int(x))
+{{- end}}
 		o.{{.NameTitle}} = a
 
 		for ai := range a {
 {{template "unmarshal-varint" .}}
-			if x > uint(ColferSizeMax) {
-				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			if x > uint({{.Struct.SizeMaxNative}}) {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, {{.Struct.SizeMaxNative}}))
 			}
 
 			start := i
@@ -914,7 +1656,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			if i >= len(data) {
 				goto eof
 			}
+{{- if .Struct.Pkg.ZeroCopy}}
+			a[ai] = colferString(data[start:i])
+{{- else}}
 			a[ai] = string(data[start:i])
+{{- end}}
 		}
 
 		if i >= len(data) {
@@ -924,8 +1670,8 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		i++
 	}
  {{- else}}
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, ColferSizeMax))
+		if x > uint({{.Struct.SizeMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, {{.Struct.SizeMaxNative}}))
 		}
 
 		start := i
@@ -933,7 +1679,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if i >= len(data) {
 			goto eof
 		}
+{{- if .Struct.Pkg.ZeroCopy}}
+		o.{{.NameTitle}} = colferString(data[start:i])
+{{- else}}
 		o.{{.NameTitle}} = string(data[start:i])
+{{- end}}
 
 		header = data[i]
 		i++
@@ -943,9 +1693,27 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
  {{- if not .TypeList}}
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, ColferSizeMax))
+		if x > uint({{.Struct.SizeMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, {{.Struct.SizeMaxNative}}))
+		}
+{{- if .Struct.Pkg.ZeroCopy}}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
 		}
+		o.{{.NameTitle}} = data[start:i:i]
+{{- else}}
+{{- if .Struct.Pkg.Reuse}}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.{{.NameTitle}} = append(o.{{.NameTitle}}[:0], data[start:i]...)
+{{- else}}
 		v := make([]byte, int(x))
 
 		start := i
@@ -955,20 +1723,51 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		copy(v, data[start:i])
 		o.{{.NameTitle}} = v
+{{- end}}
+{{- end}}
 
 		header = data[i]
 		i++
  {{- else}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x > uint({{.Struct.ListMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, {{.Struct.ListMaxNative}}))
+		}
+{{- if .Struct.Pkg.Reuse}}
+		a := o.{{.NameTitle}}
+		if l := int(x); cap(a) < l {
+			a = append(a[:cap(a)], make([][]byte, l-cap(a))...)
+		} else {
+			a = a[:l]
 		}
+{{- else}}
 		a := make([][]byte, int(x))
+{{- end}}
 		o.{{.NameTitle}} = a
 		for ai := range a {
 {{template "unmarshal-varint" .}}
-			if x > uint(ColferSizeMax) {
-				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			if x > uint({{.Struct.SizeMaxNative}}) {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, {{.Struct.SizeMaxNative}}))
+			}
+{{- if .Struct.Pkg.ZeroCopy}}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+
+			a[ai] = data[start:i:i]
+{{- else}}
+{{- if .Struct.Pkg.Reuse}}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
 			}
+
+			a[ai] = append(a[ai][:0], data[start:i]...)
+{{- else}}
 			v := make([]byte, int(x))
 
 			start := i
@@ -979,6 +1778,8 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 
 			copy(v, data[start:i])
 			a[ai] = v
+{{- end}}
+{{- end}}
 		}
 
 		if i >= len(data) {
@@ -991,21 +1792,53 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 {{else if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x > uint({{.Struct.ListMaxNative}}) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, {{.Struct.ListMaxNative}}))
 		}
 
 		l := int(x)
+{{- if .ValueRef}}
+ {{- if .Struct.Pkg.Reuse}}
+		a := o.{{.NameTitle}}
+		if cap(a) < l {
+			a = append(a[:cap(a)], make([]{{.TypeNative}}, l-cap(a))...)
+		} else {
+			a = a[:l]
+		}
+ {{- else}}
+		a := make([]{{.TypeNative}}, l)
+ {{- end}}
+		for ai := range a {
+			v := &a[ai]
+{{- else if .Struct.Pkg.Reuse}}
+		a := o.{{.NameTitle}}
+		if cap(a) < l {
+			a = append(a[:cap(a)], make([]*{{.TypeNative}}, l-cap(a))...)
+		} else {
+			a = a[:l]
+		}
+		var malloc []{{.TypeNative}}
+		for ai, v := range a {
+			if v == nil {
+				if len(malloc) == 0 {
+					malloc = make([]{{.TypeNative}}, l-ai)
+				}
+				v = &malloc[0]
+				malloc = malloc[1:]
+				a[ai] = v
+			}
+{{- else}}
 		a := make([]*{{.TypeNative}}, l)
 		malloc := make([]{{.TypeNative}}, l)
 		for ai := range a {
 			v := &malloc[ai]
 			a[ai] = v
+{{- end}}
 
 			n, err := v.Unmarshal(data[i:])
 			if err != nil {
-				if err == io.EOF && len(data) >= ColferSizeMax {
-					return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+				if err == io.EOF && len(data) >= {{.Struct.SizeMaxNative}} {
+					return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", {{.Struct.SizeMaxNative}}))
 				}
 				return 0, err
 			}
@@ -1021,11 +1854,22 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
 {{else}}
 	if header == {{.Index}} {
+{{- if .ValueRef}}
+ {{- if not .Struct.Pkg.Reuse}}
+		o.{{.NameTitle}} = {{.TypeNative}}{}
+ {{- end}}
+{{- else if .Struct.Pkg.Reuse}}
+		if reuse{{.NameTitle}} == nil {
+			reuse{{.NameTitle}} = new({{.TypeNative}})
+		}
+		o.{{.NameTitle}} = reuse{{.NameTitle}}
+{{- else}}
 		o.{{.NameTitle}} = new({{.TypeNative}})
+{{- end}}
 		n, err := o.{{.NameTitle}}.Unmarshal(data[i:])
 		if err != nil {
-			if err == io.EOF && len(data) >= ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+			if err == io.EOF && len(data) >= {{.Struct.SizeMaxNative}} {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", {{.Struct.SizeMaxNative}}))
 			}
 			return 0, err
 		}
@@ -1062,3 +1906,161 @@ const goUnmarshalVarint = `		if i >= len(data) {
 			}
 		}
 `
+
+const goJSONAppendField = `	buf = append(buf, "{{if .Index}},{{end}}\"{{.Name}}\":"...)
+{{- if .TypeList}}
+	buf = append(buf, '[')
+	for i{{if not .ValueRef}}, v{{end}} := range o.{{.NameTitle}} {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+ {{- if eq .Type "float32"}}
+		buf = colferAppendJSONFloat(buf, float64(v), 32)
+ {{- else if eq .Type "float64"}}
+		buf = colferAppendJSONFloat(buf, v, 64)
+ {{- else if eq .Type "text"}}
+		buf = colferAppendJSONString(buf, v)
+ {{- else if eq .Type "binary"}}
+		buf = colferAppendJSONBinary(buf, v)
+ {{- else if .ValueRef}}
+		buf = o.{{.NameTitle}}[i].AppendJSON(buf)
+ {{- else}}
+		if v == nil {
+			v = new({{.TypeNative}})
+		}
+		buf = v.AppendJSON(buf)
+ {{- end}}
+	}
+	buf = append(buf, ']')
+{{- else if eq .Type "bool"}}
+	if o.{{.NameTitle}} {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+{{- else if eq .Type "uint8" "uint16" "uint32"}}
+	buf = strconv.AppendUint(buf, uint64(o.{{.NameTitle}}), 10)
+{{- else if eq .Type "int32"}}
+	buf = strconv.AppendInt(buf, int64(o.{{.NameTitle}}), 10)
+{{- else if eq .Type "uint64"}}
+	buf = append(buf, '"')
+	buf = strconv.AppendUint(buf, o.{{.NameTitle}}, 10)
+	buf = append(buf, '"')
+{{- else if eq .Type "int64"}}
+	buf = append(buf, '"')
+	buf = strconv.AppendInt(buf, o.{{.NameTitle}}, 10)
+	buf = append(buf, '"')
+{{- else if eq .Type "float32"}}
+	buf = colferAppendJSONFloat(buf, float64(o.{{.NameTitle}}), 32)
+{{- else if eq .Type "float64"}}
+	buf = colferAppendJSONFloat(buf, o.{{.NameTitle}}, 64)
+{{- else if eq .Type "timestamp"}}
+	if o.{{.NameTitle}}.IsZero() {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '"')
+		buf = o.{{.NameTitle}}.UTC().AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"')
+	}
+{{- else if eq .Type "text"}}
+	buf = colferAppendJSONString(buf, o.{{.NameTitle}})
+{{- else if eq .Type "binary"}}
+	buf = colferAppendJSONBinary(buf, o.{{.NameTitle}})
+{{- else if .ValueRef}}
+	buf = o.{{.NameTitle}}.AppendJSON(buf)
+{{- else}}
+	if o.{{.NameTitle}} == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = o.{{.NameTitle}}.AppendJSON(buf)
+	}
+{{- end}}
+`
+
+const goJSONUnmarshalField = `
+		case "{{.Name}}":
+{{- if .TypeList}}
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %s", err)
+			}
+			if len(a) > {{.Struct.ListMaxNative}} {
+				return ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", len(a), {{.Struct.ListMaxNative}}))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.{{.NameTitle}} = make([]{{if and .TypeRef (not .ValueRef)}}*{{end}}{{.TypeNative}}, len(a))
+			for ai, x := range a {
+ {{- if eq .Type "float32" "float64"}}
+				v, err := colferJSONFloat(x, {{if eq .Type "float32"}}32{{else}}64{{end}})
+				if err != nil {
+					return fmt.Errorf("colfer: {{.String}} element %d: %s", ai, err)
+				}
+				o.{{.NameTitle}}[ai] = {{if eq .Type "float32"}}float32(v){{else}}v{{end}}
+ {{- else if eq .Type "text"}}
+				if err := json.Unmarshal(x, &o.{{.NameTitle}}[ai]); err != nil {
+					return fmt.Errorf("colfer: {{.String}} element %d: %s", ai, err)
+				}
+				if l := len(o.{{.NameTitle}}[ai]); l > {{.Struct.SizeMaxNative}} {
+					return ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, l, {{.Struct.SizeMaxNative}}))
+				}
+ {{- else if eq .Type "binary"}}
+				v, err := colferJSONBinary(x)
+				if err != nil {
+					return fmt.Errorf("colfer: {{.String}} element %d: %s", ai, err)
+				}
+				if len(v) > {{.Struct.SizeMaxNative}} {
+					return ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, len(v), {{.Struct.SizeMaxNative}}))
+				}
+				o.{{.NameTitle}}[ai] = v
+ {{- else}}
+  {{- if not .ValueRef}}
+				o.{{.NameTitle}}[ai] = new({{.TypeNative}})
+  {{- end}}
+				if err := o.{{.NameTitle}}[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+ {{- end}}
+			}
+{{- else if eq .Type "bool" "uint8" "uint16" "uint32" "int32"}}
+			if err := json.Unmarshal(raw, &o.{{.NameTitle}}); err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %s", err)
+			}
+{{- else if eq .Type "uint64" "int64" "float32" "float64" "timestamp"}}
+			v, err := {{if eq .Type "uint64"}}colferJSONUint64(raw)
+ {{- else if eq .Type "int64"}}colferJSONInt64(raw)
+ {{- else if eq .Type "timestamp"}}colferJSONTime(raw)
+ {{- else}}colferJSONFloat(raw, {{if eq .Type "float32"}}32{{else}}64{{end}}){{end}}
+			if err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %s", err)
+			}
+			o.{{.NameTitle}} = {{if eq .Type "float32"}}float32(v){{else}}v{{end}}
+{{- else if eq .Type "text"}}
+			if err := json.Unmarshal(raw, &o.{{.NameTitle}}); err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %s", err)
+			}
+			if l := len(o.{{.NameTitle}}); l > {{.Struct.SizeMaxNative}} {
+				return ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", l, {{.Struct.SizeMaxNative}}))
+			}
+{{- else if eq .Type "binary"}}
+			v, err := colferJSONBinary(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %s", err)
+			}
+			if len(v) > {{.Struct.SizeMaxNative}} {
+				return ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", len(v), {{.Struct.SizeMaxNative}}))
+			}
+			o.{{.NameTitle}} = v
+{{- else if .ValueRef}}
+			if err := o.{{.NameTitle}}.UnmarshalJSON(raw); err != nil {
+				return err
+			}
+{{- else}}
+			if string(raw) != "null" {
+				o.{{.NameTitle}} = new({{.TypeNative}})
+				if err := o.{{.NameTitle}}.UnmarshalJSON(raw); err != nil {
+					return err
+				}
+			}
+{{- end}}`
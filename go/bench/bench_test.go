@@ -4,7 +4,11 @@ import (
 	"testing"
 
 	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pascaldekloe/colfer/go/bench/build/gen/batch"
 	gen "github.com/pascaldekloe/colfer/go/bench/build/gen/bench"
+	"github.com/pascaldekloe/colfer/go/bench/build/gen/tree"
+	reuse "github.com/pascaldekloe/colfer/go/bench/build/reuse/tree"
+	values "github.com/pascaldekloe/colfer/go/bench/build/values/batch"
 )
 
 var testData = []*gen.Colfer{
@@ -272,3 +276,172 @@ func BenchmarkUnmarshalReuse(b *testing.B) {
 		}
 	})
 }
+
+// newBatches returns the same content in pointer and in value layout.
+func newBatches(n int) (*batch.Batch, *values.Batch) {
+	pointers := &batch.Batch{Entries: make([]*batch.Entry, n)}
+	vals := &values.Batch{Entries: make([]values.Entry, n)}
+	for i := range vals.Entries {
+		o := testData[i%len(testData)]
+		pointers.Entries[i] = &batch.Entry{Key: o.Key, Port: o.Port, Ratio: o.Ratio, Route: o.Route}
+		vals.Entries[i] = values.Entry{Key: o.Key, Port: o.Port, Ratio: o.Ratio, Route: o.Route}
+	}
+	return pointers, vals
+}
+
+func BenchmarkMarshalList(b *testing.B) {
+	pointers, vals := newBatches(10000)
+	buf := make([]byte, 0, gen.ColferSizeMax)
+
+	b.Run("pointer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			var err error
+			holdSerial, err = pointers.MarshalAppend(buf[:0])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("value", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			var err error
+			holdSerial, err = vals.MarshalAppend(buf[:0])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkUnmarshalList(b *testing.B) {
+	pointers, _ := newBatches(10000)
+	serial, err := pointers.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("pointer", func(b *testing.B) {
+		b.SetBytes(int64(len(serial)))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			o := new(batch.Batch)
+			if _, err := o.Unmarshal(serial); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("value", func(b *testing.B) {
+		b.SetBytes(int64(len(serial)))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			o := new(values.Batch)
+			if _, err := o.Unmarshal(serial); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// newTree returns a deep structure with lists on each level.
+func newTree(depth, width int) *tree.Node {
+	o := &tree.Node{Name: "node", Depth: uint32(depth), Weights: []float64{1, 2}}
+	if depth != 0 {
+		o.Children = make([]*tree.Node, width)
+		for i := range o.Children {
+			o.Children[i] = newTree(depth-1, width)
+		}
+		o.First = newTree(depth-1, 1)
+	}
+	return o
+}
+
+func BenchmarkMarshalNested(b *testing.B) {
+	o := newTree(4, 6)
+	l, err := o.MarshalLen()
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, l)
+
+	// the two traversals which MarshalBinary used to do
+	b.Run("len+to", func(b *testing.B) {
+		b.SetBytes(int64(l))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			n, err := o.MarshalLen()
+			if err != nil {
+				b.Fatal(err)
+			}
+			holdSerial = buf[:o.MarshalTo(buf[:n])]
+		}
+	})
+
+	b.Run("into", func(b *testing.B) {
+		b.SetBytes(int64(l))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			n, err := o.MarshalInto(buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			holdSerial = buf[:n]
+		}
+	})
+
+	b.Run("append", func(b *testing.B) {
+		b.SetBytes(int64(l))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			var err error
+			holdSerial, err = o.MarshalAppend(buf[:0])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("binary", func(b *testing.B) {
+		b.SetBytes(int64(l))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			var err error
+			holdSerial, err = o.MarshalBinary()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkUnmarshalNested(b *testing.B) {
+	serial, err := newTree(4, 6).MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("new", func(b *testing.B) {
+		b.SetBytes(int64(len(serial)))
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			o := new(tree.Node)
+			if _, err := o.Unmarshal(serial); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reuse", func(b *testing.B) {
+		b.SetBytes(int64(len(serial)))
+		b.ReportAllocs()
+		o := new(reuse.Node)
+		for i := b.N; i > 0; i-- {
+			if _, err := o.Unmarshal(serial); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
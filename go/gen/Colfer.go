@@ -5,15 +5,24 @@ package gen
 // The compiler used schema file test.colf.
 
 import (
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 var intconv = binary.BigEndian
 
+// colferBuffers recycles the encoding space of MarshalBinary.
+var colferBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
 // Colfer configuration attributes
 var (
 	// ColferSizeMax is the upper limit for serial byte sizes.
@@ -44,6 +53,208 @@ func (i ColferTail) Error() string {
 	return fmt.Sprintf("colfer: data continuation at byte %d", i)
 }
 
+// colferAppendJSONString appends s as a JSON string, with the escapes of
+// JSON.stringify from ECMAScript. Malformed UTF-8 is replaced with U+FFFD.
+func colferAppendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\uFFFD"...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < ' ' {
+				buf = append(buf, '\\', 'u', '0', '0', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+
+// colferAppendJSONFloat appends the shortest representation which parses back
+// into the same value with bitSize, in the number notation of ECMAScript.
+func colferAppendJSONFloat(buf []byte, x float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(x):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(x, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(x, -1):
+		return append(buf, "\"-Infinity\""...)
+	case x == 0:
+		return append(buf, '0') // including negative zero
+	}
+
+	// compare with the precision of bitSize
+	abs := math.Abs(x)
+	format := byte('f')
+	if bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) || bitSize == 64 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, x, format, -1, bitSize)
+	if format == 'e' {
+		// exponent without leading zero, like e-7 instead of e-07
+		if n := len(buf); buf[n-4] == 'e' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// colferJSONFloat parses a JSON number, or the string "NaN", "Infinity" or
+// "-Infinity", with bitSize. Null reads as zero.
+func colferJSONFloat(data []byte, bitSize int) (float64, error) {
+	switch string(data) {
+	case "null":
+		return 0, nil
+	case "\"NaN\"":
+		return math.NaN(), nil
+	case "\"Infinity\"":
+		return math.Inf(1), nil
+	case "\"-Infinity\"":
+		return math.Inf(-1), nil
+	}
+	x, err := strconv.ParseFloat(string(data), bitSize)
+	if err != nil {
+		if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("number %s out of range", data)
+		}
+		return 0, fmt.Errorf("got %s, want number, \"NaN\", \"Infinity\" or \"-Infinity\"", data)
+	}
+	return x, nil
+}
+
+// colferJSONUint64 parses a JSON string with the decimal value, or a number up
+// to Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have lost
+// precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONUint64(data []byte) (uint64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(s, 10, 64)
+	}
+	x, err := strconv.ParseUint(string(data), 10, 64)
+	if err == nil && x > 1<<53-1 {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
+// colferJSONInt64 parses a JSON string with the decimal value, or a number
+// within ±Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have
+// lost precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONInt64(data []byte) (int64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+	x, err := strconv.ParseInt(string(data), 10, 64)
+	if err == nil && (x > 1<<53-1 || x < -(1<<53-1)) {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
+// colferJSONTime parses a JSON string in RFC 3339 format, including the years
+// beyond 0000–9999, as formatted by package time. Null reads as zero.
+func colferJSONTime(data []byte) (time.Time, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil {
+		return time.Time{}, err
+	}
+
+	i := strings.IndexByte(*s, '-')
+	if i == 0 {
+		i = strings.IndexByte((*s)[1:], '-') + 1
+	}
+	if i <= 0 || i == 4 {
+		t, err := time.Parse(time.RFC3339Nano, *s)
+		return t.UTC(), err
+	}
+
+	year, err := strconv.Atoi((*s)[:i])
+	if err != nil || (year >= 0 && year <= 9999) {
+		return time.Time{}, fmt.Errorf("malformed year %q", (*s)[:i])
+	}
+	// The Gregorian calendar repeats itself every 400 years.
+	base := 2000 + year%400
+	if year < 0 {
+		base += 400
+	}
+	t, err := time.Parse(time.RFC3339Nano, strconv.Itoa(base)+(*s)[i:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC().AddDate(year-base, 0, 0), nil
+}
+
+// colferAppendJSONBinary appends b as a JSON string with the standard base64
+// encoding, including padding.
+func colferAppendJSONBinary(buf, b []byte) []byte {
+	n := base64.StdEncoding.EncodedLen(len(b))
+	offset := len(buf)
+	if cap(buf)-offset < n+2 {
+		grown := make([]byte, offset, 2*cap(buf)+n+2)
+		copy(grown, buf)
+		buf = grown
+	}
+	buf = buf[:offset+n+2]
+	buf[offset] = '"'
+	base64.StdEncoding.Encode(buf[offset+1:], b)
+	buf[offset+n+1] = '"'
+	return buf
+}
+
+// colferJSONBinary parses a JSON string with the standard base64 encoding,
+// with or without padding. Null and the empty string read as nil.
+func colferJSONBinary(data []byte) ([]byte, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil || *s == "" {
+		return nil, err
+	}
+	enc := base64.StdEncoding
+	if len(*s)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	return enc.DecodeString(*s)
+}
+
 // O contains all supported data types.
 type O struct {
 	// B tests booleans.
@@ -85,7 +296,7 @@ type O struct {
 }
 
 // MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
-// If the buffer is too small, MarshalTo will panic.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
 // All nil entries in o.Os will be replaced with a new value.
 func (o *O) MarshalTo(buf []byte) int {
 	var i int
@@ -544,13 +755,287 @@ func (o *O) MarshalLen() (int, error) {
 // All nil entries in o.Os will be replaced with a new value.
 // The error return option is gen.ColferMax.
 func (o *O) MarshalBinary() (data []byte, err error) {
-	l, err := o.MarshalLen()
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// All nil entries in o.Os will be replaced with a new value.
+// The error return option is gen.ColferMax, in which case dst is returned as is.
+func (o *O) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if o.B {
+		buf = append(buf, 0)
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		buf = append(buf, 1|0x80, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 1)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		buf = append(buf, 2|0x80, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 2)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf = append(buf, 3)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 3|0x80)
+		}
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf = append(buf, 4)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 4|0x80)
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.F32; v != 0 {
+		x := math.Float32bits(v)
+		buf = append(buf, 5, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+
+	if v := o.F64; v != 0 {
+		x := math.Float64bits(v)
+		buf = append(buf, 6, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+
+	if v := o.T; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf = append(buf, 7, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+		} else {
+			buf = append(buf, 7|0x80, byte(s>>56), byte(s>>48), byte(s>>40), byte(s>>32), byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+		}
+		buf = append(buf, byte(ns>>24), byte(ns>>16), byte(ns>>8), byte(ns))
+	}
+
+	if l := len(o.S); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 8)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.S...)
+	}
+
+	if l := len(o.A); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 9)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.A...)
+	}
+
+	if v := o.O; v != nil {
+		buf = append(buf, 10)
+		var err error
+		if buf, err = v.MarshalAppend(buf); err != nil {
+			return dst, err
+		}
+	}
+
+	if l := len(o.Os); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 11)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for vi, v := range o.Os {
+			if v == nil {
+				v = new(O)
+				o.Os[vi] = v
+			}
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.Ss); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 12)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Ss {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 13)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.As {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf = append(buf, 14, x)
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf = append(buf, 15, byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 15|0x80, byte(x))
+	}
+
+	if l := len(o.F32s); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 16)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F32s {
+			x := math.Float32bits(v)
+			buf = append(buf, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 17)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F64s {
+			x := math.Float64bits(v)
+			buf = append(buf, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// All nil entries in o.Os will be replaced with a new value.
+// The error return options are io.ErrShortBuffer and gen.ColferMax, in which case the content of buf is undefined.
+func (o *O) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
 	}
-	data = make([]byte, l)
-	o.MarshalTo(data)
-	return data, nil
+	return len(data), nil
 }
 
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
@@ -1248,3 +1733,318 @@ func (o *O) UnmarshalBinary(data []byte) error {
 	}
 	return err
 }
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+// Any nil entries in o.Os are written as a new value.
+func (o *O) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"b\":"...)
+	if o.B {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+	buf = append(buf, ",\"u32\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U32), 10)
+	buf = append(buf, ",\"u64\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendUint(buf, o.U64, 10)
+	buf = append(buf, '"')
+	buf = append(buf, ",\"i32\":"...)
+	buf = strconv.AppendInt(buf, int64(o.I32), 10)
+	buf = append(buf, ",\"i64\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendInt(buf, o.I64, 10)
+	buf = append(buf, '"')
+	buf = append(buf, ",\"f32\":"...)
+	buf = colferAppendJSONFloat(buf, float64(o.F32), 32)
+	buf = append(buf, ",\"f64\":"...)
+	buf = colferAppendJSONFloat(buf, o.F64, 64)
+	buf = append(buf, ",\"t\":"...)
+	if o.T.IsZero() {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '"')
+		buf = o.T.UTC().AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"')
+	}
+	buf = append(buf, ",\"s\":"...)
+	buf = colferAppendJSONString(buf, o.S)
+	buf = append(buf, ",\"a\":"...)
+	buf = colferAppendJSONBinary(buf, o.A)
+	buf = append(buf, ",\"o\":"...)
+	if o.O == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = o.O.AppendJSON(buf)
+	}
+	buf = append(buf, ",\"os\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Os {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		if v == nil {
+			v = new(O)
+		}
+		buf = v.AppendJSON(buf)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"ss\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Ss {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONString(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"as\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.As {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONBinary(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"u8\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U8), 10)
+	buf = append(buf, ",\"u16\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U16), 10)
+	buf = append(buf, ",\"f32s\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.F32s {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONFloat(buf, float64(v), 32)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"f64s\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.F64s {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONFloat(buf, v, 64)
+	}
+	buf = append(buf, ']')
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *O) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include gen.ColferMax.
+func (o *O) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct gen.o: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = O{}
+	for name, raw := range doc {
+		switch name {
+		case "b":
+			if err := json.Unmarshal(raw, &o.B); err != nil {
+				return fmt.Errorf("colfer: gen.o.b: %s", err)
+			}
+		case "u32":
+			if err := json.Unmarshal(raw, &o.U32); err != nil {
+				return fmt.Errorf("colfer: gen.o.u32: %s", err)
+			}
+		case "u64":
+			v, err := colferJSONUint64(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.u64: %s", err)
+			}
+			o.U64 = v
+		case "i32":
+			if err := json.Unmarshal(raw, &o.I32); err != nil {
+				return fmt.Errorf("colfer: gen.o.i32: %s", err)
+			}
+		case "i64":
+			v, err := colferJSONInt64(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.i64: %s", err)
+			}
+			o.I64 = v
+		case "f32":
+			v, err := colferJSONFloat(raw, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.f32: %s", err)
+			}
+			o.F32 = float32(v)
+		case "f64":
+			v, err := colferJSONFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.f64: %s", err)
+			}
+			o.F64 = v
+		case "t":
+			v, err := colferJSONTime(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.t: %s", err)
+			}
+			o.T = v
+		case "s":
+			if err := json.Unmarshal(raw, &o.S); err != nil {
+				return fmt.Errorf("colfer: gen.o.s: %s", err)
+			}
+			if l := len(o.S); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.s size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "a":
+			v, err := colferJSONBinary(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.a: %s", err)
+			}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.a size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+			o.A = v
+		case "o":
+			if string(raw) != "null" {
+				o.O = new(O)
+				if err := o.O.UnmarshalJSON(raw); err != nil {
+					return err
+				}
+			}
+		case "os":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.os: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.os length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Os = make([]*O, len(a))
+			for ai, x := range a {
+				o.Os[ai] = new(O)
+				if err := o.Os[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+			}
+		case "ss":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.ss: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.ss length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Ss = make([]string, len(a))
+			for ai, x := range a {
+				if err := json.Unmarshal(x, &o.Ss[ai]); err != nil {
+					return fmt.Errorf("colfer: gen.o.ss element %d: %s", ai, err)
+				}
+				if l := len(o.Ss[ai]); l > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: gen.o.ss element %d size %d exceeds %d bytes", ai, l, ColferSizeMax))
+				}
+			}
+		case "as":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.as: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.as length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.As = make([][]byte, len(a))
+			for ai, x := range a {
+				v, err := colferJSONBinary(x)
+				if err != nil {
+					return fmt.Errorf("colfer: gen.o.as element %d: %s", ai, err)
+				}
+				if len(v) > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: gen.o.as element %d size %d exceeds %d bytes", ai, len(v), ColferSizeMax))
+				}
+				o.As[ai] = v
+			}
+		case "u8":
+			if err := json.Unmarshal(raw, &o.U8); err != nil {
+				return fmt.Errorf("colfer: gen.o.u8: %s", err)
+			}
+		case "u16":
+			if err := json.Unmarshal(raw, &o.U16); err != nil {
+				return fmt.Errorf("colfer: gen.o.u16: %s", err)
+			}
+		case "f32s":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.f32s: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.f32s length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.F32s = make([]float32, len(a))
+			for ai, x := range a {
+				v, err := colferJSONFloat(x, 32)
+				if err != nil {
+					return fmt.Errorf("colfer: gen.o.f32s element %d: %s", ai, err)
+				}
+				o.F32s[ai] = float32(v)
+			}
+		case "f64s":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.f64s: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.f64s length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.F64s = make([]float64, len(a))
+			for ai, x := range a {
+				v, err := colferJSONFloat(x, 64)
+				if err != nil {
+					return fmt.Errorf("colfer: gen.o.f64s element %d: %s", ai, err)
+				}
+				o.F64s[ai] = v
+			}
+		default:
+			return fmt.Errorf("colfer: struct gen.o has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists and binaries keep their capacity for reuse.
+func (o *O) Reset() {
+	*o = O{
+		A:    o.A[:0],
+		Os:   o.Os[:0],
+		Ss:   o.Ss[:0],
+		As:   o.As[:0],
+		F32s: o.F32s[:0],
+		F64s: o.F64s[:0],
+	}
+}
@@ -3,6 +3,7 @@ package testdata
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +15,9 @@ import (
 	"github.com/pascaldekloe/goe/verify"
 
 	"github.com/pascaldekloe/colfer/go/gen"
+	reuse "github.com/pascaldekloe/colfer/go/reuse/gen"
+	values "github.com/pascaldekloe/colfer/go/values/gen"
+	zero "github.com/pascaldekloe/colfer/go/zero/gen"
 )
 
 type golden struct {
@@ -85,13 +89,108 @@ func newGoldenCases() []*golden {
 }
 
 func TestMarshal(t *testing.T) {
-	for _, gold := range newGoldenCases() {
+	golden := newGoldenCases()
+	serials := make([][]byte, len(golden))
+	for i, gold := range golden {
 		data, err := gold.object.MarshalBinary()
 		if err != nil {
 			t.Errorf("0x%s: %s", gold.serial, err)
 			continue
 		}
-		if got := hex.EncodeToString(data); got != gold.serial {
+		serials[i] = data
+	}
+
+	// verify afterwards, as the encoding space is reused
+	for i, gold := range golden {
+		if got := hex.EncodeToString(serials[i]); serials[i] != nil && got != gold.serial {
+			t.Errorf("Got 0x%s, want 0x%s", got, gold.serial)
+		}
+	}
+}
+
+func TestMarshalAppend(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		prefix := []byte{0xca, 0xfe}
+		data, err := gold.object.MarshalAppend(prefix)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(data); got != "cafe"+gold.serial {
+			t.Errorf("Got 0x%s, want 0xcafe%s", got, gold.serial)
+		}
+
+		// reuse of capacity
+		buf := make([]byte, 0, len(data))
+		reused, err := gold.object.MarshalAppend(buf)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if &reused[0] != &buf[:1][0] {
+			t.Errorf("0x%s: allocated despite sufficient capacity", gold.serial)
+		}
+	}
+}
+
+func TestMarshalAppendMax(t *testing.T) {
+	origSize, origList := gen.ColferSizeMax, gen.ColferListMax
+	defer func() {
+		gen.ColferSizeMax, gen.ColferListMax = origSize, origList
+	}()
+	gen.ColferSizeMax, gen.ColferListMax = 16, 2
+
+	for _, o := range []*gen.O{
+		{S: strings.Repeat("X", 17)},
+		{As: [][]byte{make([]byte, 10), make([]byte, 10)}},
+		{Ss: []string{"a", "b", "c"}},
+		{F32s: make([]float32, 3)},
+		{Os: []*gen.O{{}, {}, {}}},
+		{O: &gen.O{S: strings.Repeat("X", 14)}},
+		{O: &gen.O{Os: []*gen.O{{S: "deep"}, {S: strings.Repeat("X", 14)}}}},
+	} {
+		if _, err := o.MarshalLen(); err == nil {
+			t.Errorf("%+v: no MarshalLen error", o)
+		}
+
+		dst := []byte{0xca, 0xfe}
+		got, err := o.MarshalAppend(dst)
+		if _, ok := err.(gen.ColferMax); !ok {
+			t.Errorf("%+v: got MarshalAppend error %T, want gen.ColferMax", o, err)
+		}
+		if len(got) != len(dst) {
+			t.Errorf("%+v: got %d bytes on error, want dst as is", o, len(got))
+		}
+
+		if data, err := o.MarshalBinary(); data != nil || err == nil {
+			t.Errorf("%+v: got MarshalBinary %#x, %v, want nil and error", o, data, err)
+		}
+		if n, err := o.MarshalInto(make([]byte, 64)); n != 0 || err == nil {
+			t.Errorf("%+v: got MarshalInto %d, %v, want 0 and error", o, n, err)
+		}
+	}
+}
+
+func TestMarshalInto(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		size := len(gold.serial) / 2
+		for l := 0; l < size; l++ {
+			n, err := gold.object.MarshalInto(make([]byte, l))
+			if err != io.ErrShortBuffer {
+				t.Errorf("0x%s: got error %v for buffer size %d, want io.ErrShortBuffer", gold.serial, err, l)
+			}
+			if n != 0 {
+				t.Errorf("0x%s: got %d bytes written for buffer size %d", gold.serial, n, l)
+			}
+		}
+
+		buf := make([]byte, size+1)
+		n, err := gold.object.MarshalInto(buf)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(buf[:n]); got != gold.serial {
 			t.Errorf("Got 0x%s, want 0x%s", got, gold.serial)
 		}
 	}
@@ -168,6 +267,358 @@ func TestUnmarshalSizeMax(t *testing.T) {
 	}
 }
 
+func TestZeroCopyUnmarshal(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o zero.O
+		if n, err := o.Unmarshal(data); err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		} else if n != len(data) {
+			t.Errorf("0x%s: read %d bytes, want %d", gold.serial, n, len(data))
+		}
+		got, err := o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if hex.EncodeToString(got) != gold.serial {
+			t.Errorf("0x%s: marshal after unmarshal got 0x%x", gold.serial, got)
+		}
+	}
+}
+
+func TestZeroCopyAlias(t *testing.T) {
+	// text "A" and binary 0xff
+	data := []byte{0x08, 0x01, 'A', 0x09, 0x01, 0xff, 0x7f}
+
+	var o zero.O
+	if _, err := o.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	data[2] = 'B'
+	data[5] = 0xee
+	if o.S != "B" {
+		t.Errorf("got text %q after modification of the serial, want shared memory", o.S)
+	}
+	if !bytes.Equal(o.A, []byte{0xee}) {
+		t.Errorf("got binary %#x after modification of the serial, want shared memory", o.A)
+	}
+
+	o.A = append(o.A, 0)
+	if data[6] != 0x7f {
+		t.Errorf("append to binary overwrote serial byte with %#x", data[6])
+	}
+
+	var copied zero.O
+	if err := copied.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	data[2] = 'C'
+	if copied.S != "B" {
+		t.Errorf("got text %q after UnmarshalBinary and modification of the serial, want a copy", copied.S)
+	}
+}
+
+func TestReset(t *testing.T) {
+	o := gen.O{
+		B:    true,
+		S:    "x",
+		A:    []byte{1, 2},
+		O:    new(gen.O),
+		Os:   []*gen.O{new(gen.O)},
+		Ss:   []string{"a", "b"},
+		As:   [][]byte{{1}},
+		F32s: []float32{1},
+		F64s: []float64{1, 2, 3},
+	}
+	o.Reset()
+
+	if l, err := o.MarshalLen(); err != nil || l != 1 {
+		t.Errorf("got MarshalLen %d, %v after Reset, want 1, <nil>", l, err)
+	}
+	if o.O != nil {
+		t.Error("nested data structure not cleared")
+	}
+	if cap(o.A) != 2 || cap(o.Os) != 1 || cap(o.Ss) != 2 || cap(o.As) != 1 || cap(o.F32s) != 1 || cap(o.F64s) != 3 {
+		t.Error("capacity lost")
+	}
+}
+
+func TestReuseUnmarshal(t *testing.T) {
+	// the same instance for all cases
+	var o reuse.O
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := o.Unmarshal(data); err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		} else if n != len(data) {
+			t.Errorf("0x%s: read %d bytes, want %d", gold.serial, n, len(data))
+		}
+		got, err := o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if hex.EncodeToString(got) != gold.serial {
+			t.Errorf("0x%s: marshal after unmarshal got 0x%x", gold.serial, got)
+		}
+	}
+}
+
+func TestReuseMemory(t *testing.T) {
+	data, err := (&reuse.O{
+		A:    []byte{1, 2, 3},
+		O:    &reuse.O{S: "nested"},
+		Os:   []*reuse.O{{U32: 1}, {U32: 2}},
+		Ss:   []string{"a", "b"},
+		As:   [][]byte{{4, 5}},
+		F64s: []float64{1, 2},
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o reuse.O
+	if _, err := o.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	a, nested, list, ss, as, as0, f64s := &o.A[0], o.O, &o.Os[0], &o.Ss[0], &o.As[0], &o.As[0][0], &o.F64s[0]
+	elem := o.Os[1]
+
+	if _, err := o.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if &o.A[0] != a {
+		t.Error("binary reallocated")
+	}
+	if o.O != nested {
+		t.Error("nested data structure reallocated")
+	}
+	if &o.Os[0] != list || o.Os[1] != elem {
+		t.Error("data structure list reallocated")
+	}
+	if &o.Ss[0] != ss {
+		t.Error("text list reallocated")
+	}
+	if &o.As[0] != as || &o.As[0][0] != as0 {
+		t.Error("binary list reallocated")
+	}
+	if &o.F64s[0] != f64s {
+		t.Error("floating point list reallocated")
+	}
+	if o.O.S != "nested" || o.Os[1].U32 != 2 || o.Ss[1] != "b" || o.As[0][1] != 5 {
+		t.Errorf("got %+v", o)
+	}
+
+	// less content clears the previous
+	if _, err := o.Unmarshal([]byte{0x00, 0x7f}); err != nil {
+		t.Fatal(err)
+	}
+	if l, err := o.MarshalLen(); err != nil || l != 2 {
+		t.Errorf("got MarshalLen %d, %v after boolean only, want 2, <nil>", l, err)
+	}
+}
+
+func TestReusePool(t *testing.T) {
+	o := reuse.GetO()
+	if _, err := o.Unmarshal([]byte{0x08, 0x01, 'A', 0x7f}); err != nil {
+		t.Fatal(err)
+	}
+	if o.S != "A" {
+		t.Errorf("got text %q, want %q", o.S, "A")
+	}
+	reuse.PutO(o)
+}
+
+func TestValueTypes(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o values.O
+		if n, err := o.Unmarshal(data); err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		} else if n != len(data) {
+			t.Errorf("0x%s: read %d bytes, want %d", gold.serial, n, len(data))
+		}
+		got, err := o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if hex.EncodeToString(got) != gold.serial {
+			t.Errorf("0x%s: marshal after unmarshal got 0x%x", gold.serial, got)
+		}
+		got, err = o.MarshalAppend(nil)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if hex.EncodeToString(got) != gold.serial {
+			t.Errorf("0x%s: append after unmarshal got 0x%x", gold.serial, got)
+		}
+	}
+}
+
+// jsonGolden is a canonical JSON mapping from testdata/json.txt.
+type jsonGolden struct {
+	serial string
+	json   string
+	back   bool // whether the JSON maps back into the serial
+}
+
+// newJSONGoldenCases returns the canonical JSON per serial, which must be
+// identical in all languages.
+func newJSONGoldenCases(t *testing.T) []jsonGolden {
+	text, err := ioutil.ReadFile("../testdata/json.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases []jsonGolden
+	for _, line := range strings.Split(string(text), "\n") {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[1] != "<->" && fields[1] != "->" {
+			t.Fatalf("malformed JSON golden %q", line)
+		}
+		cases = append(cases, jsonGolden{fields[0], fields[2], fields[1] == "<->"})
+	}
+	return cases
+}
+
+func TestMarshalJSON(t *testing.T) {
+	for _, gold := range newJSONGoldenCases(t) {
+		serial, want := gold.serial, gold.json
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o gen.O
+		if err := o.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: %s", serial, err)
+			continue
+		}
+		if got := string(o.AppendJSON(nil)); got != want {
+			t.Errorf("0x%s: got JSON %s\nwant %s", serial, got, want)
+		}
+
+		var v values.O
+		if err := v.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: %s", serial, err)
+			continue
+		}
+		if got := string(v.AppendJSON(nil)); got != want {
+			t.Errorf("0x%s: got value types JSON %s\nwant %s", serial, got, want)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	for _, gold := range newJSONGoldenCases(t) {
+		if !gold.back {
+			continue
+		}
+		serial, text := gold.serial, gold.json
+		var o gen.O
+		if err := json.Unmarshal([]byte(text), &o); err != nil {
+			t.Errorf("0x%s: %s", serial, err)
+			continue
+		}
+		data, err := o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: %s", serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(data); got != serial {
+			t.Errorf("0x%s: marshal after JSON got 0x%s", serial, got)
+		}
+
+		var v values.O
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			t.Errorf("0x%s: %s", serial, err)
+			continue
+		}
+		data, err = v.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: %s", serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(data); got != serial {
+			t.Errorf("0x%s: value types marshal after JSON got 0x%s", serial, got)
+		}
+	}
+}
+
+func TestJSONEdges(t *testing.T) {
+	var zero string
+	for _, gold := range newJSONGoldenCases(t) {
+		if gold.serial == "7f" {
+			zero = gold.json
+		}
+	}
+	o := gen.O{F32: float32(math.Copysign(0, -1)), S: "\xffA\xe2\x80"}
+	want := strings.Replace(zero, `"s":""`, "\"s\":\"\uFFFDA\uFFFD\uFFFD\"", 1)
+	if got := string(o.AppendJSON(nil)); got != want {
+		t.Errorf("got JSON %s\nwant %s", got, want)
+	}
+
+	var got gen.O
+	err := got.UnmarshalJSON([]byte(`{"u64":9007199254740991,"i64":-9007199254740991,"a":"AQI","os":[null],"t":"2015-09-08T21:04:10.5+02:00"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "parse", &got, &gen.O{
+		U64: 1<<53 - 1,
+		I64: -(1<<53 - 1),
+		A:   []byte{1, 2},
+		Os:  []*gen.O{{}},
+		T:   time.Unix(1441739050, 5e8).In(time.UTC),
+	})
+
+	for _, text := range []string{
+		`{"u64":9007199254740992}`,
+		`{"i64":-9007199254740992}`,
+		`{"u8":256}`,
+		`{"u32":1.5}`,
+		`{"f32":1e39}`,
+		`{"f64":"nan"}`,
+		`{"t":"2015-09-08"}`,
+		`{"a":"@"}`,
+		`{"x":null}`,
+		`{"o":{"x":null}}`,
+		`[]`,
+	} {
+		if err := new(gen.O).UnmarshalJSON([]byte(text)); err == nil {
+			t.Errorf("%s: no error", text)
+		}
+	}
+
+	origList := gen.ColferListMax
+	defer func() { gen.ColferListMax = origList }()
+	gen.ColferListMax = 1
+	err = new(gen.O).UnmarshalJSON([]byte(`{"ss":["a","b"]}`))
+	if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error %v, want a ColferMax", err)
+	}
+}
+
 // TestFuzzSeed updates the initial input corpus for fuzz testing.
 func TestFuzzSeed(t *testing.T) {
 	for _, gold := range newGoldenCases() {
@@ -0,0 +1,2028 @@
+// Package gen tests all field mapping options.
+package gen
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file test.colf.
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var intconv = binary.BigEndian
+
+// colferBuffers recycles the encoding space of MarshalBinary.
+var colferBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// Colfer configuration attributes
+var (
+	// ColferSizeMax is the upper limit for serial byte sizes.
+	ColferSizeMax = 16 * 1024 * 1024
+	// ColferListMax is the upper limit for the number of elements in a list.
+	ColferListMax = 64 * 1024
+)
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// colferAppendJSONString appends s as a JSON string, with the escapes of
+// JSON.stringify from ECMAScript. Malformed UTF-8 is replaced with U+FFFD.
+func colferAppendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\uFFFD"...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < ' ' {
+				buf = append(buf, '\\', 'u', '0', '0', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+
+// colferAppendJSONFloat appends the shortest representation which parses back
+// into the same value with bitSize, in the number notation of ECMAScript.
+func colferAppendJSONFloat(buf []byte, x float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(x):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(x, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(x, -1):
+		return append(buf, "\"-Infinity\""...)
+	case x == 0:
+		return append(buf, '0') // including negative zero
+	}
+
+	// compare with the precision of bitSize
+	abs := math.Abs(x)
+	format := byte('f')
+	if bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) || bitSize == 64 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, x, format, -1, bitSize)
+	if format == 'e' {
+		// exponent without leading zero, like e-7 instead of e-07
+		if n := len(buf); buf[n-4] == 'e' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// colferJSONFloat parses a JSON number, or the string "NaN", "Infinity" or
+// "-Infinity", with bitSize. Null reads as zero.
+func colferJSONFloat(data []byte, bitSize int) (float64, error) {
+	switch string(data) {
+	case "null":
+		return 0, nil
+	case "\"NaN\"":
+		return math.NaN(), nil
+	case "\"Infinity\"":
+		return math.Inf(1), nil
+	case "\"-Infinity\"":
+		return math.Inf(-1), nil
+	}
+	x, err := strconv.ParseFloat(string(data), bitSize)
+	if err != nil {
+		if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("number %s out of range", data)
+		}
+		return 0, fmt.Errorf("got %s, want number, \"NaN\", \"Infinity\" or \"-Infinity\"", data)
+	}
+	return x, nil
+}
+
+// colferJSONUint64 parses a JSON string with the decimal value, or a number up
+// to Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have lost
+// precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONUint64(data []byte) (uint64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(s, 10, 64)
+	}
+	x, err := strconv.ParseUint(string(data), 10, 64)
+	if err == nil && x > 1<<53-1 {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
+// colferJSONInt64 parses a JSON string with the decimal value, or a number
+// within ±Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have
+// lost precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONInt64(data []byte) (int64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+	x, err := strconv.ParseInt(string(data), 10, 64)
+	if err == nil && (x > 1<<53-1 || x < -(1<<53-1)) {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
+// colferJSONTime parses a JSON string in RFC 3339 format, including the years
+// beyond 0000–9999, as formatted by package time. Null reads as zero.
+func colferJSONTime(data []byte) (time.Time, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil {
+		return time.Time{}, err
+	}
+
+	i := strings.IndexByte(*s, '-')
+	if i == 0 {
+		i = strings.IndexByte((*s)[1:], '-') + 1
+	}
+	if i <= 0 || i == 4 {
+		t, err := time.Parse(time.RFC3339Nano, *s)
+		return t.UTC(), err
+	}
+
+	year, err := strconv.Atoi((*s)[:i])
+	if err != nil || (year >= 0 && year <= 9999) {
+		return time.Time{}, fmt.Errorf("malformed year %q", (*s)[:i])
+	}
+	// The Gregorian calendar repeats itself every 400 years.
+	base := 2000 + year%400
+	if year < 0 {
+		base += 400
+	}
+	t, err := time.Parse(time.RFC3339Nano, strconv.Itoa(base)+(*s)[i:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC().AddDate(year-base, 0, 0), nil
+}
+
+// colferAppendJSONBinary appends b as a JSON string with the standard base64
+// encoding, including padding.
+func colferAppendJSONBinary(buf, b []byte) []byte {
+	n := base64.StdEncoding.EncodedLen(len(b))
+	offset := len(buf)
+	if cap(buf)-offset < n+2 {
+		grown := make([]byte, offset, 2*cap(buf)+n+2)
+		copy(grown, buf)
+		buf = grown
+	}
+	buf = buf[:offset+n+2]
+	buf[offset] = '"'
+	base64.StdEncoding.Encode(buf[offset+1:], b)
+	buf[offset+n+1] = '"'
+	return buf
+}
+
+// colferJSONBinary parses a JSON string with the standard base64 encoding,
+// with or without padding. Null and the empty string read as nil.
+func colferJSONBinary(data []byte) ([]byte, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil || *s == "" {
+		return nil, err
+	}
+	enc := base64.StdEncoding
+	if len(*s)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	return enc.DecodeString(*s)
+}
+
+// O contains all supported data types.
+type O struct {
+	// B tests booleans.
+	B bool
+	// U32 tests unsigned 32-bit integers.
+	U32 uint32
+	// U64 tests unsigned 64-bit integers.
+	U64 uint64
+	// I32 tests signed 32-bit integers.
+	I32 int32
+	// I64 tests signed 64-bit integers.
+	I64 int64
+	// F32 tests 32-bit floating points.
+	F32 float32
+	// F64 tests 64-bit floating points.
+	F64 float64
+	// T tests timestamps.
+	T time.Time
+	// S tests text.
+	S string
+	// A tests binaries.
+	A []byte
+	// O tests nested data structures.
+	O *O
+	// Os tests data structure lists.
+	Os []O
+	// Ss tests text lists.
+	Ss []string
+	// As tests binary lists.
+	As [][]byte
+	// U8 tests unsigned 8-bit integers.
+	U8 uint8
+	// U16 tests unsigned 16-bit integers.
+	U16 uint16
+	// F32s tests 32-bit floating point lists.
+	F32s []float32
+	// F64s tests 64-bit floating point lists.
+	F64s []float64
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+func (o *O) MarshalTo(buf []byte) int {
+	var i int
+
+	if o.B {
+		buf[i] = 0
+		i++
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		buf[i] = 1 | 0x80
+		intconv.PutUint32(buf[i+1:], x)
+		i += 5
+	} else if x != 0 {
+		buf[i] = 1
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		buf[i] = 2 | 0x80
+		intconv.PutUint64(buf[i+1:], x)
+		i += 9
+	} else if x != 0 {
+		buf[i] = 2
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf[i] = 3
+		} else {
+			x = ^x + 1
+			buf[i] = 3 | 0x80
+		}
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf[i] = 4
+		} else {
+			x = ^x + 1
+			buf[i] = 4 | 0x80
+		}
+		i++
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.F32; v != 0 {
+		buf[i] = 5
+		intconv.PutUint32(buf[i+1:], math.Float32bits(v))
+		i += 5
+	}
+
+	if v := o.F64; v != 0 {
+		buf[i] = 6
+		intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+		i += 9
+	}
+
+	if v := o.T; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf[i] = 7
+			intconv.PutUint32(buf[i+1:], uint32(s))
+			i += 5
+		} else {
+			buf[i] = 7 | 0x80
+			intconv.PutUint64(buf[i+1:], s)
+			i += 9
+		}
+		intconv.PutUint32(buf[i:], ns)
+		i += 4
+	}
+
+	if l := len(o.S); l != 0 {
+		buf[i] = 8
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.S)
+	}
+
+	if l := len(o.A); l != 0 {
+		buf[i] = 9
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.A)
+	}
+
+	if v := o.O; v != nil {
+		buf[i] = 10
+		i++
+		i += v.MarshalTo(buf[i:])
+	}
+
+	if l := len(o.Os); l != 0 {
+		buf[i] = 11
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi := range o.Os {
+			i += o.Os[vi].MarshalTo(buf[i:])
+		}
+	}
+
+	if l := len(o.Ss); l != 0 {
+		buf[i] = 12
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Ss {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		buf[i] = 13
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.As {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf[i] = 14
+		i++
+		buf[i] = x
+		i++
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf[i] = 15
+		i++
+		buf[i] = byte(x >> 8)
+		i++
+		buf[i] = byte(x)
+		i++
+	} else if x != 0 {
+		buf[i] = 15 | 0x80
+		i++
+		buf[i] = byte(x)
+		i++
+	}
+
+	if l := len(o.F32s); l != 0 {
+		buf[i] = 16
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.F32s {
+			intconv.PutUint32(buf[i:], math.Float32bits(v))
+			i += 4
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		buf[i] = 17
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.F64s {
+			intconv.PutUint64(buf[i:], math.Float64bits(v))
+			i += 8
+		}
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is gen.ColferMax.
+func (o *O) MarshalLen() (int, error) {
+	l := 1
+
+	if o.B {
+		l++
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		l += 5
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		l += 9
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.I64; v != 0 {
+		l += 2
+		x := uint64(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			x >>= 7
+			l++
+		}
+	}
+
+	if o.F32 != 0 {
+		l += 5
+	}
+
+	if o.F64 != 0 {
+		l += 9
+	}
+
+	if v := o.T; !v.IsZero() {
+		if s := uint64(v.Unix()); s < 1<<32 {
+			l += 9
+		} else {
+			l += 13
+		}
+	}
+
+	if x := len(o.S); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.A); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.O; v != nil {
+		vl, err := v.MarshalLen()
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	}
+
+	if x := len(o.Os); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for vi := range o.Os {
+			vl, err := o.Os[vi].MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.Ss); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.Ss {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.As); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.As {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		l += 2
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		l += 3
+	} else if x != 0 {
+		l += 2
+	}
+
+	if x := len(o.F32s); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", ColferListMax))
+		}
+		for l += 2 + x*4; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.F64s); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", ColferListMax))
+		}
+		for l += 2 + x*8; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is gen.ColferMax.
+func (o *O) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// The error return option is gen.ColferMax, in which case dst is returned as is.
+func (o *O) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if o.B {
+		buf = append(buf, 0)
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		buf = append(buf, 1|0x80, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 1)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		buf = append(buf, 2|0x80, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 2)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf = append(buf, 3)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 3|0x80)
+		}
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf = append(buf, 4)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 4|0x80)
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.F32; v != 0 {
+		x := math.Float32bits(v)
+		buf = append(buf, 5, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+
+	if v := o.F64; v != 0 {
+		x := math.Float64bits(v)
+		buf = append(buf, 6, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+
+	if v := o.T; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf = append(buf, 7, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+		} else {
+			buf = append(buf, 7|0x80, byte(s>>56), byte(s>>48), byte(s>>40), byte(s>>32), byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+		}
+		buf = append(buf, byte(ns>>24), byte(ns>>16), byte(ns>>8), byte(ns))
+	}
+
+	if l := len(o.S); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 8)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.S...)
+	}
+
+	if l := len(o.A); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 9)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.A...)
+	}
+
+	if v := o.O; v != nil {
+		buf = append(buf, 10)
+		var err error
+		if buf, err = v.MarshalAppend(buf); err != nil {
+			return dst, err
+		}
+	}
+
+	if l := len(o.Os); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 11)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for vi := range o.Os {
+			v := &o.Os[vi]
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.Ss); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 12)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Ss {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 13)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.As {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf = append(buf, 14, x)
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf = append(buf, 15, byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 15|0x80, byte(x))
+	}
+
+	if l := len(o.F32s); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 16)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F32s {
+			x := math.Float32bits(v)
+			buf = append(buf, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 17)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F64s {
+			x := math.Float64bits(v)
+			buf = append(buf, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// The error return options are io.ErrShortBuffer and gen.ColferMax, in which case the content of buf is undefined.
+func (o *O) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *O) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.S = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		v := make([]byte, int(x))
+
+		start := i
+		i += len(v)
+		if i >= len(data) {
+			goto eof
+		}
+		copy(v, data[start:i])
+		o.A = v
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		o.O = new(O)
+		n, err := o.O.Unmarshal(data[i:])
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+		a := make([]O, l)
+		for ai := range a {
+			v := &a[ai]
+
+			n, err := v.Unmarshal(data[i:])
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Os = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		a := make([]string, int(x))
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		a := make([][]byte, int(x))
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+			v := make([]byte, int(x))
+
+			start := i
+			i += len(v)
+			if i >= len(data) {
+				goto eof
+			}
+
+			copy(v, data[start:i])
+			a[ai] = v
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float32, l)
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float64, l)
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
+func (o *O) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+func (o *O) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"b\":"...)
+	if o.B {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+	buf = append(buf, ",\"u32\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U32), 10)
+	buf = append(buf, ",\"u64\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendUint(buf, o.U64, 10)
+	buf = append(buf, '"')
+	buf = append(buf, ",\"i32\":"...)
+	buf = strconv.AppendInt(buf, int64(o.I32), 10)
+	buf = append(buf, ",\"i64\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendInt(buf, o.I64, 10)
+	buf = append(buf, '"')
+	buf = append(buf, ",\"f32\":"...)
+	buf = colferAppendJSONFloat(buf, float64(o.F32), 32)
+	buf = append(buf, ",\"f64\":"...)
+	buf = colferAppendJSONFloat(buf, o.F64, 64)
+	buf = append(buf, ",\"t\":"...)
+	if o.T.IsZero() {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '"')
+		buf = o.T.UTC().AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"')
+	}
+	buf = append(buf, ",\"s\":"...)
+	buf = colferAppendJSONString(buf, o.S)
+	buf = append(buf, ",\"a\":"...)
+	buf = colferAppendJSONBinary(buf, o.A)
+	buf = append(buf, ",\"o\":"...)
+	if o.O == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = o.O.AppendJSON(buf)
+	}
+	buf = append(buf, ",\"os\":"...)
+	buf = append(buf, '[')
+	for i := range o.Os {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = o.Os[i].AppendJSON(buf)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"ss\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Ss {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONString(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"as\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.As {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONBinary(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"u8\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U8), 10)
+	buf = append(buf, ",\"u16\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U16), 10)
+	buf = append(buf, ",\"f32s\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.F32s {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONFloat(buf, float64(v), 32)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"f64s\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.F64s {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONFloat(buf, v, 64)
+	}
+	buf = append(buf, ']')
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *O) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include gen.ColferMax.
+func (o *O) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct gen.o: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = O{}
+	for name, raw := range doc {
+		switch name {
+		case "b":
+			if err := json.Unmarshal(raw, &o.B); err != nil {
+				return fmt.Errorf("colfer: gen.o.b: %s", err)
+			}
+		case "u32":
+			if err := json.Unmarshal(raw, &o.U32); err != nil {
+				return fmt.Errorf("colfer: gen.o.u32: %s", err)
+			}
+		case "u64":
+			v, err := colferJSONUint64(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.u64: %s", err)
+			}
+			o.U64 = v
+		case "i32":
+			if err := json.Unmarshal(raw, &o.I32); err != nil {
+				return fmt.Errorf("colfer: gen.o.i32: %s", err)
+			}
+		case "i64":
+			v, err := colferJSONInt64(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.i64: %s", err)
+			}
+			o.I64 = v
+		case "f32":
+			v, err := colferJSONFloat(raw, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.f32: %s", err)
+			}
+			o.F32 = float32(v)
+		case "f64":
+			v, err := colferJSONFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.f64: %s", err)
+			}
+			o.F64 = v
+		case "t":
+			v, err := colferJSONTime(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.t: %s", err)
+			}
+			o.T = v
+		case "s":
+			if err := json.Unmarshal(raw, &o.S); err != nil {
+				return fmt.Errorf("colfer: gen.o.s: %s", err)
+			}
+			if l := len(o.S); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.s size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "a":
+			v, err := colferJSONBinary(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: gen.o.a: %s", err)
+			}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.a size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+			o.A = v
+		case "o":
+			if string(raw) != "null" {
+				o.O = new(O)
+				if err := o.O.UnmarshalJSON(raw); err != nil {
+					return err
+				}
+			}
+		case "os":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.os: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.os length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Os = make([]O, len(a))
+			for ai, x := range a {
+				if err := o.Os[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+			}
+		case "ss":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.ss: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.ss length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Ss = make([]string, len(a))
+			for ai, x := range a {
+				if err := json.Unmarshal(x, &o.Ss[ai]); err != nil {
+					return fmt.Errorf("colfer: gen.o.ss element %d: %s", ai, err)
+				}
+				if l := len(o.Ss[ai]); l > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: gen.o.ss element %d size %d exceeds %d bytes", ai, l, ColferSizeMax))
+				}
+			}
+		case "as":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.as: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.as length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.As = make([][]byte, len(a))
+			for ai, x := range a {
+				v, err := colferJSONBinary(x)
+				if err != nil {
+					return fmt.Errorf("colfer: gen.o.as element %d: %s", ai, err)
+				}
+				if len(v) > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: gen.o.as element %d size %d exceeds %d bytes", ai, len(v), ColferSizeMax))
+				}
+				o.As[ai] = v
+			}
+		case "u8":
+			if err := json.Unmarshal(raw, &o.U8); err != nil {
+				return fmt.Errorf("colfer: gen.o.u8: %s", err)
+			}
+		case "u16":
+			if err := json.Unmarshal(raw, &o.U16); err != nil {
+				return fmt.Errorf("colfer: gen.o.u16: %s", err)
+			}
+		case "f32s":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.f32s: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.f32s length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.F32s = make([]float32, len(a))
+			for ai, x := range a {
+				v, err := colferJSONFloat(x, 32)
+				if err != nil {
+					return fmt.Errorf("colfer: gen.o.f32s element %d: %s", ai, err)
+				}
+				o.F32s[ai] = float32(v)
+			}
+		case "f64s":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: gen.o.f64s: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: gen.o.f64s length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.F64s = make([]float64, len(a))
+			for ai, x := range a {
+				v, err := colferJSONFloat(x, 64)
+				if err != nil {
+					return fmt.Errorf("colfer: gen.o.f64s element %d: %s", ai, err)
+				}
+				o.F64s[ai] = v
+			}
+		default:
+			return fmt.Errorf("colfer: struct gen.o has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists and binaries keep their capacity for reuse.
+func (o *O) Reset() {
+	*o = O{
+		A:    o.A[:0],
+		Os:   o.Os[:0],
+		Ss:   o.Ss[:0],
+		As:   o.As[:0],
+		F32s: o.F32s[:0],
+		F64s: o.F64s[:0],
+	}
+}
@@ -0,0 +1,385 @@
+// Package gotype converts Go struct types to Colfer.
+package gotype
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Issue is a construct without Colfer equivalent.
+type Issue struct {
+	Pos     token.Position
+	Message string
+}
+
+// String returns the issue in a single line, prefixed with the position.
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+// Convert loads the Go package in directory dir and returns the Colfer
+// equivalent of the named struct types. No names selects all exported struct
+// types. Struct references within the package are followed, such that the
+// schema is complete. Field names get the lower camel case. The issues report
+// each field which was omitted or mapped with loss, including collisions in
+// lower camel case and fields beyond the Colfer maximum of 127. The error is
+// for malformed input only.
+func Convert(dir string, names ...string) (schema []byte, issues []Issue, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("colf: %s", err)
+	}
+	if len(pkgs) != 1 {
+		return nil, nil, fmt.Errorf("colf: %s: found %d Go packages, want 1", dir, len(pkgs))
+	}
+	var files []*ast.File
+	for _, p := range pkgs {
+		var filenames []string
+		for filename := range p.Files {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		for _, filename := range filenames {
+			files = append(files, p.Files[filename])
+		}
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(filepath.Base(dir), fset, files, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("colf: %s", err)
+	}
+
+	c := &converter{
+		fset:   fset,
+		pkg:    pkg,
+		docs:   make(map[token.Pos]*ast.CommentGroup),
+		queued: make(map[*types.TypeName]bool),
+	}
+	c.collectDocs(files)
+
+	if len(names) == 0 {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if ok && obj.Exported() && isStruct(obj) {
+				c.enqueue(obj)
+			}
+		}
+	}
+	for _, name := range names {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, nil, fmt.Errorf("colf: %s: type %s not found", dir, name)
+		}
+		if !isStruct(obj) {
+			return nil, nil, fmt.Errorf("colf: %s: type %s is not a struct", fset.Position(obj.Pos()), name)
+		}
+		c.enqueue(obj)
+	}
+
+	for _, file := range files {
+		if file.Doc != nil {
+			for _, line := range c.docLines(file.Doc) {
+				c.buf.WriteString(line)
+				c.buf.WriteByte('\n')
+			}
+			break
+		}
+	}
+	fmt.Fprintf(&c.buf, "package %s\n", pkg.Name())
+	// the queue grows with the references
+	for i := 0; i < len(c.queue); i++ {
+		c.writeStruct(c.queue[i])
+	}
+
+	schema, err = format.Source(c.buf.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("colf: %s: malformed conversion: %s", dir, err)
+	}
+	return schema, c.issues, nil
+}
+
+func isStruct(obj *types.TypeName) bool {
+	_, ok := obj.Type().Underlying().(*types.Struct)
+	return ok
+}
+
+// converter is the output state.
+type converter struct {
+	fset *token.FileSet
+	pkg  *types.Package
+	buf  bytes.Buffer
+
+	// docs has the comments of type specifications and fields, keyed by
+	// the position of the name.
+	docs map[token.Pos]*ast.CommentGroup
+
+	queue  []*types.TypeName
+	queued map[*types.TypeName]bool
+
+	issues []Issue
+}
+
+// report registers a construct which has no Colfer equivalent.
+func (c *converter) report(pos token.Pos, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{Pos: c.fset.Position(pos), Message: fmt.Sprintf(format, args...)})
+}
+
+// enqueue includes the struct type in the output, if not done so already.
+func (c *converter) enqueue(obj *types.TypeName) {
+	if !c.queued[obj] {
+		c.queued[obj] = true
+		c.queue = append(c.queue, obj)
+	}
+}
+
+func (c *converter) collectDocs(files []*ast.File) {
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.GenDecl:
+				// a single specification documents with the declaration
+				if node.Tok == token.TYPE && len(node.Specs) == 1 && node.Doc != nil {
+					c.docs[node.Specs[0].(*ast.TypeSpec).Name.Pos()] = node.Doc
+				}
+			case *ast.TypeSpec:
+				if node.Doc != nil {
+					c.docs[node.Name.Pos()] = node.Doc
+				}
+			case *ast.Field:
+				doc := node.Doc
+				if doc == nil {
+					doc = node.Comment
+				}
+				if doc != nil {
+					for _, ident := range node.Names {
+						c.docs[ident.Pos()] = doc
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// docLines returns the comment text as Colfer line comments.
+func (c *converter) docLines(doc *ast.CommentGroup) []string {
+	if doc == nil {
+		return nil
+	}
+	text := strings.TrimSpace(doc.Text())
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace("// " + line)
+	}
+	return lines
+}
+
+// fieldMax is the number of fields a Colfer struct can have, as the header
+// byte holds a 7-bit index, with 127 for the end marker.
+const fieldMax = 127
+
+func (c *converter) writeStruct(obj *types.TypeName) {
+	c.buf.WriteByte('\n')
+	for _, line := range c.docLines(c.docs[obj.Pos()]) {
+		c.buf.WriteString(line)
+		c.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&c.buf, "type %s struct {\n", obj.Name())
+
+	// names has the Go field name per Colfer field name
+	names := make(map[string]string)
+	s := obj.Type().Underlying().(*types.Struct)
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		label := obj.Name() + "." + f.Name()
+		if f.Embedded() {
+			c.report(f.Pos(), "field %s: embedded field has no Colfer equivalent; omitted", label)
+			continue
+		}
+		if !f.Exported() {
+			continue
+		}
+
+		typ, ok := c.fieldType(label, f)
+		if !ok {
+			continue
+		}
+		name := c.fieldName(label, f)
+		if prev, ok := names[name]; ok {
+			c.report(f.Pos(), "field %s: name %q collides with field %s.%s; omitted", label, name, obj.Name(), prev)
+			continue
+		}
+		if len(names) >= fieldMax {
+			c.report(f.Pos(), "field %s: exceeds the Colfer maximum of %d fields; omitted", label, fieldMax)
+			continue
+		}
+		names[name] = f.Name()
+
+		for _, line := range c.docLines(c.docs[f.Pos()]) {
+			c.buf.WriteByte('\t')
+			c.buf.WriteString(line)
+			c.buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&c.buf, "\t%s %s\n", name, typ)
+	}
+	c.buf.WriteString("}\n")
+}
+
+// fieldName returns a valid Colfer identifier for f.
+func (c *converter) fieldName(label string, f *types.Var) string {
+	s := lowerCamel(f.Name())
+	if token.Lookup(s).IsKeyword() {
+		c.report(f.Pos(), "field %s: name %q is a reserved word; renamed to %q", label, s, s+"_")
+		s += "_"
+	}
+	return s
+}
+
+// lowerCamel returns name with the leading capitals in lower case, such that
+// "ID" becomes "id" and "HTTPServer" becomes "httpServer".
+func lowerCamel(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			if i > 1 && unicode.IsLower(r) {
+				// keep the capital of the next word
+				runes[i-1] = unicode.ToUpper(runes[i-1])
+			}
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// fieldType returns the Colfer datatype, with false for omission. The label
+// identifies f in issues.
+func (c *converter) fieldType(label string, f *types.Var) (string, bool) {
+	t := f.Type()
+	if slice, ok := t.Underlying().(*types.Slice); ok && !isBytes(t) {
+		elem, ok := c.elemType(label, f.Pos(), slice.Elem())
+		if !ok {
+			return "", false
+		}
+		switch elem {
+		case "float32", "float64", "text", "binary":
+			return "[]" + elem, true
+		case "bool", "uint8", "uint16", "uint32", "uint64", "int32", "int64", "timestamp":
+			c.report(f.Pos(), "field %s: list of %s has no Colfer equivalent; omitted", label, elem)
+			return "", false
+		default:
+			return "[]" + elem, true
+		}
+	}
+	return c.elemType(label, f.Pos(), t)
+}
+
+// elemType returns the Colfer datatype for t, with false for omission.
+func (c *converter) elemType(label string, pos token.Pos, t types.Type) (string, bool) {
+	if isBytes(t) {
+		return "binary", true
+	}
+
+	ptr, isPtr := t.(*types.Pointer)
+	if isPtr {
+		t = ptr.Elem()
+	}
+
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		switch {
+		case obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time":
+			return "timestamp", true
+		case isStruct(obj) && obj.Pkg() == c.pkg:
+			c.enqueue(obj)
+			return obj.Name(), true
+		case isStruct(obj):
+			c.report(pos, "field %s: struct %s from another package has no Colfer equivalent; omitted", label, types.TypeString(t, nil))
+			return "", false
+		}
+	}
+	if isPtr {
+		c.report(pos, "field %s: pointer to %s has no Colfer equivalent; omitted", label, types.TypeString(t, types.RelativeTo(c.pkg)))
+		return "", false
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool:
+			return "bool", true
+		case types.Uint8:
+			return "uint8", true
+		case types.Uint16:
+			return "uint16", true
+		case types.Uint32:
+			return "uint32", true
+		case types.Uint64:
+			return "uint64", true
+		case types.Int32:
+			return "int32", true
+		case types.Int64:
+			return "int64", true
+		case types.Float32:
+			return "float32", true
+		case types.Float64:
+			return "float64", true
+		case types.String:
+			return "text", true
+		case types.Int8, types.Int16:
+			c.report(pos, "field %s: %s has no Colfer equivalent; mapped to int32", label, u)
+			return "int32", true
+		case types.Int:
+			c.report(pos, "field %s: int has a platform dependent size; mapped to int64", label)
+			return "int64", true
+		case types.Uint:
+			c.report(pos, "field %s: uint has a platform dependent size; mapped to uint64", label)
+			return "uint64", true
+		}
+		c.report(pos, "field %s: %s has no Colfer equivalent; omitted", label, u)
+		return "", false
+
+	case *types.Struct:
+		c.report(pos, "field %s: anonymous struct has no Colfer equivalent; omitted", label)
+	case *types.Map:
+		c.report(pos, "field %s: map has no Colfer equivalent; omitted", label)
+	case *types.Interface:
+		c.report(pos, "field %s: interface has no Colfer equivalent; omitted", label)
+	case *types.Slice:
+		c.report(pos, "field %s: nested list has no Colfer equivalent; omitted", label)
+	case *types.Array:
+		c.report(pos, "field %s: array has no Colfer equivalent; omitted", label)
+	default:
+		c.report(pos, "field %s: %s has no Colfer equivalent; omitted", label, types.TypeString(t, types.RelativeTo(c.pkg)))
+	}
+	return "", false
+}
+
+// isBytes returns whether t is a byte slice.
+func isBytes(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	basic, ok := slice.Elem().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Uint8
+}
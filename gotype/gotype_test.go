@@ -0,0 +1,110 @@
+package gotype
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	schema, issues, err := Convert("testdata/model", "Order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantIssues := []string{
+		"testdata/model/model.go:16:2: field Order.Count: int has a platform dependent size; mapped to int64",
+		"testdata/model/model.go:17:2: field Order.Labels: map has no Colfer equivalent; omitted",
+		"testdata/model/model.go:18:2: field Order.Extra: interface has no Colfer equivalent; omitted",
+		"testdata/model/model.go:19:2: field Order.Type: name \"type\" is a reserved word; renamed to \"type_\"",
+		"testdata/model/model.go:20:2: field Order.Flags: list of bool has no Colfer equivalent; omitted",
+		"testdata/model/model.go:22:7: field Order.Mutex: embedded field has no Colfer equivalent; omitted",
+	}
+	if len(issues) != len(wantIssues) {
+		t.Errorf("got %d issues %q, want %d", len(issues), issues, len(wantIssues))
+	} else {
+		for i, issue := range issues {
+			if got := issue.String(); got != wantIssues[i] {
+				t.Errorf("issue %d: got %q, want %q", i, got, wantIssues[i])
+			}
+		}
+	}
+
+	const want = `// Package model has conversion samples.
+package model
+
+// Order is a purchase.
+type Order struct {
+	// ID is the identifier.
+	id     uint64
+	placed timestamp
+	lines  []Line
+	notes  []text
+	count  int64
+	// category
+	type_ text
+}
+
+// Line is an order entry.
+type Line struct {
+	sku    text
+	amount float64
+	image  binary
+	parent Order
+}
+`
+	if got := string(schema); got != want {
+		t.Errorf("got schema:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertLimits(t *testing.T) {
+	schema, issues, err := Convert("testdata/limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantIssues := []string{
+		"testdata/limits/limits.go:7:2: field Dupe.Id: name \"id\" collides with field Dupe.ID; omitted",
+		"testdata/limits/limits.go:9:2: field Dupe.Url: name \"url\" collides with field Dupe.URL; omitted",
+		"testdata/limits/limits.go:141:2: field Wide.F127: exceeds the Colfer maximum of 127 fields; omitted",
+		"testdata/limits/limits.go:142:2: field Wide.F128: exceeds the Colfer maximum of 127 fields; omitted",
+	}
+	if len(issues) != len(wantIssues) {
+		t.Errorf("got %d issues %q, want %d", len(issues), issues, len(wantIssues))
+	} else {
+		for i, issue := range issues {
+			if got := issue.String(); got != wantIssues[i] {
+				t.Errorf("issue %d: got %q, want %q", i, got, wantIssues[i])
+			}
+		}
+	}
+
+	// gofmt aligns the types
+	var wide []string
+	for i := 0; i < 127; i++ {
+		wide = append(wide, fmt.Sprintf("\t%-4s int32\n", fmt.Sprint("f", i)))
+	}
+	want := `// Package limits exceeds the Colfer constraints.
+package limits
+
+// Dupe has names which collide in lower camel case.
+type Dupe struct {
+	id  uint64
+	url text
+}
+
+// Wide has more fields than Colfer supports.
+type Wide struct {
+` + strings.Join(wide, "") + "}\n"
+	if got := string(schema); got != want {
+		t.Errorf("got schema:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertNotFound(t *testing.T) {
+	_, _, err := Convert("testdata/model", "Missing")
+	if err == nil {
+		t.Fatal("no error for missing type")
+	}
+}
@@ -0,0 +1,143 @@
+// Package limits exceeds the Colfer constraints.
+package limits
+
+// Dupe has names which collide in lower camel case.
+type Dupe struct {
+	ID  uint64
+	Id  string
+	URL string
+	Url string
+}
+
+// Wide has more fields than Colfer supports.
+type Wide struct {
+	F0   int32
+	F1   int32
+	F2   int32
+	F3   int32
+	F4   int32
+	F5   int32
+	F6   int32
+	F7   int32
+	F8   int32
+	F9   int32
+	F10  int32
+	F11  int32
+	F12  int32
+	F13  int32
+	F14  int32
+	F15  int32
+	F16  int32
+	F17  int32
+	F18  int32
+	F19  int32
+	F20  int32
+	F21  int32
+	F22  int32
+	F23  int32
+	F24  int32
+	F25  int32
+	F26  int32
+	F27  int32
+	F28  int32
+	F29  int32
+	F30  int32
+	F31  int32
+	F32  int32
+	F33  int32
+	F34  int32
+	F35  int32
+	F36  int32
+	F37  int32
+	F38  int32
+	F39  int32
+	F40  int32
+	F41  int32
+	F42  int32
+	F43  int32
+	F44  int32
+	F45  int32
+	F46  int32
+	F47  int32
+	F48  int32
+	F49  int32
+	F50  int32
+	F51  int32
+	F52  int32
+	F53  int32
+	F54  int32
+	F55  int32
+	F56  int32
+	F57  int32
+	F58  int32
+	F59  int32
+	F60  int32
+	F61  int32
+	F62  int32
+	F63  int32
+	F64  int32
+	F65  int32
+	F66  int32
+	F67  int32
+	F68  int32
+	F69  int32
+	F70  int32
+	F71  int32
+	F72  int32
+	F73  int32
+	F74  int32
+	F75  int32
+	F76  int32
+	F77  int32
+	F78  int32
+	F79  int32
+	F80  int32
+	F81  int32
+	F82  int32
+	F83  int32
+	F84  int32
+	F85  int32
+	F86  int32
+	F87  int32
+	F88  int32
+	F89  int32
+	F90  int32
+	F91  int32
+	F92  int32
+	F93  int32
+	F94  int32
+	F95  int32
+	F96  int32
+	F97  int32
+	F98  int32
+	F99  int32
+	F100 int32
+	F101 int32
+	F102 int32
+	F103 int32
+	F104 int32
+	F105 int32
+	F106 int32
+	F107 int32
+	F108 int32
+	F109 int32
+	F110 int32
+	F111 int32
+	F112 int32
+	F113 int32
+	F114 int32
+	F115 int32
+	F116 int32
+	F117 int32
+	F118 int32
+	F119 int32
+	F120 int32
+	F121 int32
+	F122 int32
+	F123 int32
+	F124 int32
+	F125 int32
+	F126 int32
+	F127 int32
+	F128 int32
+}
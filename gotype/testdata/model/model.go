@@ -0,0 +1,36 @@
+// Package model has conversion samples.
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// Order is a purchase.
+type Order struct {
+	// ID is the identifier.
+	ID     uint64
+	Placed time.Time
+	Lines  []*Line
+	Notes  []string
+	Count  int
+	Labels map[string]string
+	Extra  interface{}
+	Type   string // category
+	Flags  []bool
+
+	sync.Mutex
+	secret string
+}
+
+// Line is an order entry.
+type Line struct {
+	SKU    string
+	Amount float64
+	Image  []byte
+	Parent *Order
+}
+
+type unused struct {
+	X int32
+}
@@ -0,0 +1,285 @@
+package colfer
+
+import "sort"
+
+// RefGraph is the reference graph of struct definitions.
+type RefGraph struct {
+	// Structs are the nodes in order of appearance.
+	Structs []*StructNode `json:"structs"`
+	// Packages are the nodes sorted by name.
+	Packages []*PackageNode `json:"packages"`
+	// Cycles are the groups of structs which reference each other, in
+	// order of appearance. Self references count as a cycle too.
+	Cycles [][]string `json:"cycles"`
+	// PackageCycles are the groups of packages which reference each
+	// other, sorted by name.
+	PackageCycles [][]string `json:"packageCycles"`
+}
+
+// StructNode is a struct in the reference graph.
+type StructNode struct {
+	// Struct is the qualified name.
+	Struct string `json:"struct"`
+	// Refs are the qualified names of the referenced structs, in order
+	// of appearance.
+	Refs []string `json:"refs"`
+	// Fields are the names of the referencing fields, in order of Refs.
+	Fields [][]string `json:"fields"`
+	// FanIn is the number of other structs which reference this one.
+	FanIn int `json:"fanIn"`
+	// Cyclic is set when the struct is part of a cycle.
+	Cyclic bool `json:"cyclic"`
+	// Unreachable is set when the struct can not be reached from any of
+	// the roots.
+	Unreachable bool `json:"unreachable"`
+}
+
+// PackageNode is a package in the reference graph.
+type PackageNode struct {
+	// Package is the name.
+	Package string `json:"package"`
+	// Refs are the names of the referenced packages, sorted.
+	Refs []string `json:"refs"`
+	// FanIn is the number of other packages which reference this one.
+	FanIn int `json:"fanIn"`
+	// Cyclic is set when the package is part of a cycle.
+	Cyclic bool `json:"cyclic"`
+}
+
+// Graph returns the references amongst the struct definitions. The roots are
+// the entry points for the reachability analysis. When roots is empty, then
+// all structs which are not referenced from outside of their cycle are roots,
+// i.e., the source components of the graph. Structs without any reference
+// to them count as a component on their own.
+func Graph(packages Packages, roots []*Struct) *RefGraph {
+	var structs []*Struct
+	for _, p := range packages {
+		structs = append(structs, p.Structs...)
+	}
+
+	g := &RefGraph{
+		Structs:  make([]*StructNode, 0, len(structs)),
+		Packages: make([]*PackageNode, 0, len(packages)),
+		Cycles:   make([][]string, 0),
+	}
+
+	nodes := make(map[*Struct]*StructNode, len(structs))
+	refs := make(map[*Struct][]*Struct, len(structs))
+	for _, s := range structs {
+		n := &StructNode{
+			Struct: s.String(),
+			Refs:   make([]string, 0),
+			Fields: make([][]string, 0),
+		}
+		index := make(map[*Struct]int)
+		for _, f := range s.Fields {
+			if f.TypeRef == nil {
+				continue
+			}
+			i, ok := index[f.TypeRef]
+			if !ok {
+				i = len(n.Refs)
+				index[f.TypeRef] = i
+				refs[s] = append(refs[s], f.TypeRef)
+				n.Refs = append(n.Refs, f.TypeRef.String())
+				n.Fields = append(n.Fields, nil)
+			}
+			n.Fields[i] = append(n.Fields[i], f.Name)
+		}
+		nodes[s] = n
+		g.Structs = append(g.Structs, n)
+	}
+
+	for _, s := range structs {
+		for _, r := range refs[s] {
+			if r != s {
+				nodes[r].FanIn++
+			}
+		}
+	}
+
+	order := make(map[*Struct]int, len(structs))
+	for i, s := range structs {
+		order[s] = i
+	}
+	edges := make([][]int, len(structs))
+	for i, s := range structs {
+		for _, r := range refs[s] {
+			edges[i] = append(edges[i], order[r])
+		}
+	}
+	components := strongComponents(edges)
+	for _, c := range components {
+		if len(c) == 1 && !refersTo(refs[structs[c[0]]], structs[c[0]]) {
+			continue
+		}
+		names := make([]string, len(c))
+		for i, n := range c {
+			names[i] = structs[n].String()
+			nodes[structs[n]].Cyclic = true
+		}
+		g.Cycles = append(g.Cycles, names)
+	}
+
+	if len(roots) == 0 {
+		component := make([]int, len(structs))
+		for i, c := range components {
+			for _, n := range c {
+				component[n] = i
+			}
+		}
+		referenced := make([]bool, len(components))
+		for n, out := range edges {
+			for _, r := range out {
+				if component[r] != component[n] {
+					referenced[component[r]] = true
+				}
+			}
+		}
+		for i, c := range components {
+			if !referenced[i] {
+				for _, n := range c {
+					roots = append(roots, structs[n])
+				}
+			}
+		}
+	}
+	reached := make(map[*Struct]bool)
+	for _, s := range roots {
+		reach(s, refs, reached)
+	}
+	for _, s := range structs {
+		nodes[s].Unreachable = !reached[s]
+	}
+
+	g.Packages, g.PackageCycles = packageNodes(packages)
+	return g
+}
+
+// packageNodes returns the package level of the graph.
+func packageNodes(packages Packages) ([]*PackageNode, [][]string) {
+	sorted := make(Packages, len(packages))
+	copy(sorted, packages)
+	sort.Stable(sorted)
+	index := make(map[*Package]int, len(sorted))
+	for i, p := range sorted {
+		index[p] = i
+	}
+
+	edges := make([][]int, len(sorted))
+	fanIn := make([]int, len(sorted))
+	for i, p := range sorted {
+		for _, r := range p.Refs() {
+			edges[i] = append(edges[i], index[r])
+			fanIn[index[r]]++
+		}
+	}
+
+	cyclic := make([]bool, len(sorted))
+	cycles := make([][]string, 0)
+	for _, c := range strongComponents(edges) {
+		if len(c) == 1 {
+			continue // packages do not refer to themselves
+		}
+		names := make([]string, len(c))
+		for i, n := range c {
+			names[i] = sorted[n].Name
+			cyclic[n] = true
+		}
+		cycles = append(cycles, names)
+	}
+
+	nodes := make([]*PackageNode, len(sorted))
+	for i, p := range sorted {
+		n := &PackageNode{
+			Package: p.Name,
+			Refs:    make([]string, 0, len(edges[i])),
+			FanIn:   fanIn[i],
+			Cyclic:  cyclic[i],
+		}
+		for _, r := range edges[i] {
+			n.Refs = append(n.Refs, sorted[r].Name)
+		}
+		nodes[i] = n
+	}
+	return nodes, cycles
+}
+
+// strongComponents returns the strongly connected components conform
+// Tarjan. The nodes are identified by their index in edges, which holds the
+// outgoing references per node. Components are in order of their lowest
+// index, with the nodes in ascending order.
+func strongComponents(edges [][]int) [][]int {
+	index := make([]int, len(edges))
+	low := make([]int, len(edges))
+	onStack := make([]bool, len(edges))
+	var stack []int
+	var components [][]int
+	count := 0
+
+	var visit func(n int)
+	visit = func(n int) {
+		count++
+		index[n] = count
+		low[n] = count
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, r := range edges[n] {
+			if index[r] == 0 {
+				visit(r)
+				if low[r] < low[n] {
+					low[n] = low[r]
+				}
+			} else if onStack[r] && index[r] < low[n] {
+				low[n] = index[r]
+			}
+		}
+
+		if low[n] != index[n] {
+			return
+		}
+		var c []int
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			c = append(c, top)
+			if top == n {
+				break
+			}
+		}
+		sort.Ints(c)
+		components = append(components, c)
+	}
+
+	for n := range edges {
+		if index[n] == 0 {
+			visit(n)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
+
+// reach marks s and all of its (indirect) references.
+func reach(s *Struct, refs map[*Struct][]*Struct, reached map[*Struct]bool) {
+	if reached[s] {
+		return
+	}
+	reached[s] = true
+	for _, r := range refs[s] {
+		reach(r, refs, reached)
+	}
+}
+
+func refersTo(refs []*Struct, s *Struct) bool {
+	for _, r := range refs {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package colfer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStrongComponents(t *testing.T) {
+	golden := []struct {
+		edges [][]int
+		want  [][]int
+	}{
+		{nil, nil},
+		{[][]int{nil}, [][]int{{0}}},
+		// self reference
+		{[][]int{{0}}, [][]int{{0}}},
+		// chain
+		{[][]int{{1}, {2}, nil}, [][]int{{0}, {1}, {2}}},
+		// cycle in reverse order of appearance
+		{[][]int{{2}, {0}, {1}}, [][]int{{0, 1, 2}}},
+		// two cycles with a bridge
+		{[][]int{{1}, {0, 2}, {3}, {2}}, [][]int{{0, 1}, {2, 3}}},
+		// cycle reachable from a later node only
+		{[][]int{{1}, {0}, {0}}, [][]int{{0, 1}, {2}}},
+		// nested cycles
+		{[][]int{{1}, {2, 0}, {1, 3}, {3}}, [][]int{{0, 1, 2}, {3}}},
+	}
+	for _, g := range golden {
+		got := strongComponents(g.edges)
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("%v: got components %v, want %v", g.edges, got, g.want)
+		}
+	}
+}
+
+func TestGraphBreak(t *testing.T) {
+	packages, err := ParseFiles([]string{"testdata/break.colf", "testdata/break-refs.colf"})
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+
+	got, err := json.Marshal(Graph(packages, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"structs":[` +
+		`{"struct":"void.class","refs":["void.int","static.int"],"fields":[["extends"],["public"]],"fanIn":1,"cyclic":true,"unreachable":false},` +
+		`{"struct":"void.int","refs":["void.class"],"fields":[["throw","finally"]],"fanIn":1,"cyclic":true,"unreachable":false},` +
+		`{"struct":"static.int","refs":[],"fields":[],"fanIn":1,"cyclic":false,"unreachable":false}],` +
+		`"packages":[` +
+		`{"package":"static","refs":[],"fanIn":1,"cyclic":false},` +
+		`{"package":"void","refs":["static"],"fanIn":0,"cyclic":false}],` +
+		`"cycles":[["void.class","void.int"]],"packageCycles":[]}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestGraphRoots(t *testing.T) {
+	packages, err := ParseSources([]string{"a.colf", "b.colf"}, map[string][]byte{
+		"a.colf": []byte(`package a
+
+// Root has no fan-in.
+type root struct {
+	child child
+	other b.x
+}
+
+// Child is referenced by root only.
+type child struct {
+	n int32
+}
+
+// Self refers to itself only.
+type self struct {
+	next self
+}
+
+// Ping is an isolated cycle with pong.
+type ping struct {
+	pong pong
+	leaf leaf
+}
+
+// Pong is an isolated cycle with ping.
+type pong struct {
+	ping ping
+}
+
+// Leaf is reachable through the isolated cycle only.
+type leaf struct {
+	n int32
+}
+`),
+		"b.colf": []byte(`package b
+
+// X refers back to package a.
+type x struct {
+	child a.child
+}
+`),
+	})
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+
+	golden := []struct {
+		roots []string
+		want  map[string]bool // unreachable per struct
+	}{
+		{nil, map[string]bool{
+			"a.root": false, "a.child": false, "a.self": false,
+			"a.ping": false, "a.pong": false, "a.leaf": false, "b.x": false,
+		}},
+		{[]string{"a.root"}, map[string]bool{
+			"a.root": false, "a.child": false, "a.self": true,
+			"a.ping": true, "a.pong": true, "a.leaf": true, "b.x": false,
+		}},
+		{[]string{"a.pong"}, map[string]bool{
+			"a.root": true, "a.child": true, "a.self": true,
+			"a.ping": false, "a.pong": false, "a.leaf": false, "b.x": true,
+		}},
+	}
+	for _, gold := range golden {
+		var roots []*Struct
+		for _, name := range gold.roots {
+			for _, p := range packages {
+				for _, s := range p.Structs {
+					if s.String() == name {
+						roots = append(roots, s)
+					}
+				}
+			}
+		}
+
+		g := Graph(packages, roots)
+		if len(g.Structs) != len(gold.want) {
+			t.Fatalf("roots %q: got %d structs, want %d", gold.roots, len(g.Structs), len(gold.want))
+		}
+		for _, n := range g.Structs {
+			if want := gold.want[n.Struct]; n.Unreachable != want {
+				t.Errorf("roots %q: struct %s: got unreachable %t, want %t", gold.roots, n.Struct, n.Unreachable, want)
+			}
+		}
+
+		wantCycles := [][]string{{"a.self"}, {"a.ping", "a.pong"}}
+		if !reflect.DeepEqual(g.Cycles, wantCycles) {
+			t.Errorf("roots %q: got cycles %q, want %q", gold.roots, g.Cycles, wantCycles)
+		}
+		wantPackageCycles := [][]string{{"a", "b"}}
+		if !reflect.DeepEqual(g.PackageCycles, wantPackageCycles) {
+			t.Errorf("roots %q: got package cycles %q, want %q", gold.roots, g.PackageCycles, wantPackageCycles)
+		}
+	}
+}
@@ -2,8 +2,7 @@ package colfer
 
 import (
 	"bytes"
-	"os"
-	"path/filepath"
+	"fmt"
 	"strings"
 	"text/template"
 )
@@ -20,13 +19,8 @@ func IsJavaKeyword(s string) bool {
 	return false
 }
 
-// GenerateJava writes the code into the respective ".java" files.
-func GenerateJava(basedir string, packages Packages) error {
-	packageTemplate := template.New("java-package")
-	template.Must(packageTemplate.Parse(javaPackage))
-	codeTemplate := template.New("java-code")
-	template.Must(codeTemplate.Parse(javaCode))
-
+// javaNatives sets the Java package names and datatypes.
+func javaNatives(packages Packages) {
 	for _, p := range packages {
 		var buf bytes.Buffer
 		for i, seg := range strings.Split(p.Name, "/") {
@@ -54,23 +48,6 @@ func GenerateJava(basedir string, packages Packages) error {
 	}
 
 	for _, p := range packages {
-		pkgdir := filepath.Join(basedir, strings.Replace(p.NameNative, ".", string([]rune{filepath.Separator}), -1))
-		if err := os.MkdirAll(pkgdir, os.ModeDir|os.ModePerm); err != nil {
-			return err
-		}
-
-		if doc := p.DocText(" * "); doc != "" {
-			f, err := os.Create(filepath.Join(pkgdir, "package-info.java"))
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-
-			if err := packageTemplate.Execute(f, p); err != nil {
-				return err
-			}
-		}
-
 		for _, s := range p.Structs {
 			for _, f := range s.Fields {
 				switch f.Type {
@@ -110,19 +87,61 @@ func GenerateJava(basedir string, packages Packages) error {
 					f.NameNative += "_"
 				}
 			}
+		}
+	}
+}
+
+// GenerateJava writes the code into the respective ".java" files.
+func GenerateJava(basedir string, packages Packages) error {
+	sources, err := JavaSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// JavaSources returns the code of GenerateJava in memory.
+func JavaSources(packages Packages) (Sources, error) {
+	packageTemplate := template.New("java-package")
+	template.Must(packageTemplate.Parse(javaPackage))
+	codeTemplate := template.New("java-code")
+	template.Must(codeTemplate.Parse(javaCode))
+	jsonTemplate := template.New("java-json")
+	template.Must(jsonTemplate.Parse(javaJSON))
 
-			f, err := os.Create(filepath.Join(pkgdir, s.NameTitle()+".java"))
-			if err != nil {
-				return err
+	javaNatives(packages)
+
+	sources := make(Sources)
+	for _, p := range packages {
+		pkgdir := strings.Replace(p.NameNative, ".", "/", -1)
+
+		if doc := p.DocText(" * "); doc != "" {
+			var buf bytes.Buffer
+			if err := packageTemplate.Execute(&buf, p); err != nil {
+				return nil, err
+			}
+			sources[pkgdir+"/package-info.java"] = buf.Bytes()
+		}
+
+		for _, s := range p.Structs {
+			if s.NameTitle() == "ColferJSON" {
+				return nil, fmt.Errorf("colf: struct %s collides with the JSON helper class in Java", s)
 			}
-			defer f.Close()
 
-			if err := codeTemplate.Execute(f, s); err != nil {
-				return err
+			var buf bytes.Buffer
+			if err := codeTemplate.Execute(&buf, s); err != nil {
+				return nil, err
 			}
+			sources[pkgdir+"/"+s.NameTitle()+".java"] = buf.Bytes()
+		}
+
+		var buf bytes.Buffer
+		if err := jsonTemplate.Execute(&buf, p); err != nil {
+			return nil, err
 		}
+		sources[pkgdir+"/ColferJSON.java"] = buf.Bytes()
 	}
-	return nil
+	return sources, nil
 }
 
 const javaPackage = `// Code generated by colf(1); DO NOT EDIT.
@@ -134,6 +153,440 @@ const javaPackage = `// Code generated by colf(1); DO NOT EDIT.
 package {{.NameNative}};
 `
 
+const javaJSON = `package {{.NameNative}};
+
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}}.
+
+
+import static java.lang.String.format;
+{{- if .HasText}}
+import java.nio.charset.StandardCharsets;
+{{- end}}
+import java.util.InputMismatchException;
+
+
+/**
+ * JSON mapping of the data beans in this package, in the canonical
+ * representation which is shared with the other languages.
+ * @author generated by colf(1)
+ * @see <a href="https://github.com/pascaldekloe/colfer">Colfer's home</a>
+ */
+final class ColferJSON {
+
+	private ColferJSON() {}
+{{- if .HasFloat}}
+
+	// Appends the shortest decimals which parse back into the same value.
+	static void appendJSON(StringBuilder buf, float x) {
+		if (x != x) buf.append("\"NaN\"");
+		else if (Float.isInfinite(x)) buf.append(x > 0 ? "\"Infinity\"" : "\"-Infinity\"");
+		else if (x == 0) buf.append('0');
+		else {
+			java.math.BigDecimal d = new java.math.BigDecimal(x), r;
+			for (int p = 1; ; p++) {
+				r = d.round(new java.math.MathContext(p, java.math.RoundingMode.HALF_EVEN));
+				if (p == 9 || Float.parseFloat(r.toString()) == x) break;
+
+				// The neighbour above may qualify, as the gap below
+				// a power of two is half the size of the one above.
+				if (r.abs().compareTo(d.abs()) < 0) {
+					java.math.BigDecimal up = r.add(r.ulp().multiply(java.math.BigDecimal.valueOf(r.signum())));
+					if (Float.parseFloat(up.toString()) == x) {
+						r = up;
+						break;
+					}
+				}
+			}
+			appendJSONNumber(buf, r);
+		}
+	}
+
+	// Appends the shortest decimals which parse back into the same value.
+	static void appendJSON(StringBuilder buf, double x) {
+		if (x != x) buf.append("\"NaN\"");
+		else if (Double.isInfinite(x)) buf.append(x > 0 ? "\"Infinity\"" : "\"-Infinity\"");
+		else if (x == 0) buf.append('0');
+		else {
+			java.math.BigDecimal d = new java.math.BigDecimal(x), r;
+			for (int p = 1; ; p++) {
+				r = d.round(new java.math.MathContext(p, java.math.RoundingMode.HALF_EVEN));
+				if (p == 17 || Double.parseDouble(r.toString()) == x) break;
+
+				// The neighbour above may qualify, as the gap below
+				// a power of two is half the size of the one above.
+				if (r.abs().compareTo(d.abs()) < 0) {
+					java.math.BigDecimal up = r.add(r.ulp().multiply(java.math.BigDecimal.valueOf(r.signum())));
+					if (Double.parseDouble(up.toString()) == x) {
+						r = up;
+						break;
+					}
+				}
+			}
+			appendJSONNumber(buf, r);
+		}
+	}
+
+	// Appends d in the number notation of ECMAScript.
+	static void appendJSONNumber(StringBuilder buf, java.math.BigDecimal d) {
+		d = d.stripTrailingZeros();
+		if (d.signum() < 0) {
+			buf.append('-');
+			d = d.negate();
+		}
+		String digits = d.unscaledValue().toString();
+		int k = digits.length();
+		int n = k - d.scale();
+		if (k <= n && n <= 21) {
+			buf.append(digits);
+			for (int i = k; i < n; i++) buf.append('0');
+		} else if (0 < n && n <= 21) {
+			buf.append(digits, 0, n).append('.').append(digits, n, k);
+		} else if (-6 < n && n <= 0) {
+			buf.append("0.");
+			for (int i = n; i < 0; i++) buf.append('0');
+			buf.append(digits);
+		} else {
+			buf.append(digits.charAt(0));
+			if (k > 1) buf.append('.').append(digits, 1, k);
+			buf.append(n > 0 ? "e+" : "e-").append(Math.abs(n - 1));
+		}
+	}
+
+	static double jsonFloat(Object v, String path, boolean f32) {
+		if (v == null) return 0;
+		if ("NaN".equals(v)) return Double.NaN;
+		if ("Infinity".equals(v)) return Double.POSITIVE_INFINITY;
+		if ("-Infinity".equals(v)) return Double.NEGATIVE_INFINITY;
+		if (! (v instanceof java.math.BigDecimal))
+			throw new InputMismatchException(format("colfer: %s: got %s, want number, \"NaN\", \"Infinity\" or \"-Infinity\"", path, v));
+		double x = f32 ? Float.parseFloat(v.toString()) : Double.parseDouble(v.toString());
+		if (Double.isInfinite(x))
+			throw new InputMismatchException(format("colfer: %s: number %s out of range", path, v));
+		return x;
+	}
+{{- end}}
+{{- if .HasNumber}}
+
+	static long jsonInteger(Object v, String path, long min, long max) {
+		if (v == null) return 0;
+		if (! (v instanceof java.math.BigDecimal))
+			throw new InputMismatchException(format("colfer: %s: got %s, want number", path, v));
+		try {
+			long x = ((java.math.BigDecimal) v).longValueExact();
+			if (x >= min && x <= max) return x;
+		} catch (ArithmeticException e) {
+		}
+		throw new InputMismatchException(format("colfer: %s: number %s out of range", path, v));
+	}
+{{- end}}
+{{- if .HasInt64}}
+
+	// Parses a string with the decimal value, or a number within the range of
+	// Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have lost
+	// precision in an IEEE 754 double already.
+	static long jsonInt64(Object v, String path, boolean unsigned) {
+		if (v == null) return 0;
+		try {
+			if (v instanceof String && ! ((String) v).startsWith("+")) {
+				if (unsigned) return Long.parseUnsignedLong((String) v);
+				return Long.parseLong((String) v);
+			}
+		} catch (NumberFormatException e) {
+		}
+		if (v instanceof String)
+			throw new InputMismatchException(format("colfer: %s: malformed integer %s", path, v));
+		return jsonInteger(v, path, unsigned ? 0 : -(1L << 53) + 1, (1L << 53) - 1);
+	}
+{{- end}}
+{{- if .HasTimestamp}}
+
+	// Appends RFC 3339 with the year as in Go, and the fraction without trailing zeros.
+	static void appendJSON(StringBuilder buf, java.time.Instant t) {
+		if (t == null) {
+			buf.append("null");
+			return;
+		}
+		java.time.LocalDateTime d = java.time.LocalDateTime.ofEpochSecond(t.getEpochSecond(), 0, java.time.ZoneOffset.UTC);
+		buf.append('"');
+		int year = d.getYear();
+		if (year < 0) {
+			buf.append('-');
+			year = -year;
+		}
+		for (int i = Integer.toString(year).length(); i < 4; i++) buf.append('0');
+		buf.append(year).append('-');
+		appendTwoDigits(buf, d.getMonthValue()).append('-');
+		appendTwoDigits(buf, d.getDayOfMonth()).append('T');
+		appendTwoDigits(buf, d.getHour()).append(':');
+		appendTwoDigits(buf, d.getMinute()).append(':');
+		appendTwoDigits(buf, d.getSecond());
+		if (t.getNano() != 0) {
+			String f = Integer.toString(t.getNano() + 1000000000);
+			int end = f.length();
+			while (f.charAt(end - 1) == '0') end--;
+			buf.append('.').append(f, 1, end);
+		}
+		buf.append("Z\"");
+	}
+
+	static StringBuilder appendTwoDigits(StringBuilder buf, int x) {
+		return buf.append((char) ('0' + x / 10)).append((char) ('0' + x % 10));
+	}
+
+	static java.time.Instant jsonTimestamp(Object v, String path) {
+		if (v == null) return null;
+		if (v instanceof String) {
+			String s = (String) v;
+			// years beyond 9999 have no plus sign, like in Go
+			if (s.indexOf('-') > 4 && s.charAt(0) != '-') s = "+" + s;
+			try {
+				return java.time.OffsetDateTime.parse(s).toInstant();
+			} catch (java.time.format.DateTimeParseException e) {
+			}
+		}
+		throw new InputMismatchException(format("colfer: %s: got %s, want RFC 3339 timestamp", path, v));
+	}
+{{- end}}
+{{- if .HasText}}
+
+	// Appends a JSON string with the escapes of JSON.stringify from ECMAScript.
+	// Unpaired surrogates are replaced with U+FFFD.
+	static void appendJSON(StringBuilder buf, String s) {
+		buf.append('"');
+		if (s != null) for (int i = 0; i < s.length(); i++) {
+			char c = s.charAt(i);
+			switch (c) {
+			case '"':
+				buf.append("\\\"");
+				break;
+			case '\\':
+				buf.append("\\\\");
+				break;
+			case '\b':
+				buf.append("\\b");
+				break;
+			case '\f':
+				buf.append("\\f");
+				break;
+			case '\n':
+				buf.append("\\n");
+				break;
+			case '\r':
+				buf.append("\\r");
+				break;
+			case '\t':
+				buf.append("\\t");
+				break;
+			default:
+				if (c < ' ') {
+					buf.append("\\u00").append("0123456789abcdef".charAt(c >> 4)).append("0123456789abcdef".charAt(c & 0xf));
+				} else if (! Character.isSurrogate(c)) {
+					buf.append(c);
+				} else if (Character.isHighSurrogate(c) && i + 1 < s.length() && Character.isLowSurrogate(s.charAt(i + 1))) {
+					buf.append(c).append(s.charAt(++i));
+				} else {
+					buf.append('\ufffd');
+				}
+			}
+		}
+		buf.append('"');
+	}
+
+	static String jsonText(Object v, String path, int max) {
+		if (v == null) return "";
+		if (! (v instanceof String))
+			throw new InputMismatchException(format("colfer: %s: got %s, want string", path, v));
+		String s = (String) v;
+		if (s.length() * 3 > max) {
+			int size = s.getBytes(StandardCharsets.UTF_8).length;
+			if (size > max)
+				throw new SecurityException(format("colfer: %s size %d exceeds %d UTF-8 bytes", path, size, max));
+		}
+		return s;
+	}
+{{- end}}
+{{- if .HasBinary}}
+
+	static void appendJSON(StringBuilder buf, byte[] b) {
+		buf.append('"');
+		if (b != null) buf.append(java.util.Base64.getEncoder().encodeToString(b));
+		buf.append('"');
+	}
+
+	// Parses standard base64, with or without padding.
+	static byte[] jsonBinary(Object v, String path, int max) {
+		if (v == null) return new byte[0];
+		byte[] b;
+		try {
+			b = java.util.Base64.getDecoder().decode((String) v);
+		} catch (ClassCastException | IllegalArgumentException e) {
+			throw new InputMismatchException(format("colfer: %s: got %s, want standard base64", path, v));
+		}
+		if (b.length > max)
+			throw new SecurityException(format("colfer: %s size %d exceeds %d bytes", path, b.length, max));
+		return b;
+	}
+{{- end}}
+{{- if .HasList}}
+
+	@SuppressWarnings("unchecked")
+	static java.util.List<Object> jsonList(Object v, String path, int max) {
+		if (v == null) return java.util.Collections.emptyList();
+		if (! (v instanceof java.util.List))
+			throw new InputMismatchException(format("colfer: %s: got %s, want array", path, v));
+		java.util.List<Object> a = (java.util.List<Object>) v;
+		if (a.size() > max)
+			throw new SecurityException(format("colfer: %s length %d exceeds %d elements", path, a.size(), max));
+		return a;
+	}
+{{- end}}
+
+	@SuppressWarnings("unchecked")
+	static java.util.Map<String, Object> jsonObject(Object v, String path) {
+		if (! (v instanceof java.util.Map))
+			throw new InputMismatchException(format("colfer: %s: got %s, want object", path, v));
+		return (java.util.Map<String, Object>) v;
+	}
+
+	static int skipJSONSpace(String s, int i) {
+		while (i < s.length() && " \t\n\r".indexOf(s.charAt(i)) >= 0) i++;
+		return i;
+	}
+
+	// Parses the JSON value at pos[0] and updates the index to its end.
+	static Object parseJSON(String s, int[] pos) {
+		int i = skipJSONSpace(s, pos[0]);
+		if (i >= s.length()) throw new InputMismatchException("colfer: JSON EOF");
+		switch (s.charAt(i)) {
+		case '{':
+			java.util.Map<String, Object> m = new java.util.LinkedHashMap<>();
+			i = skipJSONSpace(s, i + 1);
+			if (i < s.length() && s.charAt(i) == '}') {
+				pos[0] = i + 1;
+				return m;
+			}
+			while (true) {
+				pos[0] = i;
+				if (i >= s.length() || s.charAt(i) != '"') break;
+				String name = parseJSONString(s, pos);
+				i = skipJSONSpace(s, pos[0]);
+				if (i >= s.length() || s.charAt(i) != ':') break;
+				pos[0] = i + 1;
+				m.put(name, parseJSON(s, pos));
+				i = skipJSONSpace(s, pos[0]);
+				if (i < s.length() && s.charAt(i) == '}') {
+					pos[0] = i + 1;
+					return m;
+				}
+				if (i >= s.length() || s.charAt(i) != ',') break;
+				i = skipJSONSpace(s, i + 1);
+			}
+			break;
+		case '[':
+			java.util.List<Object> a = new java.util.ArrayList<>();
+			i = skipJSONSpace(s, i + 1);
+			if (i < s.length() && s.charAt(i) == ']') {
+				pos[0] = i + 1;
+				return a;
+			}
+			while (true) {
+				pos[0] = i;
+				a.add(parseJSON(s, pos));
+				i = skipJSONSpace(s, pos[0]);
+				if (i < s.length() && s.charAt(i) == ']') {
+					pos[0] = i + 1;
+					return a;
+				}
+				if (i >= s.length() || s.charAt(i) != ',') break;
+				i++;
+			}
+			break;
+		case '"':
+			pos[0] = i;
+			return parseJSONString(s, pos);
+		case 't':
+			if (! s.startsWith("true", i)) break;
+			pos[0] = i + 4;
+			return Boolean.TRUE;
+		case 'f':
+			if (! s.startsWith("false", i)) break;
+			pos[0] = i + 5;
+			return Boolean.FALSE;
+		case 'n':
+			if (! s.startsWith("null", i)) break;
+			pos[0] = i + 4;
+			return null;
+		default:
+			int start = i;
+			while (i < s.length() && "+-.0123456789Ee".indexOf(s.charAt(i)) >= 0) i++;
+			String n = s.substring(start, i);
+			if (! n.matches("-?(0|[1-9][0-9]*)(\\.[0-9]+)?([Ee][+-]?[0-9]+)?")) break;
+			try {
+				java.math.BigDecimal d = new java.math.BigDecimal(n);
+				pos[0] = i;
+				return d;
+			} catch (NumberFormatException e) {
+				break;
+			}
+		}
+		throw new InputMismatchException(format("colfer: malformed JSON at character %d", i));
+	}
+
+	// Parses the JSON string at pos[0] and updates the index to its end.
+	static String parseJSONString(String s, int[] pos) {
+		StringBuilder buf = new StringBuilder();
+		int i = pos[0] + 1;
+		while (i < s.length()) {
+			char c = s.charAt(i++);
+			if (c == '"') {
+				pos[0] = i;
+				return buf.toString();
+			}
+			if (c < ' ') break;
+			if (c != '\\') {
+				buf.append(c);
+				continue;
+			}
+			if (i >= s.length()) break;
+			c = s.charAt(i++);
+			switch (c) {
+			case '"': case '\\': case '/':
+				buf.append(c);
+				continue;
+			case 'b':
+				buf.append('\b');
+				continue;
+			case 'f':
+				buf.append('\f');
+				continue;
+			case 'n':
+				buf.append('\n');
+				continue;
+			case 'r':
+				buf.append('\r');
+				continue;
+			case 't':
+				buf.append('\t');
+				continue;
+			case 'u':
+				if (i + 4 > s.length()) break;
+				try {
+					buf.append((char) Integer.parseInt(s.substring(i, i + 4), 16));
+				} catch (NumberFormatException e) {
+					break;
+				}
+				i += 4;
+				continue;
+			}
+			break;
+		}
+		throw new InputMismatchException(format("colfer: malformed JSON string at character %d", i - 1));
+	}
+}
+`
+
 const javaCode = `package {{.Pkg.NameNative}};
 
 
@@ -166,10 +619,14 @@ import java.nio.BufferUnderflowException;
 {{$class := .NameTitle}}public class {{$class}}{{if .Pkg.SuperClassNative}} extends {{.Pkg.SuperClassNative}}{{end}} implements Serializable {
 
 	/** The upper limit for serial byte sizes. */
-	public static int colferSizeMax = {{.Pkg.SizeMax}};
+	public static int colferSizeMax = {{.SizeMaxExpr}};
 {{if .HasList}}
 	/** The upper limit for the number of elements in a list. */
-	public static int colferListMax = {{.Pkg.ListMax}};
+	public static int colferListMax = {{.ListMaxExpr}};
+{{end}}
+{{- if .FixedSizeMax}}
+	/** The upper limit for serial byte sizes, regardless of the field values. */
+	public static final int colferFixedMax = {{.FixedSizeMax}};
 {{end}}
 
 {{range .Fields}}
@@ -1086,8 +1543,8 @@ import java.nio.BufferUnderflowException;
 		while (true) try {
 			n = marshal(buf, 0);
 			break;
-		} catch (BufferUnderflowException e) {
-			buf = new byte[4 * buf.length];
+		} catch (BufferOverflowException e) {
+			buf = new byte[Math.min({{$class}}.colferSizeMax, 4 * buf.length)];
 		}
 
 		out.writeInt(n);
@@ -1108,6 +1565,160 @@ import java.nio.BufferUnderflowException;
 	private void readObjectNoData() throws ObjectStreamException {
 		init();
 	}
+
+	/**
+	 * Serializes the object as JSON, in the canonical representation which is
+	 * shared with the other languages. The 64-bit integers are strings,
+	 * binaries are base64 and timestamps are RFC 3339 with nanoseconds.
+{{- range .Fields}}{{if and .TypeList .TypeRef}}
+	 * Any {@code null} elements in {@link #{{.NameNative}}} are written as a {@code new} value.
+{{- end}}{{end}}
+	 * @return the JSON text.
+	 */
+	public String toJSON() {
+		return toJSON(new StringBuilder()).toString();
+	}
+
+	/**
+	 * Serializes the object as JSON, conform {@link #toJSON()}.
+	 * @param buf the data destination.
+	 * @return {@code buf}.
+	 */
+	public StringBuilder toJSON(StringBuilder buf) {
+{{- range .Fields}}
+		buf.append("{{if .Index}},{{else}}{{"{"}}{{end}}\"{{.Name}}\":");
+{{- if .TypeList}}
+		buf.append('[');
+		if (this.{{.NameNative}} != null) for (int ai = 0; ai < this.{{.NameNative}}.length; ai++) {
+			if (ai != 0) buf.append(',');
+ {{- if eq .Type "float32" "float64" "text" "binary"}}
+			ColferJSON.appendJSON(buf, this.{{.NameNative}}[ai]);
+ {{- else}}
+			{{.TypeNative}} o = this.{{.NameNative}}[ai];
+			(o == null ? new {{.TypeNative}}() : o).toJSON(buf);
+ {{- end}}
+		}
+		buf.append(']');
+{{- else if eq .Type "bool" "int32"}}
+		buf.append(this.{{.NameNative}});
+{{- else if eq .Type "uint8"}}
+		buf.append(this.{{.NameNative}} & 0xff);
+{{- else if eq .Type "uint16"}}
+		buf.append(this.{{.NameNative}} & 0xffff);
+{{- else if eq .Type "uint32"}}
+		buf.append(this.{{.NameNative}} & 0xffffffffL);
+{{- else if eq .Type "uint64"}}
+		buf.append('"').append(Long.toUnsignedString(this.{{.NameNative}})).append('"');
+{{- else if eq .Type "int64"}}
+		buf.append('"').append(this.{{.NameNative}}).append('"');
+{{- else if .TypeRef}}
+		if (this.{{.NameNative}} == null) buf.append("null");
+		else this.{{.NameNative}}.toJSON(buf);
+{{- else}}
+		ColferJSON.appendJSON(buf, this.{{.NameNative}});
+{{- end}}
+{{- end}}
+		return buf.append({{if .Fields}}'}'{{else}}"{}"{{end}});
+	}
+
+	/**
+	 * Deserializes the object from JSON, conform {@link #toJSON()}.
+	 * Absent fields and {@code null} values read as the zero value.
+	 * @param json the JSON text.
+	 * @return {@code this}.
+	 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 * @throws InputMismatchException when the data is malformed or when it does not match this object's schema.
+	 */
+	public {{$class}} fromJSON(String json) {
+		int[] pos = {0};
+		Object doc = ColferJSON.parseJSON(json, pos);
+		if (ColferJSON.skipJSONSpace(json, pos[0]) != json.length())
+			throw new InputMismatchException(format("colfer: data continuation after JSON at character %d", pos[0]));
+		return fromJSON(ColferJSON.jsonObject(doc, "{{.String}}"));
+	}
+
+	/**
+	 * Deserializes the object from a JSON object, conform {@link #fromJSON(String)}.
+	 * The values are either {@code null}, {@link Boolean}, {@link java.math.BigDecimal},
+	 * {@link String}, {@link java.util.List} or {@link java.util.Map}.
+	 * @param json the JSON object.
+	 * @return {@code this}.
+	 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public {{$class}} fromJSON(java.util.Map<String, Object> json) {
+		for (String name : json.keySet()) switch (name) {
+{{- range .Fields}}
+		case "{{.Name}}":
+{{- end}}
+{{- if .Fields}}
+			break;
+{{- end}}
+		default:
+			throw new InputMismatchException(format("colfer: {{.String}} has no field %s", name));
+		}
+{{range .Fields}}
+{{- if .TypeList}}
+		{
+			java.util.List<Object> a = ColferJSON.jsonList(json.get("{{.Name}}"), "{{.String}}", colferListMax);
+{{- if eq .Type "binary"}}
+			byte[][] x = new byte[a.size()][];
+{{- else}}
+			{{.TypeNative}}[] x = new {{.TypeNative}}[a.size()];
+{{- end}}
+			for (int ai = 0; ai < x.length; ai++) {
+				String path = "{{.String}}[" + ai + "]";
+ {{- if eq .Type "float32"}}
+				x[ai] = (float) ColferJSON.jsonFloat(a.get(ai), path, true);
+ {{- else if eq .Type "float64"}}
+				x[ai] = ColferJSON.jsonFloat(a.get(ai), path, false);
+ {{- else if eq .Type "text"}}
+				x[ai] = ColferJSON.jsonText(a.get(ai), path, colferSizeMax);
+ {{- else if eq .Type "binary"}}
+				x[ai] = ColferJSON.jsonBinary(a.get(ai), path, colferSizeMax);
+ {{- else}}
+				Object o = a.get(ai);
+				x[ai] = new {{.TypeNative}}().fromJSON(o == null ? java.util.Collections.<String, Object>emptyMap() : ColferJSON.jsonObject(o, path));
+ {{- end}}
+			}
+			this.{{.NameNative}} = x;
+		}
+{{- else if eq .Type "bool"}}
+		{
+			Object v = json.get("{{.Name}}");
+			if (v != null && ! (v instanceof Boolean))
+				throw new InputMismatchException(format("colfer: {{.String}}: got %s, want boolean", v));
+			this.{{.NameNative}} = v != null && (Boolean) v;
+		}
+{{- else if eq .Type "uint8"}}
+		this.{{.NameNative}} = (byte) ColferJSON.jsonInteger(json.get("{{.Name}}"), "{{.String}}", 0, 255);
+{{- else if eq .Type "uint16"}}
+		this.{{.NameNative}} = (short) ColferJSON.jsonInteger(json.get("{{.Name}}"), "{{.String}}", 0, 65535);
+{{- else if eq .Type "uint32"}}
+		this.{{.NameNative}} = (int) ColferJSON.jsonInteger(json.get("{{.Name}}"), "{{.String}}", 0, 4294967295L);
+{{- else if eq .Type "int32"}}
+		this.{{.NameNative}} = (int) ColferJSON.jsonInteger(json.get("{{.Name}}"), "{{.String}}", Integer.MIN_VALUE, Integer.MAX_VALUE);
+{{- else if eq .Type "uint64" "int64"}}
+		this.{{.NameNative}} = ColferJSON.jsonInt64(json.get("{{.Name}}"), "{{.String}}", {{if eq .Type "uint64"}}true{{else}}false{{end}});
+{{- else if eq .Type "float32"}}
+		this.{{.NameNative}} = (float) ColferJSON.jsonFloat(json.get("{{.Name}}"), "{{.String}}", true);
+{{- else if eq .Type "float64"}}
+		this.{{.NameNative}} = ColferJSON.jsonFloat(json.get("{{.Name}}"), "{{.String}}", false);
+{{- else if eq .Type "timestamp"}}
+		this.{{.NameNative}} = ColferJSON.jsonTimestamp(json.get("{{.Name}}"), "{{.String}}");
+{{- else if eq .Type "text"}}
+		this.{{.NameNative}} = ColferJSON.jsonText(json.get("{{.Name}}"), "{{.String}}", colferSizeMax);
+{{- else if eq .Type "binary"}}
+		this.{{.NameNative}} = ColferJSON.jsonBinary(json.get("{{.Name}}"), "{{.String}}", colferSizeMax);
+{{- else}}
+		{
+			Object o = json.get("{{.Name}}");
+			this.{{.NameNative}} = o == null ? null : new {{.TypeNative}}().fromJSON(ColferJSON.jsonObject(o, "{{.String}}"));
+		}
+{{- end}}
+{{- end}}
+		return this;
+	}
 {{range .Fields}}
 	/**
 	 * Gets {{.String}}.
@@ -1,6 +1,7 @@
 import java.util.Arrays;
 
 import bench.Colfer;
+import tree.Node;
 
 
 public class bench {
@@ -9,6 +10,8 @@ public class bench {
 		benchMarshal();
 		benchMarshalReuse();
 		benchUnmarshal();
+		benchMarshalNested();
+		benchUnmarshalNested();
 	}
 
 	private static Colfer[] newTestData() {
@@ -51,9 +54,25 @@ public class bench {
 		return new Colfer[] {c1, c2, c3, c4};
 	}
 
+	// Nested test data with lists on each level.
+	private static Node newTree(int depth, int width) {
+		Node o = new Node();
+		o.name = "node";
+		o.depth = depth;
+		o.weights = new double[] {1, 2};
+		if (depth != 0) {
+			o.children = new Node[width];
+			for (int i = 0; i < width; i++)
+				o.children[i] = newTree(depth - 1, width);
+			o.first = newTree(depth - 1, 1);
+		}
+		return o;
+	}
+
 	// prevent compiler optimization
 	public static byte[] holdSerial;
 	public static Colfer holdData;
+	public static Node holdNode;
 
 	static void benchMarshal() {
 		Colfer[] testData = newTestData();
@@ -103,4 +122,33 @@ public class bench {
 		System.err.printf("%dM unmarshals avg %dns\n", n / 1000000, (end - start) / n);
 	}
 
+	static void benchMarshalNested() {
+		Node testTree = newTree(4, 6);
+		holdSerial = new byte[64 * 1024];
+		final int n = 20000;
+
+		long start = System.nanoTime();
+		for (int i = 0; i < n; i++) {
+			testTree.marshal(holdSerial, 0);
+		}
+		long end = System.nanoTime();
+
+		System.err.printf("%dk nested marshals avg %dns\n", n / 1000, (end - start) / n);
+	}
+
+	static void benchUnmarshalNested() {
+		byte[] buf = new byte[64 * 1024];
+		byte[] serial = Arrays.copyOf(buf, newTree(4, 6).marshal(buf, 0));
+		final int n = 20000;
+
+		long start = System.nanoTime();
+		for (int i = 0; i < n; i++) {
+			holdNode = new Node();
+			holdNode.unmarshal(serial, 0);
+		}
+		long end = System.nanoTime();
+
+		System.err.printf("%dk nested unmarshals avg %dns\n", n / 1000, (end - start) / n);
+	}
+
 }
@@ -1012,8 +1012,8 @@ public class O implements Serializable {
 		while (true) try {
 			n = marshal(buf, 0);
 			break;
-		} catch (BufferUnderflowException e) {
-			buf = new byte[4 * buf.length];
+		} catch (BufferOverflowException e) {
+			buf = new byte[Math.min(O.colferSizeMax, 4 * buf.length)];
 		}
 
 		out.writeInt(n);
@@ -1035,6 +1035,209 @@ public class O implements Serializable {
 		init();
 	}
 
+	/**
+	 * Serializes the object as JSON, in the canonical representation which is
+	 * shared with the other languages. The 64-bit integers are strings,
+	 * binaries are base64 and timestamps are RFC 3339 with nanoseconds.
+	 * Any {@code null} elements in {@link #os} are written as a {@code new} value.
+	 * @return the JSON text.
+	 */
+	public String toJSON() {
+		return toJSON(new StringBuilder()).toString();
+	}
+
+	/**
+	 * Serializes the object as JSON, conform {@link #toJSON()}.
+	 * @param buf the data destination.
+	 * @return {@code buf}.
+	 */
+	public StringBuilder toJSON(StringBuilder buf) {
+		buf.append("{\"b\":");
+		buf.append(this.b);
+		buf.append(",\"u32\":");
+		buf.append(this.u32 & 0xffffffffL);
+		buf.append(",\"u64\":");
+		buf.append('"').append(Long.toUnsignedString(this.u64)).append('"');
+		buf.append(",\"i32\":");
+		buf.append(this.i32);
+		buf.append(",\"i64\":");
+		buf.append('"').append(this.i64).append('"');
+		buf.append(",\"f32\":");
+		ColferJSON.appendJSON(buf, this.f32);
+		buf.append(",\"f64\":");
+		ColferJSON.appendJSON(buf, this.f64);
+		buf.append(",\"t\":");
+		ColferJSON.appendJSON(buf, this.t);
+		buf.append(",\"s\":");
+		ColferJSON.appendJSON(buf, this.s);
+		buf.append(",\"a\":");
+		ColferJSON.appendJSON(buf, this.a);
+		buf.append(",\"o\":");
+		if (this.o == null) buf.append("null");
+		else this.o.toJSON(buf);
+		buf.append(",\"os\":");
+		buf.append('[');
+		if (this.os != null) for (int ai = 0; ai < this.os.length; ai++) {
+			if (ai != 0) buf.append(',');
+			O o = this.os[ai];
+			(o == null ? new O() : o).toJSON(buf);
+		}
+		buf.append(']');
+		buf.append(",\"ss\":");
+		buf.append('[');
+		if (this.ss != null) for (int ai = 0; ai < this.ss.length; ai++) {
+			if (ai != 0) buf.append(',');
+			ColferJSON.appendJSON(buf, this.ss[ai]);
+		}
+		buf.append(']');
+		buf.append(",\"as\":");
+		buf.append('[');
+		if (this.as != null) for (int ai = 0; ai < this.as.length; ai++) {
+			if (ai != 0) buf.append(',');
+			ColferJSON.appendJSON(buf, this.as[ai]);
+		}
+		buf.append(']');
+		buf.append(",\"u8\":");
+		buf.append(this.u8 & 0xff);
+		buf.append(",\"u16\":");
+		buf.append(this.u16 & 0xffff);
+		buf.append(",\"f32s\":");
+		buf.append('[');
+		if (this.f32s != null) for (int ai = 0; ai < this.f32s.length; ai++) {
+			if (ai != 0) buf.append(',');
+			ColferJSON.appendJSON(buf, this.f32s[ai]);
+		}
+		buf.append(']');
+		buf.append(",\"f64s\":");
+		buf.append('[');
+		if (this.f64s != null) for (int ai = 0; ai < this.f64s.length; ai++) {
+			if (ai != 0) buf.append(',');
+			ColferJSON.appendJSON(buf, this.f64s[ai]);
+		}
+		buf.append(']');
+		return buf.append('}');
+	}
+
+	/**
+	 * Deserializes the object from JSON, conform {@link #toJSON()}.
+	 * Absent fields and {@code null} values read as the zero value.
+	 * @param json the JSON text.
+	 * @return {@code this}.
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws InputMismatchException when the data is malformed or when it does not match this object's schema.
+	 */
+	public O fromJSON(String json) {
+		int[] pos = {0};
+		Object doc = ColferJSON.parseJSON(json, pos);
+		if (ColferJSON.skipJSONSpace(json, pos[0]) != json.length())
+			throw new InputMismatchException(format("colfer: data continuation after JSON at character %d", pos[0]));
+		return fromJSON(ColferJSON.jsonObject(doc, "gen.o"));
+	}
+
+	/**
+	 * Deserializes the object from a JSON object, conform {@link #fromJSON(String)}.
+	 * The values are either {@code null}, {@link Boolean}, {@link java.math.BigDecimal},
+	 * {@link String}, {@link java.util.List} or {@link java.util.Map}.
+	 * @param json the JSON object.
+	 * @return {@code this}.
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public O fromJSON(java.util.Map<String, Object> json) {
+		for (String name : json.keySet()) switch (name) {
+		case "b":
+		case "u32":
+		case "u64":
+		case "i32":
+		case "i64":
+		case "f32":
+		case "f64":
+		case "t":
+		case "s":
+		case "a":
+		case "o":
+		case "os":
+		case "ss":
+		case "as":
+		case "u8":
+		case "u16":
+		case "f32s":
+		case "f64s":
+			break;
+		default:
+			throw new InputMismatchException(format("colfer: gen.o has no field %s", name));
+		}
+
+		{
+			Object v = json.get("b");
+			if (v != null && ! (v instanceof Boolean))
+				throw new InputMismatchException(format("colfer: gen.o.b: got %s, want boolean", v));
+			this.b = v != null && (Boolean) v;
+		}
+		this.u32 = (int) ColferJSON.jsonInteger(json.get("u32"), "gen.o.u32", 0, 4294967295L);
+		this.u64 = ColferJSON.jsonInt64(json.get("u64"), "gen.o.u64", true);
+		this.i32 = (int) ColferJSON.jsonInteger(json.get("i32"), "gen.o.i32", Integer.MIN_VALUE, Integer.MAX_VALUE);
+		this.i64 = ColferJSON.jsonInt64(json.get("i64"), "gen.o.i64", false);
+		this.f32 = (float) ColferJSON.jsonFloat(json.get("f32"), "gen.o.f32", true);
+		this.f64 = ColferJSON.jsonFloat(json.get("f64"), "gen.o.f64", false);
+		this.t = ColferJSON.jsonTimestamp(json.get("t"), "gen.o.t");
+		this.s = ColferJSON.jsonText(json.get("s"), "gen.o.s", colferSizeMax);
+		this.a = ColferJSON.jsonBinary(json.get("a"), "gen.o.a", colferSizeMax);
+		{
+			Object o = json.get("o");
+			this.o = o == null ? null : new O().fromJSON(ColferJSON.jsonObject(o, "gen.o.o"));
+		}
+		{
+			java.util.List<Object> a = ColferJSON.jsonList(json.get("os"), "gen.o.os", colferListMax);
+			O[] x = new O[a.size()];
+			for (int ai = 0; ai < x.length; ai++) {
+				String path = "gen.o.os[" + ai + "]";
+				Object o = a.get(ai);
+				x[ai] = new O().fromJSON(o == null ? java.util.Collections.<String, Object>emptyMap() : ColferJSON.jsonObject(o, path));
+			}
+			this.os = x;
+		}
+		{
+			java.util.List<Object> a = ColferJSON.jsonList(json.get("ss"), "gen.o.ss", colferListMax);
+			String[] x = new String[a.size()];
+			for (int ai = 0; ai < x.length; ai++) {
+				String path = "gen.o.ss[" + ai + "]";
+				x[ai] = ColferJSON.jsonText(a.get(ai), path, colferSizeMax);
+			}
+			this.ss = x;
+		}
+		{
+			java.util.List<Object> a = ColferJSON.jsonList(json.get("as"), "gen.o.as", colferListMax);
+			byte[][] x = new byte[a.size()][];
+			for (int ai = 0; ai < x.length; ai++) {
+				String path = "gen.o.as[" + ai + "]";
+				x[ai] = ColferJSON.jsonBinary(a.get(ai), path, colferSizeMax);
+			}
+			this.as = x;
+		}
+		this.u8 = (byte) ColferJSON.jsonInteger(json.get("u8"), "gen.o.u8", 0, 255);
+		this.u16 = (short) ColferJSON.jsonInteger(json.get("u16"), "gen.o.u16", 0, 65535);
+		{
+			java.util.List<Object> a = ColferJSON.jsonList(json.get("f32s"), "gen.o.f32s", colferListMax);
+			float[] x = new float[a.size()];
+			for (int ai = 0; ai < x.length; ai++) {
+				String path = "gen.o.f32s[" + ai + "]";
+				x[ai] = (float) ColferJSON.jsonFloat(a.get(ai), path, true);
+			}
+			this.f32s = x;
+		}
+		{
+			java.util.List<Object> a = ColferJSON.jsonList(json.get("f64s"), "gen.o.f64s", colferListMax);
+			double[] x = new double[a.size()];
+			for (int ai = 0; ai < x.length; ai++) {
+				String path = "gen.o.f64s[" + ai + "]";
+				x[ai] = ColferJSON.jsonFloat(a.get(ai), path, false);
+			}
+			this.f64s = x;
+		}
+		return this;
+	}
+
 	/**
 	 * Gets gen.o.b.
 	 * @return the value.
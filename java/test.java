@@ -2,13 +2,18 @@ import gen.O;
 
 import java.io.ByteArrayOutputStream;
 import java.io.ByteArrayInputStream;
+import java.io.IOException;
 import java.io.ObjectInputStream;
 import java.io.ObjectOutputStream;
 import java.math.BigInteger;
 import java.nio.ByteBuffer;
+import java.nio.file.Files;
+import java.nio.file.Paths;
 import java.time.Instant;
+import java.util.ArrayList;
 import java.util.Arrays;
 import java.util.LinkedHashMap;
+import java.util.List;
 import java.util.Map;
 import java.util.Map.Entry;
 import java.util.Set;
@@ -40,6 +45,10 @@ public class test {
 			unmarshalListMax();
 
 			serializable();
+
+			toJSON();
+			fromJSON();
+			fromJSONErrors();
 		} catch (Exception e) {
 			e.printStackTrace();
 			System.exit(1);
@@ -128,6 +137,21 @@ public class test {
 		return o;
 	}
 
+	// Gets the canonical JSON per serial from testdata/json.txt, which must be
+	// identical in all languages. Each case has the serial in hexadecimal, the
+	// direction, and the JSON.
+	static List<String[]> newJSONGoldenCases() throws IOException {
+		List<String[]> cases = new ArrayList<>();
+		for (String line : Files.readAllLines(Paths.get("../testdata/json.txt"), UTF_8)) {
+			if (line.isEmpty() || line.startsWith("#")) continue;
+			String[] fields = line.split(" ", 3);
+			if (fields.length != 3 || ! fields[1].equals("<->") && ! fields[1].equals("->"))
+				throw new IOException("malformed JSON golden " + line);
+			cases.add(fields);
+		}
+		return cases;
+	}
+
 
 	static void identity() {
 		if (new O().equals((Object) null))
@@ -342,6 +366,62 @@ public class test {
 		}
 	}
 
+	static void toJSON() throws IOException {
+		for (String[] c : newJSONGoldenCases()) {
+			O o = new O();
+			o.unmarshal(parseHex(c[0]), 0);
+			String got = o.toJSON();
+			if (! got.equals(c[2]))
+				fail("toJSON: 0x%s: got %s\nwant %s", c[0], got, c[2]);
+		}
+	}
+
+	static void fromJSON() throws IOException {
+		for (String[] c : newJSONGoldenCases()) {
+			if (! c[1].equals("<->")) continue;
+			O o = new O().fromJSON(c[2]);
+			byte[] buf = new byte[O.colferSizeMax];
+			String got = toHex(Arrays.copyOf(buf, o.marshal(buf, 0)));
+			if (! got.equals(c[0]))
+				fail("fromJSON: %s: got serial 0x%s, want 0x%s", c[2], got, c[0]);
+		}
+
+		String[][] lenient = {
+			{" { \"u64\" : 9007199254740991 } ", "02ffffffffffffff0f7f"},
+			{"{\"a\":\"/w\"}", "0901ff7f"},
+			{"{\"t\":\"2015-09-08T21:04:10.1+02:00\"}", "0755ef312a05f5e1007f"},
+			{"{\"os\":[null]}", "0b017f7f"},
+		};
+		for (String[] c : lenient) {
+			O o = new O().fromJSON(c[0]);
+			byte[] buf = new byte[O.colferSizeMax];
+			String got = toHex(Arrays.copyOf(buf, o.marshal(buf, 0)));
+			if (! got.equals(c[1]))
+				fail("fromJSON: %s: got serial 0x%s, want 0x%s", c[0], got, c[1]);
+		}
+	}
+
+	static void fromJSONErrors() {
+		String[][] cases = {
+			{"{\"x\":1}", "colfer: gen.o has no field x"},
+			{"{\"u8\":256}", "colfer: gen.o.u8: number 256 out of range"},
+			{"{\"u64\":9007199254740992}", "colfer: gen.o.u64: number 9007199254740992 out of range"},
+			{"{\"i64\":\"1.5\"}", "colfer: gen.o.i64: malformed integer 1.5"},
+			{"{\"f32\":1e39}", "colfer: gen.o.f32: number 1E+39 out of range"},
+			{"{\"b\":1}", "colfer: gen.o.b: got 1, want boolean"},
+			{"{} {}", "colfer: data continuation after JSON at character 2"},
+		};
+		for (String[] c : cases) {
+			try {
+				new O().fromJSON(c[0]);
+				fail("fromJSON: %s: no exception", c[0]);
+			} catch (java.util.InputMismatchException e) {
+				if (! c[1].equals(e.getMessage()))
+					fail("fromJSON: %s: got error %s\nwant %s", c[0], e.getMessage(), c[1]);
+			}
+		}
+	}
+
 	static String toHex(byte[] bytes) {
 		String hex = new BigInteger(1, bytes).toString(16);
 		while (bytes.length * 2 > hex.length())
@@ -0,0 +1,246 @@
+package colfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Limits apply when the package has no expression. The evaluation of the
+// default expressions can not fail.
+var (
+	defaultSizeMax, _ = EvalLimit(DefaultSizeMax, 0)
+	defaultListMax, _ = EvalLimit(DefaultListMax, 0)
+)
+
+// jsonSchema is a JSON Schema (draft 2020-12) node. The field order
+// determines the keyword order in the output.
+type jsonSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type is either a string or a list of strings.
+	Type            interface{}   `json:"type,omitempty"`
+	Format          string        `json:"format,omitempty"`
+	ContentEncoding string        `json:"contentEncoding,omitempty"`
+	Pattern         string        `json:"pattern,omitempty"`
+	Enum            []interface{} `json:"enum,omitempty"`
+	Minimum         json.Number   `json:"minimum,omitempty"`
+	Maximum         json.Number   `json:"maximum,omitempty"`
+	MaxLength       int           `json:"maxLength,omitempty"`
+	AnyOf           []*jsonSchema `json:"anyOf,omitempty"`
+	Items           *jsonSchema   `json:"items,omitempty"`
+	MaxItems        int           `json:"maxItems,omitempty"`
+
+	Properties           jsonProperties `json:"properties,omitempty"`
+	AdditionalProperties *bool          `json:"additionalProperties,omitempty"`
+}
+
+// jsonProperties is an object with the keys in order of appearance.
+type jsonProperties []jsonProperty
+
+type jsonProperty struct {
+	Name   string
+	Schema *jsonSchema
+}
+
+// MarshalJSON honors the json.Marshaler interface.
+func (props jsonProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, p := range props {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// GenerateJSONSchema writes a JSON Schema (draft 2020-12) for each struct into
+// file "<name>.schema.json" in the package directory. The schemas validate
+// the JSON mapping of package dynamic. Note that maxLength counts characters
+// while SizeMax counts UTF-8 bytes, so multibyte text may pass validation and
+// still exceed the limit.
+func GenerateJSONSchema(basedir string, packages Packages) error {
+	sources, err := JSONSchemaSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// JSONSchemaSources returns the schemas of GenerateJSONSchema in memory.
+func JSONSchemaSources(packages Packages) (Sources, error) {
+	sources := make(Sources)
+	for _, p := range packages {
+		for _, s := range p.Structs {
+			sizeMax, err := EvalLimit(s.SizeMaxExpr(), defaultSizeMax)
+			if err != nil {
+				return nil, fmt.Errorf("colf: struct %s size maximum: %s", s, err)
+			}
+			listMax, err := EvalLimit(s.ListMaxExpr(), defaultListMax)
+			if err != nil {
+				return nil, fmt.Errorf("colf: struct %s list maximum: %s", s, err)
+			}
+
+			doc := jsonStructSchema(s, sizeMax, listMax)
+			doc.Schema = "https://json-schema.org/draft/2020-12/schema"
+
+			buf, err := json.MarshalIndent(doc, "", "\t")
+			if err != nil {
+				return nil, err
+			}
+			sources[p.Name+"/"+s.Name+".schema.json"] = append(buf, '\n')
+		}
+	}
+	return sources, nil
+}
+
+// jsonStructSchema returns the object definition of s. Absent fields and null
+// values are accepted as the zero value, like package dynamic does.
+func jsonStructSchema(s *Struct, sizeMax, listMax int) *jsonSchema {
+	no := false
+	doc := &jsonSchema{
+		Title:                s.String(),
+		Description:          strings.TrimSpace(s.DocText("")),
+		Type:                 "object",
+		AdditionalProperties: &no,
+	}
+
+	for _, f := range s.Fields {
+		prop := jsonFieldSchema(f, sizeMax)
+		if f.TypeList {
+			if f.TypeRef != nil {
+				prop = jsonNullable(prop)
+			}
+			prop = &jsonSchema{
+				Type:     "array",
+				Items:    prop,
+				MaxItems: listMax,
+			}
+		}
+		prop = jsonNullable(prop)
+		prop.Description = strings.TrimSpace(f.DocText(""))
+
+		doc.Properties = append(doc.Properties, jsonProperty{f.Name, prop})
+	}
+	return doc
+}
+
+// jsonFieldSchema returns the value definition of a single f element.
+func jsonFieldSchema(f *Field, sizeMax int) *jsonSchema {
+	switch f.Type {
+	case "bool":
+		return &jsonSchema{Type: "boolean"}
+	case "uint8":
+		return &jsonSchema{Type: "integer", Minimum: "0", Maximum: "255"}
+	case "uint16":
+		return &jsonSchema{Type: "integer", Minimum: "0", Maximum: "65535"}
+	case "uint32":
+		return &jsonSchema{Type: "integer", Minimum: "0", Maximum: "4294967295"}
+	case "int32":
+		return &jsonSchema{Type: "integer", Minimum: "-2147483648", Maximum: "2147483647"}
+	case "uint64":
+		// JSON numbers are limited to Number.MAX_SAFE_INTEGER.
+		return &jsonSchema{AnyOf: []*jsonSchema{
+			{Type: "string", Pattern: "^" + jsonDecimalPattern("18446744073709551615") + "$"},
+			{Type: "integer", Minimum: "0", Maximum: "9007199254740991"},
+		}}
+	case "int64":
+		return &jsonSchema{AnyOf: []*jsonSchema{
+			{Type: "string", Pattern: `^(\+?` + jsonDecimalPattern("9223372036854775807") + "|-" + jsonDecimalPattern("9223372036854775808") + ")$"},
+			{Type: "integer", Minimum: "-9007199254740991", Maximum: "9007199254740991"},
+		}}
+	case "float32":
+		return &jsonSchema{AnyOf: []*jsonSchema{
+			{Type: "number", Minimum: "-3.4028234663852886e+38", Maximum: "3.4028234663852886e+38"},
+			{Enum: []interface{}{"NaN", "Infinity", "-Infinity"}},
+		}}
+	case "float64":
+		return &jsonSchema{AnyOf: []*jsonSchema{
+			{Type: "number"},
+			{Enum: []interface{}{"NaN", "Infinity", "-Infinity"}},
+		}}
+	case "timestamp":
+		// RFC 3339, plus the years beyond 9999 and before 0 from Go
+		return &jsonSchema{Type: "string", Pattern: `^-?[0-9]{4,}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]{1,9})?(Z|[+-][0-9]{2}:[0-9]{2})$`}
+	case "text":
+		return &jsonSchema{Type: "string", MaxLength: sizeMax}
+	case "binary":
+		return &jsonSchema{Type: "string", ContentEncoding: "base64", MaxLength: (sizeMax + 2) / 3 * 4}
+	default:
+		return &jsonSchema{Ref: jsonSchemaRef(f.Struct, f.TypeRef)}
+	}
+}
+
+// jsonDecimalPattern returns a regular expression which matches the decimal
+// notation of the integers from zero up to max, with optional leading zeros,
+// like strconv.ParseUint accepts.
+func jsonDecimalPattern(max string) string {
+	var alts []string
+	if len(max) > 1 {
+		alts = append(alts, fmt.Sprintf("[0-9]{1,%d}", len(max)-1))
+	}
+	for i := 0; i < len(max); i++ {
+		if max[i] == '0' {
+			continue
+		}
+		alt := max[:i]
+		if max[i] == '1' {
+			alt += "0"
+		} else {
+			alt += fmt.Sprintf("[0-%c]", max[i]-1)
+		}
+		switch n := len(max) - i - 1; {
+		case n == 1:
+			alt += "[0-9]"
+		case n > 1:
+			alt += fmt.Sprintf("[0-9]{%d}", n)
+		}
+		alts = append(alts, alt)
+	}
+	alts = append(alts, max)
+	return "0*(" + strings.Join(alts, "|") + ")"
+}
+
+// jsonSchemaRef returns the reference to the schema of target from within the
+// schema of s.
+func jsonSchemaRef(s, target *Struct) string {
+	if s == target {
+		return "#"
+	}
+	if s.Pkg == target.Pkg {
+		return target.Name + ".schema.json"
+	}
+	rel := strings.Repeat("../", strings.Count(s.Pkg.Name, "/")+1)
+	return path.Join(rel, target.Pkg.Name, target.Name+".schema.json")
+}
+
+// jsonNullable returns a definition which also accepts null.
+func jsonNullable(doc *jsonSchema) *jsonSchema {
+	switch {
+	case doc.AnyOf != nil:
+		doc.AnyOf = append(doc.AnyOf, &jsonSchema{Type: "null"})
+		return doc
+	case doc.Type != nil:
+		doc.Type = []string{doc.Type.(string), "null"}
+		return doc
+	default:
+		return &jsonSchema{AnyOf: []*jsonSchema{doc, {Type: "null"}}}
+	}
+}
@@ -0,0 +1,260 @@
+package colfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestJSONDecimalPattern(t *testing.T) {
+	for _, max := range []uint64{0, 1, 9, 10, 255, 1000, 4096} {
+		pattern := regexp.MustCompile("^" + jsonDecimalPattern(strconv.FormatUint(max, 10)) + "$")
+		for x := uint64(0); x < 5000; x++ {
+			for _, s := range []string{strconv.FormatUint(x, 10), fmt.Sprintf("%05d", x)} {
+				if got, want := pattern.MatchString(s), x <= max; got != want {
+					t.Errorf("max %d: got match %t for %q, want %t", max, got, s, want)
+				}
+			}
+		}
+	}
+}
+
+// jsonSchemaValidator checks instances against the output of
+// JSONSchemaSources. Only the keywords in use are implemented.
+type jsonSchemaValidator map[string]interface{}
+
+func newJSONSchemaValidator(t *testing.T, schemas ...string) jsonSchemaValidator {
+	packages, err := ParseFiles(schemas)
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+	sources, err := JSONSchemaSources(packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := make(jsonSchemaValidator)
+	for p, src := range sources {
+		d := json.NewDecoder(strings.NewReader(string(src)))
+		d.UseNumber()
+		var doc interface{}
+		if err := d.Decode(&doc); err != nil {
+			t.Fatalf("%s: %s", p, err)
+		}
+		v[p] = doc
+	}
+	return v
+}
+
+// Validate returns the first violation of the instance JSON against the
+// schema in file, if any.
+func (v jsonSchemaValidator) Validate(file, instance string) error {
+	d := json.NewDecoder(strings.NewReader(instance))
+	d.UseNumber()
+	var x interface{}
+	if err := d.Decode(&x); err != nil {
+		return err
+	}
+	return v.validate(file, v[file], x, "")
+}
+
+func (v jsonSchemaValidator) validate(file string, schema, x interface{}, at string) error {
+	doc, ok := schema.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: schema %#v is not an object", file, schema)
+	}
+
+	if ref, ok := doc["$ref"].(string); ok {
+		if ref != "#" {
+			file = path.Join(path.Dir(file), ref)
+		}
+		root, ok := v[file]
+		if !ok {
+			return fmt.Errorf("%s: unresolved reference %q", at, ref)
+		}
+		return v.validate(file, root, x, at)
+	}
+
+	if alts, ok := doc["anyOf"].([]interface{}); ok {
+		var errs []string
+		for _, alt := range alts {
+			err := v.validate(file, alt, x, at)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no match in anyOf: %s", at, strings.Join(errs, "; "))
+	}
+
+	if types, ok := doc["type"]; ok {
+		if s, ok := types.(string); ok {
+			types = []interface{}{s}
+		}
+		var match bool
+		for _, name := range types.([]interface{}) {
+			if jsonSchemaTypeMatch(name.(string), x) {
+				match = true
+			}
+		}
+		if !match {
+			return fmt.Errorf("%s: %#v is not of type %v", at, x, types)
+		}
+	}
+
+	if enum, ok := doc["enum"].([]interface{}); ok {
+		var match bool
+		for _, e := range enum {
+			if e == x {
+				match = true
+			}
+		}
+		if !match {
+			return fmt.Errorf("%s: %#v not in enum %v", at, x, enum)
+		}
+	}
+
+	switch x := x.(type) {
+	case string:
+		if p, ok := doc["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(x) {
+			return fmt.Errorf("%s: %q does not match pattern %q", at, x, p)
+		}
+		if n, ok := doc["maxLength"].(json.Number); ok && utf8.RuneCountInString(x) > jsonSchemaInt(n) {
+			return fmt.Errorf("%s: %q exceeds maxLength %s", at, x, n)
+		}
+
+	case json.Number:
+		r, _ := new(big.Rat).SetString(string(x))
+		if n, ok := doc["minimum"].(json.Number); ok {
+			if min, _ := new(big.Rat).SetString(string(n)); r.Cmp(min) < 0 {
+				return fmt.Errorf("%s: %s below minimum %s", at, x, n)
+			}
+		}
+		if n, ok := doc["maximum"].(json.Number); ok {
+			if max, _ := new(big.Rat).SetString(string(n)); r.Cmp(max) > 0 {
+				return fmt.Errorf("%s: %s above maximum %s", at, x, n)
+			}
+		}
+
+	case []interface{}:
+		if n, ok := doc["maxItems"].(json.Number); ok && len(x) > jsonSchemaInt(n) {
+			return fmt.Errorf("%s: %d items exceed maxItems %s", at, len(x), n)
+		}
+		if items, ok := doc["items"]; ok {
+			for i, e := range x {
+				if err := v.validate(file, items, e, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case map[string]interface{}:
+		props, _ := doc["properties"].(map[string]interface{})
+		for name, e := range x {
+			prop, ok := props[name]
+			if !ok {
+				if doc["additionalProperties"] == false {
+					return fmt.Errorf("%s: additional property %q", at, name)
+				}
+				continue
+			}
+			if err := v.validate(file, prop, e, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonSchemaTypeMatch(name string, x interface{}) bool {
+	switch x := x.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case []interface{}:
+		return name == "array"
+	case map[string]interface{}:
+		return name == "object"
+	case json.Number:
+		if name == "number" {
+			return true
+		}
+		r, ok := new(big.Rat).SetString(string(x))
+		return name == "integer" && ok && r.IsInt()
+	}
+	return false
+}
+
+func jsonSchemaInt(n json.Number) int {
+	i, err := strconv.Atoi(string(n))
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+func TestJSONSchemaInstances(t *testing.T) {
+	v := newJSONSchemaValidator(t, goldenSchemas...)
+
+	golden := []struct {
+		file     string
+		instance string
+		valid    bool
+	}{
+		{"gen/o.schema.json", `{}`, true},
+		{"gen/o.schema.json", `{"b":null,"u32":null,"s":null,"os":null}`, true},
+		{"gen/o.schema.json", `{"b":true,"u8":255,"u16":65535,"u32":4294967295,"i32":-2147483648}`, true},
+		{"gen/o.schema.json", `{"u64":"18446744073709551615","i64":"-9223372036854775808"}`, true},
+		{"gen/o.schema.json", `{"u64":"00018446744073709551615","i64":"+9223372036854775807"}`, true},
+		{"gen/o.schema.json", `{"u64":9007199254740991,"i64":-9007199254740991}`, true},
+		{"gen/o.schema.json", `{"f32":3.4028234663852886e+38,"f64":"-Infinity","f32s":["NaN",1]}`, true},
+		{"gen/o.schema.json", `{"t":"2006-01-02T15:04:05.999999999+07:00"}`, true},
+		{"gen/o.schema.json", `{"t":"-10000-01-01T00:00:00Z"}`, true},
+		{"gen/o.schema.json", `{"s":"π","a":"AAE=","ss":["x"],"as":[""]}`, true},
+		{"gen/o.schema.json", `{"o":{"o":{"b":true}},"os":[null,{"u8":1}]}`, true},
+
+		{"gen/o.schema.json", `[]`, false},
+		{"gen/o.schema.json", `{"x":1}`, false},
+		{"gen/o.schema.json", `{"b":1}`, false},
+		{"gen/o.schema.json", `{"u8":256}`, false},
+		{"gen/o.schema.json", `{"u16":-1}`, false},
+		{"gen/o.schema.json", `{"i32":1.5}`, false},
+		{"gen/o.schema.json", `{"u64":"18446744073709551616"}`, false},
+		{"gen/o.schema.json", `{"u64":"99999999999999999999"}`, false},
+		{"gen/o.schema.json", `{"u64":"+1"}`, false},
+		{"gen/o.schema.json", `{"u64":"-1"}`, false},
+		{"gen/o.schema.json", `{"u64":9007199254740992}`, false},
+		{"gen/o.schema.json", `{"i64":"9999999999999999999"}`, false},
+		{"gen/o.schema.json", `{"i64":"9223372036854775808"}`, false},
+		{"gen/o.schema.json", `{"i64":"-9223372036854775809"}`, false},
+		{"gen/o.schema.json", `{"i64":""}`, false},
+		{"gen/o.schema.json", `{"f32":3.5e38}`, false},
+		{"gen/o.schema.json", `{"f64":"nan"}`, false},
+		{"gen/o.schema.json", `{"t":"2006-01-02"}`, false},
+		{"gen/o.schema.json", `{"ss":[null]}`, false},
+		{"gen/o.schema.json", `{"o":{"u8":-1}}`, false},
+		{"gen/o.schema.json", `{"os":[{"x":true}]}`, false},
+
+		{"void/class.schema.json", `{"extends":{"throw":[{}]},"public":[{"try":["x"]}]}`, true},
+		{"void/class.schema.json", `{"public":[{"try":[1]}]}`, false},
+		{"void/int.schema.json", `{"finally":[{"extends":{"extends":1}}]}`, false},
+	}
+	for _, gold := range golden {
+		err := v.Validate(gold.file, gold.instance)
+		if gold.valid && err != nil {
+			t.Errorf("%s %s: %s", gold.file, gold.instance, err)
+		}
+		if !gold.valid && err == nil {
+			t.Errorf("%s %s: passed validation", gold.file, gold.instance)
+		}
+	}
+}
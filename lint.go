@@ -0,0 +1,262 @@
+package colfer
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/pascaldekloe/name"
+)
+
+// Lint rule identifiers.
+const (
+	// LintKeyword flags names which collide with a reserved word.
+	LintKeyword = "keyword"
+	// LintCase flags names which differ only by case.
+	LintCase = "case"
+	// LintDoc flags data structures without documentation.
+	LintDoc = "doc"
+	// LintRecursion flags reference cycles without lists.
+	LintRecursion = "recursion"
+	// LintJSInt flags 64-bit integers, which exceed JavaScript numbers.
+	LintJSInt = "js-int"
+)
+
+// Finding is a lint result.
+type Finding struct {
+	// File is the schema filename.
+	File string `json:"file"`
+	// Pos is the location of the subject in the schema file.
+	Pos token.Position `json:"pos"`
+	// Subject is the qualified name of the definition.
+	Subject string `json:"subject"`
+	// Rule is the lint rule identifier.
+	Rule string `json:"rule"`
+	// Message is the description.
+	Message string `json:"message"`
+}
+
+// String returns the finding in a single line.
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.File, f.Pos.Line, f.Pos.Column, f.Subject, f.Message, f.Rule)
+}
+
+// Lint checks the schema definitions for style and portability issues. The
+// code generators do compensate for most of them, at the expense of the
+// naming consistency amongst languages.
+func Lint(packages Packages) []*Finding {
+	var findings []*Finding
+
+	for _, p := range packages {
+		findings = append(findings, lintPackage(p)...)
+
+		for _, s := range p.Structs {
+			if len(s.Docs) == 0 {
+				findings = append(findings, &Finding{
+					File:    s.SchemaFile,
+					Pos:     s.Pos,
+					Subject: s.String(),
+					Rule:    LintDoc,
+					Message: "exported data structure without documentation",
+				})
+			}
+
+			for _, f := range s.Fields {
+				findings = append(findings, lintField(f)...)
+			}
+			findings = append(findings, lintFieldNames(s)...)
+		}
+		findings = append(findings, lintStructNames(p)...)
+	}
+
+	return append(findings, lintRecursion(packages)...)
+}
+
+func lintPackage(p *Package) []*Finding {
+	var langs []string
+
+	segs := strings.Split(p.Name, "/")
+	if token.Lookup(segs[len(segs)-1]).IsKeyword() {
+		langs = append(langs, "Go")
+	}
+	for _, seg := range segs {
+		if IsJavaKeyword(seg) {
+			langs = append(langs, "Java")
+			break
+		}
+	}
+	if IsECMAKeyword(strings.Replace(p.Name, "/", "_", -1)) {
+		langs = append(langs, "JavaScript")
+	}
+
+	if len(langs) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("package name is a reserved word in %s", joinLangs(langs))
+	if langs[0] == "Go" {
+		msg += "; the Go code does not compile"
+	}
+	file := ""
+	if len(p.SchemaFiles) != 0 {
+		file = p.SchemaFiles[0]
+	}
+	return []*Finding{{File: file, Pos: p.Pos, Subject: p.Name, Rule: LintKeyword, Message: msg}}
+}
+
+func lintField(f *Field) []*Finding {
+	var findings []*Finding
+
+	var langs []string
+	if IsCKeyword(name.SnakeCase(f.Name)) {
+		langs = append(langs, "C")
+	}
+	if IsJavaKeyword(f.Name) {
+		langs = append(langs, "Java")
+	}
+	if IsECMAKeyword(f.Name) {
+		langs = append(langs, "JavaScript")
+	}
+	if len(langs) != 0 {
+		findings = append(findings, &Finding{
+			File:    f.Struct.SchemaFile,
+			Pos:     f.Pos,
+			Subject: f.String(),
+			Rule:    LintKeyword,
+			Message: fmt.Sprintf("field name is a reserved word in %s, which get an underscore suffix", joinLangs(langs)),
+		})
+	}
+
+	if !f.TypeList && (f.Type == "uint64" || f.Type == "int64") {
+		findings = append(findings, &Finding{
+			File:    f.Struct.SchemaFile,
+			Pos:     f.Pos,
+			Subject: f.String(),
+			Rule:    LintJSInt,
+			Message: fmt.Sprintf("%s values beyond Number.MAX_SAFE_INTEGER fail in JavaScript", f.Type),
+		})
+	}
+
+	return findings
+}
+
+func lintStructNames(p *Package) []*Finding {
+	var findings []*Finding
+	var names []string
+	for _, s := range p.Structs {
+		if msg := caseCollision(names, s.Name); msg != "" {
+			findings = append(findings, &Finding{
+				File:    s.SchemaFile,
+				Pos:     s.Pos,
+				Subject: s.String(),
+				Rule:    LintCase,
+				Message: msg,
+			})
+		}
+		names = append(names, s.Name)
+	}
+	return findings
+}
+
+func lintFieldNames(s *Struct) []*Finding {
+	var findings []*Finding
+	var names []string
+	for _, f := range s.Fields {
+		if msg := caseCollision(names, f.Name); msg != "" {
+			findings = append(findings, &Finding{
+				File:    s.SchemaFile,
+				Pos:     f.Pos,
+				Subject: f.String(),
+				Rule:    LintCase,
+				Message: msg,
+			})
+		}
+		names = append(names, f.Name)
+	}
+	return findings
+}
+
+// caseCollision returns a description when s conflicts with any of the
+// previous names, in order of severity.
+func caseCollision(prevs []string, s string) string {
+	for _, prev := range prevs {
+		if strings.Title(prev) == strings.Title(s) {
+			return fmt.Sprintf("name collides with %q in title case; the Go and Java code does not compile", prev)
+		}
+	}
+	for _, prev := range prevs {
+		if name.SnakeCase(prev) == name.SnakeCase(s) {
+			return fmt.Sprintf("name collides with %q in snake case; the C code does not compile", prev)
+		}
+	}
+	for _, prev := range prevs {
+		if strings.EqualFold(prev, s) {
+			return fmt.Sprintf("name differs only by case from %q", prev)
+		}
+	}
+	return ""
+}
+
+// lintRecursion reports each reference cycle without lists once, on the
+// first struct in order of appearance.
+func lintRecursion(packages Packages) []*Finding {
+	order := make(map[*Struct]int)
+	for _, p := range packages {
+		for _, s := range p.Structs {
+			order[s] = len(order)
+		}
+	}
+
+	var findings []*Finding
+	for _, p := range packages {
+		for _, s := range p.Structs {
+			path := refPath(s, s, order[s], order, make(map[*Struct]bool))
+			if path == nil {
+				continue
+			}
+
+			names := []string{s.String()}
+			for _, f := range path {
+				names = append(names, f.TypeRef.String())
+			}
+			findings = append(findings, &Finding{
+				File:    s.SchemaFile,
+				Pos:     s.Pos,
+				Subject: s.String(),
+				Rule:    LintRecursion,
+				Message: fmt.Sprintf("reference cycle %s without lists; the nesting depth is bounded by the size limit only", strings.Join(names, " → ")),
+			})
+		}
+	}
+	return findings
+}
+
+// refPath returns the fields which reference from s to target without lists.
+// Structs which appear before target, conform order, are excluded, such that
+// each cycle is found once.
+func refPath(s, target *Struct, min int, order map[*Struct]int, visited map[*Struct]bool) []*Field {
+	visited[s] = true
+
+	for _, f := range s.Fields {
+		if f.TypeRef == nil || f.TypeList || order[f.TypeRef] < min {
+			continue
+		}
+		if f.TypeRef == target {
+			return []*Field{f}
+		}
+		if visited[f.TypeRef] {
+			continue
+		}
+		if path := refPath(f.TypeRef, target, min, order, visited); path != nil {
+			return append([]*Field{f}, path...)
+		}
+	}
+	return nil
+}
+
+// joinLangs returns an enumeration.
+func joinLangs(langs []string) string {
+	if len(langs) == 1 {
+		return langs[0]
+	}
+	return strings.Join(langs[:len(langs)-1], ", ") + " and " + langs[len(langs)-1]
+}
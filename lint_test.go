@@ -0,0 +1,180 @@
+package colfer
+
+import "testing"
+
+// lintGolden is a Finding without the message.
+type lintGolden struct {
+	File, Subject, Rule string
+}
+
+func verifyFindings(t *testing.T, got []*Finding, want []lintGolden) {
+	for i, f := range got {
+		if i >= len(want) {
+			t.Errorf("got extra finding %s", f)
+			continue
+		}
+		if w := want[i]; f.File != w.File || f.Subject != w.Subject || f.Rule != w.Rule {
+			t.Errorf("finding %d: got %s, want file %q, subject %q and rule %q", i, f, w.File, w.Subject, w.Rule)
+		}
+		if f.Message == "" {
+			t.Errorf("finding %d: %s: no message", i, f)
+		}
+		if f.Pos.Line == 0 || f.Pos.Column == 0 {
+			t.Errorf("finding %d: %s: no position", i, f)
+		}
+	}
+	for _, w := range want[len(got):] {
+		t.Errorf("missing finding %s: %s [%s]", w.File, w.Subject, w.Rule)
+	}
+}
+
+func TestLintBreak(t *testing.T) {
+	packages, err := ParseFiles([]string{"testdata/break.colf", "testdata/break-refs.colf"})
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+
+	verifyFindings(t, Lint(packages), []lintGolden{
+		{"break.colf", "void", LintKeyword},
+		{"break.colf", "void.class.extends", LintKeyword},
+		{"break.colf", "void.class.public", LintKeyword},
+		{"break.colf", "void.int.throw", LintKeyword},
+		{"break.colf", "void.int.finally", LintKeyword},
+		{"break-refs.colf", "static", LintKeyword},
+		{"break-refs.colf", "static.int.try", LintKeyword},
+	})
+}
+
+func TestLintSources(t *testing.T) {
+	golden := []struct {
+		src  string
+		want []lintGolden
+	}{
+		{`package p
+
+// A is fine.
+type a struct {
+	n int32
+	s []a
+}
+`, nil},
+
+		{`package p
+
+// A has names which collide.
+type a struct {
+	fooBar  bool
+	FooBar  bool
+	foo_bar bool
+	FOOBAR  bool
+}
+`, []lintGolden{
+			{"p.colf", "p.a.FooBar", LintCase},
+			{"p.colf", "p.a.foo_bar", LintCase},
+			{"p.colf", "p.a.FOOBAR", LintCase},
+		}},
+
+		{`package p
+
+// A collides with B in title case.
+type a struct {}
+
+// B collides with A in title case.
+type A struct {}
+`, []lintGolden{
+			{"p.colf", "p.A", LintCase},
+		}},
+
+		{`package p
+
+type a struct {
+	class bool
+	count int64
+	sum   uint64
+	list  []a
+}
+`, []lintGolden{
+			{"p.colf", "p.a", LintDoc},
+			{"p.colf", "p.a.class", LintKeyword},
+			{"p.colf", "p.a.count", LintJSInt},
+			{"p.colf", "p.a.sum", LintJSInt},
+		}},
+
+		{`package p
+
+// A is a cycle on its own.
+type a struct {
+	self a
+}
+
+// B is part of a cycle with C.
+type b struct {
+	next c
+}
+
+// C is part of a cycle with B.
+type c struct {
+	back b
+	a    a
+}
+`, []lintGolden{
+			{"p.colf", "p.a", LintRecursion},
+			{"p.colf", "p.b", LintRecursion},
+		}},
+
+		{`package enum
+
+// A is fine.
+type a struct {}
+`, []lintGolden{
+			{"p.colf", "enum", LintKeyword},
+		}},
+	}
+
+	for _, g := range golden {
+		packages, err := ParseSources([]string{"dir/p.colf"}, map[string][]byte{"dir/p.colf": []byte(g.src)})
+		if err != nil {
+			t.Errorf("parse error: %s\n%s", err, g.src)
+			continue
+		}
+		verifyFindings(t, Lint(packages), g.want)
+	}
+}
+
+func TestLintMessages(t *testing.T) {
+	golden := []struct{ prev, name, want string }{
+		{"fooBar", "FooBar", `name collides with "fooBar" in title case; the Go and Java code does not compile`},
+		{"fooBar", "foo_bar", `name collides with "fooBar" in snake case; the C code does not compile`},
+		{"fooBar", "FOOBAR", `name differs only by case from "fooBar"`},
+		{"fooBar", "fooBaz", ""},
+	}
+	for _, g := range golden {
+		if got := caseCollision([]string{g.prev}, g.name); got != g.want {
+			t.Errorf("%q after %q: got %q, want %q", g.name, g.prev, got, g.want)
+		}
+	}
+
+	packages, err := ParseSources([]string{"p.colf"}, map[string][]byte{"p.colf": []byte(`package p
+
+// A is a cycle with B.
+type a struct {
+	b b
+}
+
+// B is a cycle with A.
+type b struct {
+	a a
+}
+`)})
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+	findings := Lint(packages)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	const want = "p.colf:4:6: p.a: reference cycle p.a → p.b → p.a without lists; the nesting depth is bounded by the size limit only [recursion]"
+	if got := findings[0].String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,553 @@
+// Package lsp implements a Language Server Protocol service for schema files.
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// datatypes are the built-in names.
+var datatypes = []string{"bool", "uint8", "uint16", "uint32", "uint64", "int32", "int64", "float32", "float64", "timestamp", "text", "binary"}
+
+// server is the session state.
+type server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// root is the workspace directory, if any.
+	root string
+	// docs has the content of open documents, keyed by their path.
+	docs map[string][]byte
+	// published has the paths with diagnostics on the client.
+	published map[string]bool
+	// packages are from the last successful parse, if any.
+	packages colfer.Packages
+
+	shutdown bool
+}
+
+// Serve runs a language server on the connection until the client exits.
+// The service provides diagnostics, go-to-definition, hover, completion and
+// formatting. All schema files in the workspace are read as one, such that
+// references across packages resolve.
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		docs:      make(map[string][]byte),
+		published: make(map[string]bool),
+	}
+
+	for {
+		body, err := readMessage(s.in)
+		if err != nil {
+			if err == io.EOF {
+				return errors.New("lsp: connection closed without exit")
+			}
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			if err := s.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("lsp: exit without shutdown")
+			}
+			return nil
+		}
+
+		if err := s.handle(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or a notification.
+func (s *server) handle(msg *message) error {
+	var result interface{}
+	var err error
+	switch msg.Method {
+	case "initialize":
+		result, err = s.initialize(msg.Params)
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		err = s.didOpen(msg.Params)
+	case "textDocument/didChange":
+		err = s.didChange(msg.Params)
+	case "textDocument/didClose":
+		err = s.didClose(msg.Params)
+	case "textDocument/didSave":
+		err = s.publish()
+	case "textDocument/hover":
+		result, err = s.hover(msg.Params)
+	case "textDocument/definition":
+		result, err = s.definition(msg.Params)
+	case "textDocument/completion":
+		result, err = s.completion(msg.Params)
+	case "textDocument/formatting":
+		result, err = s.formatting(msg.Params)
+	default:
+		if msg.ID == nil {
+			return nil // notifications may be ignored
+		}
+		return s.replyError(msg.ID, codeMethodNotFound, "lsp: method "+msg.Method+" not supported")
+	}
+
+	if err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok || isSyntaxError(err) {
+			if msg.ID == nil {
+				return nil
+			}
+			return s.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return err
+	}
+	if msg.ID == nil {
+		return nil
+	}
+	return s.reply(msg.ID, result)
+}
+
+func isSyntaxError(err error) bool {
+	_, ok := err.(*json.SyntaxError)
+	return ok
+}
+
+func (s *server) reply(id *json.RawMessage, result interface{}) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	rawResult := json.RawMessage(raw)
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: id, Result: &rawResult})
+}
+
+func (s *server) replyError(id *json.RawMessage, code int, msg string) error {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: id, Error: &responseError{code, msg}})
+}
+
+func (s *server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *server) initialize(params json.RawMessage) (interface{}, error) {
+	var p initializeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	uri := p.RootURI
+	if uri == "" && len(p.WorkspaceFolders) != 0 {
+		uri = p.WorkspaceFolders[0].URI
+	}
+	switch {
+	case uri != "":
+		if path, err := uriPath(uri); err == nil {
+			s.root = path
+		}
+	case p.RootPath != "":
+		s.root = p.RootPath
+	}
+
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":           2, // incremental
+			"hoverProvider":              true,
+			"definitionProvider":         true,
+			"completionProvider":         map[string]interface{}{"triggerCharacters": []string{"."}},
+			"documentFormattingProvider": true,
+		},
+		"serverInfo": map[string]string{"name": "colf"},
+	}, nil
+}
+
+func (s *server) didOpen(params json.RawMessage) error {
+	var p didOpenParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	path, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil // not for us
+	}
+	s.docs[path] = []byte(p.TextDocument.Text)
+	return s.publish()
+}
+
+func (s *server) didChange(params json.RawMessage) error {
+	var p didChangeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	path, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil // not for us
+	}
+
+	src := s.docs[path]
+	for _, change := range p.ContentChanges {
+		if change.Range == nil {
+			src = []byte(change.Text)
+			continue
+		}
+		start := positionOffset(src, change.Range.Start)
+		end := positionOffset(src, change.Range.End)
+		if end < start {
+			end = start
+		}
+		var buf bytes.Buffer
+		buf.Write(src[:start])
+		buf.WriteString(change.Text)
+		buf.Write(src[end:])
+		src = buf.Bytes()
+	}
+	s.docs[path] = src
+	return s.publish()
+}
+
+func (s *server) didClose(params json.RawMessage) error {
+	var p didCloseParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if path, err := uriPath(p.TextDocument.URI); err == nil {
+		delete(s.docs, path)
+	}
+	return s.publish()
+}
+
+// content returns the current state of a file.
+func (s *server) content(path string) []byte {
+	if src, ok := s.docs[path]; ok {
+		return src
+	}
+	src, _ := ioutil.ReadFile(path)
+	return src
+}
+
+// schemaFiles returns all schema files in the workspace, which includes the
+// directories of the open documents.
+func (s *server) schemaFiles() []string {
+	found := make(map[string]bool)
+	for path := range s.docs {
+		found[path] = true
+		matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.colf"))
+		for _, m := range matches {
+			found[m] = true
+		}
+	}
+	if s.root != "" {
+		filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+			switch {
+			case err != nil:
+				return nil // best effort
+			case info.IsDir() && path != s.root && strings.HasPrefix(info.Name(), "."):
+				return filepath.SkipDir
+			case !info.IsDir() && filepath.Ext(path) == ".colf":
+				found[path] = true
+			}
+			return nil
+		})
+	}
+
+	files := make([]string, 0, len(found))
+	for path := range found {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// load parses the workspace. The packages are retained on success.
+func (s *server) load() (colfer.Packages, error) {
+	packages, err := colfer.ParseSources(s.schemaFiles(), s.docs)
+	if err != nil {
+		return nil, err
+	}
+	s.packages = packages
+	return packages, nil
+}
+
+// publish sends the diagnostics of the workspace.
+func (s *server) publish() error {
+	diags := make(map[string][]diagnostic)
+	add := func(pos token.Position, severity int, msg string) {
+		src := s.content(pos.Filename)
+		start := pos.Offset
+		if start > len(src) {
+			start = len(src)
+		}
+		end := start
+		for end < len(src) && isIdentByte(src[end]) {
+			end++
+		}
+		diags[pos.Filename] = append(diags[pos.Filename], diagnostic{
+			Range:    textRange{offsetPosition(src, start), offsetPosition(src, end)},
+			Severity: severity,
+			Source:   "colf",
+			Message:  msg,
+		})
+	}
+
+	packages, err := s.load()
+	switch e := err.(type) {
+	case nil:
+		for _, f := range colfer.Lint(packages) {
+			add(f.Pos, severityWarning, f.Message+" ["+f.Rule+"]")
+		}
+	case scanner.ErrorList:
+		for _, e := range e {
+			add(e.Pos, severityError, e.Msg)
+		}
+	case *colfer.SchemaError:
+		add(e.Pos, severityError, strings.TrimPrefix(e.Msg, "colfer: "))
+	default:
+		return s.notify("window/logMessage", map[string]interface{}{"type": 1, "message": err.Error()})
+	}
+
+	var paths []string
+	for path := range s.published {
+		if _, ok := diags[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	for path := range diags {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	s.published = make(map[string]bool)
+	for _, path := range paths {
+		list := diags[path]
+		if list == nil {
+			list = []diagnostic{}
+		} else {
+			s.published[path] = true
+		}
+		err := s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+			URI:         pathURI(path),
+			Diagnostics: list,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// target is a schema element at a text position.
+type target struct {
+	// exactly one is set
+	s *colfer.Struct
+	f *colfer.Field
+	// typ flags the datatype of f, rather than its name.
+	typ bool
+
+	start, end int // byte offsets
+}
+
+// lookup returns the schema element at the text position.
+func (s *server) lookup(params json.RawMessage) (*target, []byte, error) {
+	var p textDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, nil, err
+	}
+	path, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, nil, nil
+	}
+	packages, err := s.load()
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	src := s.content(path)
+	offset := positionOffset(src, p.Position)
+	within := func(pos token.Position, name string) bool {
+		return pos.Filename == path && offset >= pos.Offset && offset <= pos.Offset+len(name)
+	}
+	for _, pkg := range packages {
+		for _, st := range pkg.Structs {
+			if within(st.Pos, st.Name) {
+				return &target{s: st, start: st.Pos.Offset, end: st.Pos.Offset + len(st.Name)}, src, nil
+			}
+			for _, f := range st.Fields {
+				if within(f.Pos, f.Name) {
+					return &target{f: f, start: f.Pos.Offset, end: f.Pos.Offset + len(f.Name)}, src, nil
+				}
+				if within(f.TypePos, f.Type) {
+					return &target{f: f, typ: true, start: f.TypePos.Offset, end: f.TypePos.Offset + len(f.Type)}, src, nil
+				}
+			}
+		}
+	}
+	return nil, src, nil
+}
+
+func (s *server) hover(params json.RawMessage) (interface{}, error) {
+	t, src, err := s.lookup(params)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	langs, structs, fields := colfer.Natives(s.packages)
+	var buf bytes.Buffer
+	writeNatives := func(natives []string) {
+		buf.WriteString("\n\n| Language | Declaration |\n|----------|-------------|\n")
+		for i, lang := range langs {
+			buf.WriteString("| " + lang + " | `" + natives[i] + "` |\n")
+		}
+	}
+	writeDocs := func(text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			buf.WriteString("\n\n")
+			buf.WriteString(text)
+		}
+	}
+
+	switch {
+	case t.s != nil:
+		buf.WriteString("struct `" + t.s.String() + "`")
+		writeDocs(t.s.DocText(""))
+		writeNatives(structs[t.s])
+
+	case t.typ && t.f.TypeRef != nil:
+		buf.WriteString("struct `" + t.f.TypeRef.String() + "`")
+		writeDocs(t.f.TypeRef.DocText(""))
+		writeNatives(structs[t.f.TypeRef])
+
+	case t.typ:
+		buf.WriteString("datatype `" + t.f.Type + "`")
+
+	default:
+		typ := t.f.Type
+		if t.f.TypeList {
+			typ = "[]" + typ
+		}
+		buf.WriteString("field `" + t.f.String() + "` " + typ)
+		writeDocs(t.f.DocText(""))
+		writeNatives(fields[t.f])
+	}
+
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: buf.String()},
+		Range:    &textRange{offsetPosition(src, t.start), offsetPosition(src, t.end)},
+	}, nil
+}
+
+func (s *server) definition(params json.RawMessage) (interface{}, error) {
+	t, _, err := s.lookup(params)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	var st *colfer.Struct
+	switch {
+	case t.s != nil:
+		st = t.s
+	case t.typ && t.f.TypeRef != nil:
+		st = t.f.TypeRef
+	default:
+		return nil, nil
+	}
+
+	src := s.content(st.Pos.Filename)
+	return []location{{
+		URI: pathURI(st.Pos.Filename),
+		Range: textRange{
+			offsetPosition(src, st.Pos.Offset),
+			offsetPosition(src, st.Pos.Offset+len(st.Name)),
+		},
+	}}, nil
+}
+
+func (s *server) completion(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	path, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, nil
+	}
+	// The packages from before remain when the document
+	// does not parse while typing.
+	s.load()
+
+	// structs in the same package need no qualifier
+	var pkgName string
+	fileAST, err := parser.ParseFile(token.NewFileSet(), path, s.content(path), parser.PackageClauseOnly)
+	if err == nil {
+		pkgName = fileAST.Name.Name
+	}
+
+	items := make([]completionItem, 0, len(datatypes))
+	for _, name := range datatypes {
+		items = append(items, completionItem{Label: name, Kind: completionKeyword, Detail: "datatype"})
+	}
+	for _, pkg := range s.packages {
+		for _, st := range pkg.Structs {
+			label := st.String()
+			if pkg.Name == pkgName {
+				label = st.Name
+			}
+			items = append(items, completionItem{
+				Label:         label,
+				Kind:          completionStruct,
+				Detail:        "struct " + st.String(),
+				Documentation: strings.TrimSpace(st.DocText("")),
+			})
+		}
+	}
+	return items, nil
+}
+
+func (s *server) formatting(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	path, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, nil
+	}
+
+	src := s.content(path)
+	clean, err := colfer.FormatSource(src)
+	if err != nil || bytes.Equal(src, clean) {
+		return []textEdit{}, nil
+	}
+	return []textEdit{{
+		Range:   textRange{position{}, offsetPosition(src, len(src))},
+		NewText: string(clean),
+	}}, nil
+}
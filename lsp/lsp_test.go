@@ -0,0 +1,209 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// client is the test side of a session.
+type client struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	nextID int
+	done   chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{t: t, w: clientOut, r: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		c.done <- Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+	return c
+}
+
+func (c *client) send(v interface{}) {
+	if err := writeMessage(c.w, v); err != nil {
+		c.t.Fatal("send:", err)
+	}
+}
+
+// receive returns the next message from the server.
+func (c *client) receive() map[string]interface{} {
+	body, err := readMessage(c.r)
+	if err != nil {
+		c.t.Fatal("receive:", err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal("receive:", err)
+	}
+	return msg
+}
+
+// call sends a request and returns the result.
+func (c *client) call(method string, params interface{}) interface{} {
+	c.nextID++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	msg := c.receive()
+	if msg["error"] != nil {
+		c.t.Fatalf("%s: got error %v", method, msg["error"])
+	}
+	return msg["result"]
+}
+
+// diagnostics sends a notification and returns the messages published per URI.
+func (c *client) diagnostics(method string, params interface{}, uris ...string) map[string][]string {
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+	got := make(map[string][]string)
+	for range uris {
+		msg := c.receive()
+		if msg["method"] != "textDocument/publishDiagnostics" {
+			c.t.Fatalf("%s: got message %v, want diagnostics", method, msg)
+		}
+		p := msg["params"].(map[string]interface{})
+		list := []string{}
+		for _, d := range p["diagnostics"].([]interface{}) {
+			list = append(list, d.(map[string]interface{})["message"].(string))
+		}
+		got[p["uri"].(string)] = list
+	}
+	return got
+}
+
+func TestSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colf-lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	aPath := filepath.Join(dir, "a", "a.colf")
+	bPath := filepath.Join(dir, "b", "b.colf")
+	for _, d := range []string{filepath.Dir(aPath), filepath.Dir(bPath)} {
+		if err := os.Mkdir(d, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const aSrc = "package a\n\n// O has a doc.\ntype o struct {\n\tref b.p\n\tcount uint32\n}\n"
+	const bSrc = "package b\n\n// P is referenced.\ntype p struct {\n\tname text\n}\n"
+	if err := ioutil.WriteFile(aPath, []byte(aSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(bPath, []byte(bSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+	aURI, bURI := pathURI(aPath), pathURI(bPath)
+
+	c := newClient(t)
+	c.call("initialize", map[string]interface{}{"rootUri": pathURI(dir)})
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": "initialized", "params": map[string]interface{}{}})
+
+	// open with an unknown datatype
+	broken := strings.Replace(aSrc, "b.p", "b.q", 1)
+	got := c.diagnostics("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": aURI, "languageId": "colfer", "version": 1, "text": broken},
+	}, aURI)
+	if len(got[aURI]) != 1 || !strings.Contains(got[aURI][0], `unknown datatype "b.q"`) {
+		t.Errorf("got diagnostics %q, want unknown datatype", got)
+	}
+
+	// fix the datatype with an incremental change
+	got = c.diagnostics("textDocument/didChange", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": aURI, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{
+			"range": map[string]interface{}{
+				"start": map[string]int{"line": 4, "character": 7},
+				"end":   map[string]int{"line": 4, "character": 8},
+			},
+			"text": "p",
+		}},
+	}, aURI)
+	if len(got[aURI]) != 0 {
+		t.Errorf("got diagnostics %q after fix, want none", got)
+	}
+
+	// datatype reference at line 4 "\tref b.p"
+	refPos := map[string]interface{}{
+		"textDocument": map[string]string{"uri": aURI},
+		"position":     map[string]int{"line": 4, "character": 7},
+	}
+	defs, ok := c.call("textDocument/definition", refPos).([]interface{})
+	if !ok || len(defs) != 1 {
+		t.Fatalf("got definition %v, want 1 location", defs)
+	}
+	def := defs[0].(map[string]interface{})
+	if def["uri"] != bURI {
+		t.Errorf("got definition URI %v, want %q", def["uri"], bURI)
+	}
+	start := def["range"].(map[string]interface{})["start"].(map[string]interface{})
+	if start["line"] != 3.0 || start["character"] != 5.0 {
+		t.Errorf("got definition start %v, want line 3, character 5", start)
+	}
+
+	h := c.call("textDocument/hover", refPos).(map[string]interface{})
+	text := h["contents"].(map[string]interface{})["value"].(string)
+	if !strings.HasPrefix(text, "struct `b.p`") || !strings.Contains(text, "P is referenced.") || !strings.Contains(text, "| Go | `b.P` |") {
+		t.Errorf("got hover text %q", text)
+	}
+
+	// field name at line 5 "\tcount uint32"
+	h = c.call("textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]string{"uri": aURI},
+		"position":     map[string]int{"line": 5, "character": 2},
+	}).(map[string]interface{})
+	text = h["contents"].(map[string]interface{})["value"].(string)
+	if !strings.HasPrefix(text, "field `a.o.count` uint32") || !strings.Contains(text, "| Go | `Count uint32` |") {
+		t.Errorf("got field hover text %q", text)
+	}
+
+	items := c.call("textDocument/completion", refPos).([]interface{})
+	labels := make(map[string]bool)
+	for _, item := range items {
+		labels[item.(map[string]interface{})["label"].(string)] = true
+	}
+	for _, want := range []string{"timestamp", "binary", "o", "b.p"} {
+		if !labels[want] {
+			t.Errorf("completion %q missing in %v", want, labels)
+		}
+	}
+
+	edits := c.call("textDocument/formatting", map[string]interface{}{
+		"textDocument": map[string]string{"uri": aURI},
+		"options":      map[string]interface{}{"tabSize": 8, "insertSpaces": false},
+	}).([]interface{})
+	if len(edits) != 1 {
+		t.Fatalf("got %d formatting edits, want 1", len(edits))
+	}
+	if got := edits[0].(map[string]interface{})["newText"]; !strings.Contains(got.(string), "\tref   b.p\n\tcount uint32\n") {
+		t.Errorf("got formatted text %q", got)
+	}
+
+	c.call("shutdown", nil)
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": "exit"})
+	if err := <-c.done; err != nil {
+		t.Error("serve error:", err)
+	}
+}
+
+func TestPositionOffset(t *testing.T) {
+	src := []byte("a\n\U0001F600b€c\n")
+	for _, offset := range []int{0, 1, 2, 6, 7, 10, 11, 12} {
+		p := offsetPosition(src, offset)
+		if got := positionOffset(src, p); got != offset {
+			t.Errorf("offset %d: got %d via %+v", offset, got, p)
+		}
+	}
+	if p := offsetPosition(src, 7); p != (position{Line: 1, Character: 3}) {
+		t.Errorf("got %+v for offset 7, want line 1, character 3", p)
+	}
+}
@@ -0,0 +1,233 @@
+// Package plugin implements code generation by external executables.
+//
+// For a language without built-in support, colf(1) runs the executable named
+// "colf-gen-" plus the language in lower case, as found in the directories of
+// the PATH environment variable. The plugin receives a protocol.Request in
+// Colfer format on the standard input, and it replies with a
+// protocol.Response in Colfer format on the standard output. Any output on
+// the standard error is passed through. A non-zero exit status or an Error in
+// the response aborts the compilation.
+//
+// Plugins written in Go can use Main to get the schema definitions in their
+// resolved form.
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+	"github.com/pascaldekloe/colfer/plugin/protocol"
+)
+
+// Executable returns the file name of the plugin for lang.
+func Executable(lang string) string {
+	return "colf-gen-" + strings.ToLower(lang)
+}
+
+// Lookup returns the path of the plugin executable for lang.
+func Lookup(lang string) (string, error) {
+	return exec.LookPath(Executable(lang))
+}
+
+// Run executes the plugin for lang and returns the generated files.
+func Run(lang string, packages colfer.Packages) (colfer.Sources, error) {
+	file, err := Lookup(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := NewRequest(lang, packages).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("colf: plugin request: %s", err)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(file)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("colf: plugin %s: %s", file, err)
+	}
+
+	var resp protocol.Response
+	if err := resp.UnmarshalBinary(out.Bytes()); err != nil {
+		return nil, fmt.Errorf("colf: plugin %s: malformed response: %s", file, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("colf: plugin %s: %s", file, resp.Error)
+	}
+
+	sources := make(colfer.Sources, len(resp.Files))
+	for _, f := range resp.Files {
+		if f.Path == "" || path.IsAbs(f.Path) || path.Clean(f.Path) != f.Path || strings.HasPrefix(f.Path, "../") || f.Path == ".." {
+			return nil, fmt.Errorf("colf: plugin %s: illegal file path %q", file, f.Path)
+		}
+		if _, ok := sources[f.Path]; ok {
+			return nil, fmt.Errorf("colf: plugin %s: duplicate file path %q", file, f.Path)
+		}
+		sources[f.Path] = f.Content
+	}
+	return sources, nil
+}
+
+// NewRequest returns the serial form of packages.
+func NewRequest(lang string, packages colfer.Packages) *protocol.Request {
+	req := &protocol.Request{Lang: lang}
+	for _, p := range packages {
+		pkg := &protocol.PackageDef{
+			Name:        p.Name,
+			Docs:        p.Docs,
+			SchemaFiles: p.SchemaFiles,
+			SizeMax:     p.SizeMax,
+			ListMax:     p.ListMax,
+			SuperClass:  p.SuperClass,
+			ZeroCopy:    p.ZeroCopy,
+			Reuse:       p.Reuse,
+			ValueTypes:  p.ValueTypes,
+		}
+		req.Packages = append(req.Packages, pkg)
+
+		for _, s := range p.Structs {
+			st := &protocol.StructDef{
+				Name:       s.Name,
+				Docs:       s.Docs,
+				SchemaFile: s.SchemaFile,
+				SizeMax:    s.SizeMax,
+				ListMax:    s.ListMax,
+			}
+			pkg.Structs = append(pkg.Structs, st)
+
+			for _, f := range s.Fields {
+				field := &protocol.FieldDef{
+					Index:    uint8(f.Index),
+					Name:     f.Name,
+					Docs:     f.Docs,
+					Datatype: f.Type,
+					TypeList: f.TypeList,
+				}
+				if f.TypeRef != nil {
+					field.TypeRef = f.TypeRef.String()
+				}
+				st.Fields = append(st.Fields, field)
+			}
+		}
+	}
+	return req
+}
+
+// Packages returns the schema definitions of req with their references
+// resolved.
+func Packages(req *protocol.Request) (colfer.Packages, error) {
+	var packages colfer.Packages
+	structs := make(map[string]*colfer.Struct)
+	for _, pkg := range req.Packages {
+		p := &colfer.Package{
+			Name:        pkg.Name,
+			Docs:        pkg.Docs,
+			SchemaFiles: pkg.SchemaFiles,
+			SizeMax:     pkg.SizeMax,
+			ListMax:     pkg.ListMax,
+			SuperClass:  pkg.SuperClass,
+			ZeroCopy:    pkg.ZeroCopy,
+			Reuse:       pkg.Reuse,
+			ValueTypes:  pkg.ValueTypes,
+		}
+		packages = append(packages, p)
+
+		for _, st := range pkg.Structs {
+			s := &colfer.Struct{
+				Pkg:        p,
+				Name:       st.Name,
+				Docs:       st.Docs,
+				SchemaFile: st.SchemaFile,
+				SizeMax:    st.SizeMax,
+				ListMax:    st.ListMax,
+			}
+			p.Structs = append(p.Structs, s)
+			structs[s.String()] = s
+
+			for _, field := range st.Fields {
+				s.Fields = append(s.Fields, &colfer.Field{
+					Struct:   s,
+					Index:    int(field.Index),
+					Name:     field.Name,
+					Docs:     field.Docs,
+					Type:     field.Datatype,
+					TypeList: field.TypeList,
+				})
+			}
+		}
+	}
+
+	for i, pkg := range req.Packages {
+		for j, st := range pkg.Structs {
+			for k, field := range st.Fields {
+				if field.TypeRef == "" {
+					continue
+				}
+				ref, ok := structs[field.TypeRef]
+				if !ok {
+					return nil, fmt.Errorf("colf: plugin request: unknown struct %q for field %s", field.TypeRef, packages[i].Structs[j].Fields[k])
+				}
+				packages[i].Structs[j].Fields[k].TypeRef = ref
+			}
+		}
+	}
+	return packages, nil
+}
+
+// Main runs a plugin with gen as the code generator. The process exits when
+// done.
+func Main(gen func(lang string, packages colfer.Packages) (colfer.Sources, error)) {
+	resp, err := serve(gen)
+	if err != nil {
+		resp = &protocol.Response{Error: err.Error()}
+	}
+
+	data, err := resp.MarshalBinary()
+	if err != nil {
+		os.Stderr.WriteString("colf plugin: response: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		os.Stderr.WriteString("colf plugin: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func serve(gen func(lang string, packages colfer.Packages) (colfer.Sources, error)) (*protocol.Response, error) {
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no request on standard input; run by colf(1)")
+	}
+	var req protocol.Request
+	if err := req.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("malformed request: %s", err)
+	}
+	packages, err := Packages(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := gen(req.Lang, packages)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(protocol.Response)
+	for _, path := range sources.Paths() {
+		resp.Files = append(resp.Files, &protocol.File{Path: path, Content: sources[path]})
+	}
+	return resp, nil
+}
@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/colfer"
+	"github.com/pascaldekloe/colfer/plugin/protocol"
+)
+
+// TestMain runs the test binary as a plugin when so requested by TestRun.
+func TestMain(m *testing.M) {
+	if os.Getenv("COLF_PLUGIN_TEST") != "" {
+		Main(listing)
+	}
+	os.Exit(m.Run())
+}
+
+// listing is a code generator with a summary per package.
+func listing(lang string, packages colfer.Packages) (colfer.Sources, error) {
+	sources := make(colfer.Sources)
+	for _, p := range packages {
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "%s %s\n", lang, p.Name)
+		for _, s := range p.Structs {
+			for _, f := range s.Fields {
+				fmt.Fprintf(&buf, "%s %d %s", f, f.Index, f.Type)
+				if f.TypeRef != nil {
+					fmt.Fprintf(&buf, " -> %s", f.TypeRef)
+				}
+				if f.TypeList {
+					buf.WriteString(" list")
+				}
+				buf.WriteByte('\n')
+			}
+		}
+		sources[p.Name+"/listing.txt"] = []byte(buf.String())
+	}
+	return sources, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	packages, err := colfer.ParseFiles([]string{"../testdata/test.colf", "../testdata/break-refs.colf"})
+	if err != nil {
+		t.Fatal("schema parse:", err)
+	}
+	want, err := listing("Go", packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewRequest("Go", packages).MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal:", err)
+	}
+	var req protocol.Request
+	if err := req.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal:", err)
+	}
+	restored, err := Packages(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := listing(req.Lang, restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d packages, want %d", len(got), len(want))
+	}
+	for path, b := range want {
+		if string(got[path]) != string(b) {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", path, got[path], b)
+		}
+	}
+	for i, p := range restored {
+		if p.SizeMax != packages[i].SizeMax || p.ListMax != packages[i].ListMax {
+			t.Errorf("package %s: got limits %q and %q, want %q and %q", p.Name, p.SizeMax, p.ListMax, packages[i].SizeMax, packages[i].ListMax)
+		}
+		for j, s := range p.Structs {
+			if got, want := strings.Join(s.Docs, "\n"), strings.Join(packages[i].Structs[j].Docs, "\n"); got != want {
+				t.Errorf("struct %s: got docs %q, want %q", s, got, want)
+			}
+		}
+	}
+}
+
+func TestUnknownRef(t *testing.T) {
+	req := &protocol.Request{Packages: []*protocol.PackageDef{{
+		Name: "a",
+		Structs: []*protocol.StructDef{{
+			Name:   "o",
+			Fields: []*protocol.FieldDef{{Name: "ref", Datatype: "b.p", TypeRef: "b.p"}},
+		}},
+	}}}
+	_, err := Packages(req)
+	if err == nil || !strings.Contains(err.Error(), `unknown struct "b.p"`) {
+		t.Errorf("got error %v, want unknown struct", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colf-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(exe, filepath.Join(dir, Executable("Test"))); err != nil {
+		t.Skip("no plugin executable:", err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+	defer os.Unsetenv("COLF_PLUGIN_TEST")
+	os.Setenv("COLF_PLUGIN_TEST", "1")
+
+	packages, err := colfer.ParseFiles([]string{"../testdata/test.colf"})
+	if err != nil {
+		t.Fatal("schema parse:", err)
+	}
+	got, err := Run("Test", packages)
+	if err != nil {
+		t.Fatal("run:", err)
+	}
+	want, err := listing("Test", packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := got.Paths(); len(paths) != 1 || paths[0] != "gen/listing.txt" {
+		t.Fatalf("got files %q, want gen/listing.txt", paths)
+	}
+	if string(got["gen/listing.txt"]) != string(want["gen/listing.txt"]) {
+		t.Errorf("got:\n%s\nwant:\n%s", got["gen/listing.txt"], want["gen/listing.txt"])
+	}
+
+	if _, err := Run("none", packages); err == nil {
+		t.Error("run of absent plugin got no error")
+	}
+}
@@ -0,0 +1,3847 @@
+// Package protocol defines the communication with generator plugins.
+package protocol
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file protocol.colf.
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+var intconv = binary.BigEndian
+
+// colferBuffers recycles the encoding space of MarshalBinary.
+var colferBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// Colfer configuration attributes
+var (
+	// ColferSizeMax is the upper limit for serial byte sizes.
+	ColferSizeMax = 16 * 1024 * 1024
+	// ColferListMax is the upper limit for the number of elements in a list.
+	ColferListMax = 64 * 1024
+)
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// colferAppendJSONString appends s as a JSON string, with the escapes of
+// JSON.stringify from ECMAScript. Malformed UTF-8 is replaced with U+FFFD.
+func colferAppendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\uFFFD"...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < ' ' {
+				buf = append(buf, '\\', 'u', '0', '0', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+
+// colferAppendJSONBinary appends b as a JSON string with the standard base64
+// encoding, including padding.
+func colferAppendJSONBinary(buf, b []byte) []byte {
+	n := base64.StdEncoding.EncodedLen(len(b))
+	offset := len(buf)
+	if cap(buf)-offset < n+2 {
+		grown := make([]byte, offset, 2*cap(buf)+n+2)
+		copy(grown, buf)
+		buf = grown
+	}
+	buf = buf[:offset+n+2]
+	buf[offset] = '"'
+	base64.StdEncoding.Encode(buf[offset+1:], b)
+	buf[offset+n+1] = '"'
+	return buf
+}
+
+// colferJSONBinary parses a JSON string with the standard base64 encoding,
+// with or without padding. Null and the empty string read as nil.
+func colferJSONBinary(data []byte) ([]byte, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil || s == nil || *s == "" {
+		return nil, err
+	}
+	enc := base64.StdEncoding
+	if len(*s)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	return enc.DecodeString(*s)
+}
+
+// Request is the input of a generator plugin.
+type Request struct {
+	// Lang is the language argument as provided to colf(1).
+	Lang string
+	// Packages are the schema definitions.
+	Packages []*PackageDef
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+// All nil entries in o.Packages will be replaced with a new value.
+func (o *Request) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.Lang); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Lang)
+	}
+
+	if l := len(o.Packages); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi, v := range o.Packages {
+			if v == nil {
+				v = new(PackageDef)
+				o.Packages[vi] = v
+			}
+			i += v.MarshalTo(buf[i:])
+		}
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is protocol.ColferMax.
+func (o *Request) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.Lang); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.request.lang exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Packages); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.request.packages exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, v := range o.Packages {
+			if v == nil {
+				l++
+				continue
+			}
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.request size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct protocol.request exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in o.Packages will be replaced with a new value.
+// The error return option is protocol.ColferMax.
+func (o *Request) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// All nil entries in o.Packages will be replaced with a new value.
+// The error return option is protocol.ColferMax, in which case dst is returned as is.
+func (o *Request) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if l := len(o.Lang); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.request.lang exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Lang...)
+	}
+
+	if l := len(o.Packages); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.request.packages exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for vi, v := range o.Packages {
+			if v == nil {
+				v = new(PackageDef)
+				o.Packages[vi] = v
+			}
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.request size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.request exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// All nil entries in o.Packages will be replaced with a new value.
+// The error return options are io.ErrShortBuffer and protocol.ColferMax, in which case the content of buf is undefined.
+func (o *Request) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, protocol.ColferError and protocol.ColferMax.
+func (o *Request) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.request.lang size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Lang = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.request.packages length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+		a := make([]*PackageDef, l)
+		malloc := make([]PackageDef, l)
+		for ai := range a {
+			v := &malloc[ai]
+			a[ai] = v
+
+			n, err := v.Unmarshal(data[i:])
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: protocol.request size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Packages = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.request size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, protocol.ColferError, protocol.ColferTail and protocol.ColferMax.
+func (o *Request) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+// Any nil entries in o.Packages are written as a new value.
+func (o *Request) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"lang\":"...)
+	buf = colferAppendJSONString(buf, o.Lang)
+	buf = append(buf, ",\"packages\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Packages {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		if v == nil {
+			v = new(PackageDef)
+		}
+		buf = v.AppendJSON(buf)
+	}
+	buf = append(buf, ']')
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *Request) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include protocol.ColferMax.
+func (o *Request) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct protocol.request: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = Request{}
+	for name, raw := range doc {
+		switch name {
+		case "lang":
+			if err := json.Unmarshal(raw, &o.Lang); err != nil {
+				return fmt.Errorf("colfer: protocol.request.lang: %s", err)
+			}
+			if l := len(o.Lang); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.request.lang size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "packages":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.request.packages: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.request.packages length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Packages = make([]*PackageDef, len(a))
+			for ai, x := range a {
+				o.Packages[ai] = new(PackageDef)
+				if err := o.Packages[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("colfer: struct protocol.request has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists keep their capacity for reuse.
+func (o *Request) Reset() {
+	*o = Request{
+		Packages: o.Packages[:0],
+	}
+}
+
+// PackageDef is a named definition bundle.
+type PackageDef struct {
+	// Name is the identification token, including any prefix.
+	Name string
+	// Docs are the documentation texts.
+	Docs []string
+	// Structs are the type definitions.
+	Structs []*StructDef
+	// SchemaFiles are the source filenames.
+	SchemaFiles []string
+	// SizeMax is the upper limit expression.
+	SizeMax string
+	// ListMax is the upper limit expression.
+	ListMax string
+	// SuperClass is the fully qualified path, if any.
+	SuperClass string
+	// ZeroCopy requests unmarshalled text and binaries to share memory
+	// with the serial data.
+	ZeroCopy bool
+	// Reuse requests unmarshalling to recycle the memory of lists,
+	// binaries and nested data structures from previous content.
+	Reuse bool
+	// ValueTypes requests data structure references as values instead
+	// of pointers, where possible.
+	ValueTypes bool
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+// All nil entries in o.Structs will be replaced with a new value.
+func (o *PackageDef) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.Name); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Name)
+	}
+
+	if l := len(o.Docs); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Docs {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.Structs); l != 0 {
+		buf[i] = 2
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi, v := range o.Structs {
+			if v == nil {
+				v = new(StructDef)
+				o.Structs[vi] = v
+			}
+			i += v.MarshalTo(buf[i:])
+		}
+	}
+
+	if l := len(o.SchemaFiles); l != 0 {
+		buf[i] = 3
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.SchemaFiles {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.SizeMax); l != 0 {
+		buf[i] = 4
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.SizeMax)
+	}
+
+	if l := len(o.ListMax); l != 0 {
+		buf[i] = 5
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.ListMax)
+	}
+
+	if l := len(o.SuperClass); l != 0 {
+		buf[i] = 6
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.SuperClass)
+	}
+
+	if o.ZeroCopy {
+		buf[i] = 7
+		i++
+	}
+
+	if o.Reuse {
+		buf[i] = 8
+		i++
+	}
+
+	if o.ValueTypes {
+		buf[i] = 9
+		i++
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is protocol.ColferMax.
+func (o *PackageDef) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.Name); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.name exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Docs); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.docs exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.Docs {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.docs exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.Structs); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.structs exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, v := range o.Structs {
+			if v == nil {
+				l++
+				continue
+			}
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.SchemaFiles); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.schemaFiles exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.SchemaFiles {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.schemaFiles exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.SizeMax); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.sizeMax exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.ListMax); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.listMax exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.SuperClass); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.superClass exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if o.ZeroCopy {
+		l++
+	}
+
+	if o.Reuse {
+		l++
+	}
+
+	if o.ValueTypes {
+		l++
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in o.Structs will be replaced with a new value.
+// The error return option is protocol.ColferMax.
+func (o *PackageDef) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// All nil entries in o.Structs will be replaced with a new value.
+// The error return option is protocol.ColferMax, in which case dst is returned as is.
+func (o *PackageDef) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if l := len(o.Name); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.name exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Name...)
+	}
+
+	if l := len(o.Docs); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.docs exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Docs {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.docs exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.Structs); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.structs exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 2)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for vi, v := range o.Structs {
+			if v == nil {
+				v = new(StructDef)
+				o.Structs[vi] = v
+			}
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.SchemaFiles); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.schemaFiles exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 3)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.SchemaFiles {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.schemaFiles exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.SizeMax); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.sizeMax exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 4)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.SizeMax...)
+	}
+
+	if l := len(o.ListMax); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.listMax exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 5)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.ListMax...)
+	}
+
+	if l := len(o.SuperClass); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.packageDef.superClass exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 6)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.SuperClass...)
+	}
+
+	if o.ZeroCopy {
+		buf = append(buf, 7)
+	}
+
+	if o.Reuse {
+		buf = append(buf, 8)
+	}
+
+	if o.ValueTypes {
+		buf = append(buf, 9)
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// All nil entries in o.Structs will be replaced with a new value.
+// The error return options are io.ErrShortBuffer and protocol.ColferMax, in which case the content of buf is undefined.
+func (o *PackageDef) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, protocol.ColferError and protocol.ColferMax.
+func (o *PackageDef) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.name size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Name = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.docs length %d exceeds %d elements", x, ColferListMax))
+		}
+		a := make([]string, int(x))
+		o.Docs = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.docs element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.structs length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+		a := make([]*StructDef, l)
+		malloc := make([]StructDef, l)
+		for ai := range a {
+			v := &malloc[ai]
+			a[ai] = v
+
+			n, err := v.Unmarshal(data[i:])
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Structs = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.schemaFiles length %d exceeds %d elements", x, ColferListMax))
+		}
+		a := make([]string, int(x))
+		o.SchemaFiles = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.schemaFiles element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.sizeMax size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.SizeMax = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.listMax size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.ListMax = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.packageDef.superClass size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.SuperClass = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.ZeroCopy = true
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.Reuse = true
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.ValueTypes = true
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.packageDef size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, protocol.ColferError, protocol.ColferTail and protocol.ColferMax.
+func (o *PackageDef) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+// Any nil entries in o.Structs are written as a new value.
+func (o *PackageDef) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"name\":"...)
+	buf = colferAppendJSONString(buf, o.Name)
+	buf = append(buf, ",\"docs\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Docs {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONString(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"structs\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Structs {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		if v == nil {
+			v = new(StructDef)
+		}
+		buf = v.AppendJSON(buf)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"schemaFiles\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.SchemaFiles {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONString(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"sizeMax\":"...)
+	buf = colferAppendJSONString(buf, o.SizeMax)
+	buf = append(buf, ",\"listMax\":"...)
+	buf = colferAppendJSONString(buf, o.ListMax)
+	buf = append(buf, ",\"superClass\":"...)
+	buf = colferAppendJSONString(buf, o.SuperClass)
+	buf = append(buf, ",\"zeroCopy\":"...)
+	if o.ZeroCopy {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+	buf = append(buf, ",\"reuse\":"...)
+	if o.Reuse {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+	buf = append(buf, ",\"valueTypes\":"...)
+	if o.ValueTypes {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *PackageDef) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include protocol.ColferMax.
+func (o *PackageDef) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct protocol.packageDef: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = PackageDef{}
+	for name, raw := range doc {
+		switch name {
+		case "name":
+			if err := json.Unmarshal(raw, &o.Name); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.name: %s", err)
+			}
+			if l := len(o.Name); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.name size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "docs":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.docs: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.docs length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Docs = make([]string, len(a))
+			for ai, x := range a {
+				if err := json.Unmarshal(x, &o.Docs[ai]); err != nil {
+					return fmt.Errorf("colfer: protocol.packageDef.docs element %d: %s", ai, err)
+				}
+				if l := len(o.Docs[ai]); l > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.docs element %d size %d exceeds %d bytes", ai, l, ColferSizeMax))
+				}
+			}
+		case "structs":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.structs: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.structs length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Structs = make([]*StructDef, len(a))
+			for ai, x := range a {
+				o.Structs[ai] = new(StructDef)
+				if err := o.Structs[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+			}
+		case "schemaFiles":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.schemaFiles: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.schemaFiles length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.SchemaFiles = make([]string, len(a))
+			for ai, x := range a {
+				if err := json.Unmarshal(x, &o.SchemaFiles[ai]); err != nil {
+					return fmt.Errorf("colfer: protocol.packageDef.schemaFiles element %d: %s", ai, err)
+				}
+				if l := len(o.SchemaFiles[ai]); l > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.schemaFiles element %d size %d exceeds %d bytes", ai, l, ColferSizeMax))
+				}
+			}
+		case "sizeMax":
+			if err := json.Unmarshal(raw, &o.SizeMax); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.sizeMax: %s", err)
+			}
+			if l := len(o.SizeMax); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.sizeMax size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "listMax":
+			if err := json.Unmarshal(raw, &o.ListMax); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.listMax: %s", err)
+			}
+			if l := len(o.ListMax); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.listMax size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "superClass":
+			if err := json.Unmarshal(raw, &o.SuperClass); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.superClass: %s", err)
+			}
+			if l := len(o.SuperClass); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.packageDef.superClass size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "zeroCopy":
+			if err := json.Unmarshal(raw, &o.ZeroCopy); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.zeroCopy: %s", err)
+			}
+		case "reuse":
+			if err := json.Unmarshal(raw, &o.Reuse); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.reuse: %s", err)
+			}
+		case "valueTypes":
+			if err := json.Unmarshal(raw, &o.ValueTypes); err != nil {
+				return fmt.Errorf("colfer: protocol.packageDef.valueTypes: %s", err)
+			}
+		default:
+			return fmt.Errorf("colfer: struct protocol.packageDef has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists keep their capacity for reuse.
+func (o *PackageDef) Reset() {
+	*o = PackageDef{
+		Docs:        o.Docs[:0],
+		Structs:     o.Structs[:0],
+		SchemaFiles: o.SchemaFiles[:0],
+	}
+}
+
+// StructDef is a data structure definition.
+type StructDef struct {
+	// Name is the identification token.
+	Name string
+	// Docs are the documentation texts.
+	Docs []string
+	// Fields are the elements in order of appearance.
+	Fields []*FieldDef
+	// SchemaFile is the source filename.
+	SchemaFile string
+	// SizeMax is the upper limit expression for the struct, if any.
+	SizeMax string
+	// ListMax is the upper limit expression for the struct, if any.
+	ListMax string
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+// All nil entries in o.Fields will be replaced with a new value.
+func (o *StructDef) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.Name); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Name)
+	}
+
+	if l := len(o.Docs); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Docs {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.Fields); l != 0 {
+		buf[i] = 2
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi, v := range o.Fields {
+			if v == nil {
+				v = new(FieldDef)
+				o.Fields[vi] = v
+			}
+			i += v.MarshalTo(buf[i:])
+		}
+	}
+
+	if l := len(o.SchemaFile); l != 0 {
+		buf[i] = 3
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.SchemaFile)
+	}
+
+	if l := len(o.SizeMax); l != 0 {
+		buf[i] = 4
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.SizeMax)
+	}
+
+	if l := len(o.ListMax); l != 0 {
+		buf[i] = 5
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.ListMax)
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is protocol.ColferMax.
+func (o *StructDef) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.Name); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.name exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Docs); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.docs exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.Docs {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.docs exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.Fields); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.fields exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, v := range o.Fields {
+			if v == nil {
+				l++
+				continue
+			}
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.SchemaFile); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.schemaFile exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.SizeMax); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.sizeMax exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.ListMax); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.listMax exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in o.Fields will be replaced with a new value.
+// The error return option is protocol.ColferMax.
+func (o *StructDef) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// All nil entries in o.Fields will be replaced with a new value.
+// The error return option is protocol.ColferMax, in which case dst is returned as is.
+func (o *StructDef) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if l := len(o.Name); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.name exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Name...)
+	}
+
+	if l := len(o.Docs); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.docs exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Docs {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.docs exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.Fields); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.fields exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 2)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for vi, v := range o.Fields {
+			if v == nil {
+				v = new(FieldDef)
+				o.Fields[vi] = v
+			}
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.SchemaFile); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.schemaFile exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 3)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.SchemaFile...)
+	}
+
+	if l := len(o.SizeMax); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.sizeMax exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 4)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.SizeMax...)
+	}
+
+	if l := len(o.ListMax); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.structDef.listMax exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 5)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.ListMax...)
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// All nil entries in o.Fields will be replaced with a new value.
+// The error return options are io.ErrShortBuffer and protocol.ColferMax, in which case the content of buf is undefined.
+func (o *StructDef) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, protocol.ColferError and protocol.ColferMax.
+func (o *StructDef) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.name size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Name = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.docs length %d exceeds %d elements", x, ColferListMax))
+		}
+		a := make([]string, int(x))
+		o.Docs = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.docs element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.fields length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+		a := make([]*FieldDef, l)
+		malloc := make([]FieldDef, l)
+		for ai := range a {
+			v := &malloc[ai]
+			a[ai] = v
+
+			n, err := v.Unmarshal(data[i:])
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Fields = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.schemaFile size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.SchemaFile = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.sizeMax size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.SizeMax = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.structDef.listMax size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.ListMax = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.structDef size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, protocol.ColferError, protocol.ColferTail and protocol.ColferMax.
+func (o *StructDef) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+// Any nil entries in o.Fields are written as a new value.
+func (o *StructDef) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"name\":"...)
+	buf = colferAppendJSONString(buf, o.Name)
+	buf = append(buf, ",\"docs\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Docs {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONString(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"fields\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Fields {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		if v == nil {
+			v = new(FieldDef)
+		}
+		buf = v.AppendJSON(buf)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"schemaFile\":"...)
+	buf = colferAppendJSONString(buf, o.SchemaFile)
+	buf = append(buf, ",\"sizeMax\":"...)
+	buf = colferAppendJSONString(buf, o.SizeMax)
+	buf = append(buf, ",\"listMax\":"...)
+	buf = colferAppendJSONString(buf, o.ListMax)
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *StructDef) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include protocol.ColferMax.
+func (o *StructDef) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct protocol.structDef: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = StructDef{}
+	for name, raw := range doc {
+		switch name {
+		case "name":
+			if err := json.Unmarshal(raw, &o.Name); err != nil {
+				return fmt.Errorf("colfer: protocol.structDef.name: %s", err)
+			}
+			if l := len(o.Name); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.structDef.name size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "docs":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.structDef.docs: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.structDef.docs length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Docs = make([]string, len(a))
+			for ai, x := range a {
+				if err := json.Unmarshal(x, &o.Docs[ai]); err != nil {
+					return fmt.Errorf("colfer: protocol.structDef.docs element %d: %s", ai, err)
+				}
+				if l := len(o.Docs[ai]); l > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: protocol.structDef.docs element %d size %d exceeds %d bytes", ai, l, ColferSizeMax))
+				}
+			}
+		case "fields":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.structDef.fields: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.structDef.fields length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Fields = make([]*FieldDef, len(a))
+			for ai, x := range a {
+				o.Fields[ai] = new(FieldDef)
+				if err := o.Fields[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+			}
+		case "schemaFile":
+			if err := json.Unmarshal(raw, &o.SchemaFile); err != nil {
+				return fmt.Errorf("colfer: protocol.structDef.schemaFile: %s", err)
+			}
+			if l := len(o.SchemaFile); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.structDef.schemaFile size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "sizeMax":
+			if err := json.Unmarshal(raw, &o.SizeMax); err != nil {
+				return fmt.Errorf("colfer: protocol.structDef.sizeMax: %s", err)
+			}
+			if l := len(o.SizeMax); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.structDef.sizeMax size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "listMax":
+			if err := json.Unmarshal(raw, &o.ListMax); err != nil {
+				return fmt.Errorf("colfer: protocol.structDef.listMax: %s", err)
+			}
+			if l := len(o.ListMax); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.structDef.listMax size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		default:
+			return fmt.Errorf("colfer: struct protocol.structDef has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists keep their capacity for reuse.
+func (o *StructDef) Reset() {
+	*o = StructDef{
+		Docs:   o.Docs[:0],
+		Fields: o.Fields[:0],
+	}
+}
+
+// FieldDef is a data structure element.
+type FieldDef struct {
+	// Index is the position in the struct.
+	Index uint8
+	// Name is the identification token.
+	Name string
+	// Docs are the documentation texts.
+	Docs []string
+	// Datatype is the type name as declared in the schema.
+	Datatype string
+	// TypeRef is the qualified name of the data structure, if any.
+	TypeRef string
+	// TypeList flags whether the datatype is a list.
+	TypeList bool
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+func (o *FieldDef) MarshalTo(buf []byte) int {
+	var i int
+
+	if x := o.Index; x != 0 {
+		buf[i] = 0
+		i++
+		buf[i] = x
+		i++
+	}
+
+	if l := len(o.Name); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Name)
+	}
+
+	if l := len(o.Docs); l != 0 {
+		buf[i] = 2
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Docs {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.Datatype); l != 0 {
+		buf[i] = 3
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Datatype)
+	}
+
+	if l := len(o.TypeRef); l != 0 {
+		buf[i] = 4
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.TypeRef)
+	}
+
+	if o.TypeList {
+		buf[i] = 5
+		i++
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is protocol.ColferMax.
+func (o *FieldDef) MarshalLen() (int, error) {
+	l := 1
+
+	if x := o.Index; x != 0 {
+		l += 2
+	}
+
+	if x := len(o.Name); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.name exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Docs); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.docs exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.Docs {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.docs exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.fieldDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.Datatype); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.datatype exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.TypeRef); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.typeRef exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if o.TypeList {
+		l++
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct protocol.fieldDef exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is protocol.ColferMax.
+func (o *FieldDef) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// The error return option is protocol.ColferMax, in which case dst is returned as is.
+func (o *FieldDef) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if x := o.Index; x != 0 {
+		buf = append(buf, 0, x)
+	}
+
+	if l := len(o.Name); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.name exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Name...)
+	}
+
+	if l := len(o.Docs); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.docs exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 2)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Docs {
+			if len(a) > ColferSizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.docs exceeds %d bytes", ColferSizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.fieldDef size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.Datatype); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.datatype exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 3)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Datatype...)
+	}
+
+	if l := len(o.TypeRef); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.fieldDef.typeRef exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 4)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.TypeRef...)
+	}
+
+	if o.TypeList {
+		buf = append(buf, 5)
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.fieldDef exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// The error return options are io.ErrShortBuffer and protocol.ColferMax, in which case the content of buf is undefined.
+func (o *FieldDef) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, protocol.ColferError and protocol.ColferMax.
+func (o *FieldDef) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.Index = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.name size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Name = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.docs length %d exceeds %d elements", x, ColferListMax))
+		}
+		a := make([]string, int(x))
+		o.Docs = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.docs element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.datatype size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Datatype = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.typeRef size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.TypeRef = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.TypeList = true
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.fieldDef size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, protocol.ColferError, protocol.ColferTail and protocol.ColferMax.
+func (o *FieldDef) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+func (o *FieldDef) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"index\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.Index), 10)
+	buf = append(buf, ",\"name\":"...)
+	buf = colferAppendJSONString(buf, o.Name)
+	buf = append(buf, ",\"docs\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Docs {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferAppendJSONString(buf, v)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"datatype\":"...)
+	buf = colferAppendJSONString(buf, o.Datatype)
+	buf = append(buf, ",\"typeRef\":"...)
+	buf = colferAppendJSONString(buf, o.TypeRef)
+	buf = append(buf, ",\"typeList\":"...)
+	if o.TypeList {
+		buf = append(buf, "true"...)
+	} else {
+		buf = append(buf, "false"...)
+	}
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *FieldDef) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include protocol.ColferMax.
+func (o *FieldDef) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct protocol.fieldDef: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = FieldDef{}
+	for name, raw := range doc {
+		switch name {
+		case "index":
+			if err := json.Unmarshal(raw, &o.Index); err != nil {
+				return fmt.Errorf("colfer: protocol.fieldDef.index: %s", err)
+			}
+		case "name":
+			if err := json.Unmarshal(raw, &o.Name); err != nil {
+				return fmt.Errorf("colfer: protocol.fieldDef.name: %s", err)
+			}
+			if l := len(o.Name); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.name size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "docs":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.fieldDef.docs: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.docs length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Docs = make([]string, len(a))
+			for ai, x := range a {
+				if err := json.Unmarshal(x, &o.Docs[ai]); err != nil {
+					return fmt.Errorf("colfer: protocol.fieldDef.docs element %d: %s", ai, err)
+				}
+				if l := len(o.Docs[ai]); l > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.docs element %d size %d exceeds %d bytes", ai, l, ColferSizeMax))
+				}
+			}
+		case "datatype":
+			if err := json.Unmarshal(raw, &o.Datatype); err != nil {
+				return fmt.Errorf("colfer: protocol.fieldDef.datatype: %s", err)
+			}
+			if l := len(o.Datatype); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.datatype size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "typeRef":
+			if err := json.Unmarshal(raw, &o.TypeRef); err != nil {
+				return fmt.Errorf("colfer: protocol.fieldDef.typeRef: %s", err)
+			}
+			if l := len(o.TypeRef); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.fieldDef.typeRef size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "typeList":
+			if err := json.Unmarshal(raw, &o.TypeList); err != nil {
+				return fmt.Errorf("colfer: protocol.fieldDef.typeList: %s", err)
+			}
+		default:
+			return fmt.Errorf("colfer: struct protocol.fieldDef has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists keep their capacity for reuse.
+func (o *FieldDef) Reset() {
+	*o = FieldDef{
+		Docs: o.Docs[:0],
+	}
+}
+
+// Response is the output of a generator plugin.
+type Response struct {
+	// Files are the generated content.
+	Files []*File
+	// Error is the reason of failure, if any.
+	Error string
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+// All nil entries in o.Files will be replaced with a new value.
+func (o *Response) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.Files); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi, v := range o.Files {
+			if v == nil {
+				v = new(File)
+				o.Files[vi] = v
+			}
+			i += v.MarshalTo(buf[i:])
+		}
+	}
+
+	if l := len(o.Error); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Error)
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is protocol.ColferMax.
+func (o *Response) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.Files); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.response.files exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, v := range o.Files {
+			if v == nil {
+				l++
+				continue
+			}
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.response size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.Error); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.response.error exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct protocol.response exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in o.Files will be replaced with a new value.
+// The error return option is protocol.ColferMax.
+func (o *Response) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// All nil entries in o.Files will be replaced with a new value.
+// The error return option is protocol.ColferMax, in which case dst is returned as is.
+func (o *Response) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if l := len(o.Files); l != 0 {
+		if l > ColferListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.response.files exceeds %d elements", ColferListMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for vi, v := range o.Files {
+			if v == nil {
+				v = new(File)
+				o.Files[vi] = v
+			}
+			var err error
+			if buf, err = v.MarshalAppend(buf); err != nil {
+				return dst, err
+			}
+		}
+		if len(buf)-offset >= ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.response size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if l := len(o.Error); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.response.error exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Error...)
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.response exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// All nil entries in o.Files will be replaced with a new value.
+// The error return options are io.ErrShortBuffer and protocol.ColferMax, in which case the content of buf is undefined.
+func (o *Response) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, protocol.ColferError and protocol.ColferMax.
+func (o *Response) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.response.files length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+		a := make([]*File, l)
+		malloc := make([]File, l)
+		for ai := range a {
+			v := &malloc[ai]
+			a[ai] = v
+
+			n, err := v.Unmarshal(data[i:])
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: protocol.response size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Files = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.response.error size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Error = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.response size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, protocol.ColferError, protocol.ColferTail and protocol.ColferMax.
+func (o *Response) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+// Any nil entries in o.Files are written as a new value.
+func (o *Response) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"files\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Files {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		if v == nil {
+			v = new(File)
+		}
+		buf = v.AppendJSON(buf)
+	}
+	buf = append(buf, ']')
+	buf = append(buf, ",\"error\":"...)
+	buf = colferAppendJSONString(buf, o.Error)
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *Response) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include protocol.ColferMax.
+func (o *Response) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct protocol.response: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = Response{}
+	for name, raw := range doc {
+		switch name {
+		case "files":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: protocol.response.files: %s", err)
+			}
+			if len(a) > ColferListMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.response.files length %d exceeds %d elements", len(a), ColferListMax))
+			}
+			if len(a) == 0 {
+				break
+			}
+			o.Files = make([]*File, len(a))
+			for ai, x := range a {
+				o.Files[ai] = new(File)
+				if err := o.Files[ai].UnmarshalJSON(x); err != nil {
+					return err
+				}
+			}
+		case "error":
+			if err := json.Unmarshal(raw, &o.Error); err != nil {
+				return fmt.Errorf("colfer: protocol.response.error: %s", err)
+			}
+			if l := len(o.Error); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.response.error size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		default:
+			return fmt.Errorf("colfer: struct protocol.response has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that lists keep their capacity for reuse.
+func (o *Response) Reset() {
+	*o = Response{
+		Files: o.Files[:0],
+	}
+}
+
+// File is a generated file.
+type File struct {
+	// Path is relative to the base directory, with slash as a separator.
+	Path string
+	// Content is the data.
+	Content []byte
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
+func (o *File) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.Path); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Path)
+	}
+
+	if l := len(o.Content); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Content)
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is protocol.ColferMax.
+func (o *File) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.Path); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.file.path exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Content); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field protocol.file.content exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct protocol.file exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is protocol.ColferMax.
+func (o *File) MarshalBinary() (data []byte, err error) {
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// The error return option is protocol.ColferMax, in which case dst is returned as is.
+func (o *File) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if l := len(o.Path); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.file.path exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Path...)
+	}
+
+	if l := len(o.Content); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field protocol.file.content exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Content...)
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct protocol.file exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// The error return options are io.ErrShortBuffer and protocol.ColferMax, in which case the content of buf is undefined.
+func (o *File) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+	return len(data), nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, protocol.ColferError and protocol.ColferMax.
+func (o *File) Unmarshal(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.file.path size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Path = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: protocol.file.content size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		v := make([]byte, int(x))
+
+		start := i
+		i += len(v)
+		if i >= len(data) {
+			goto eof
+		}
+		copy(v, data[start:i])
+		o.Content = v
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct protocol.file size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, protocol.ColferError, protocol.ColferTail and protocol.ColferMax.
+func (o *File) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+func (o *File) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"path\":"...)
+	buf = colferAppendJSONString(buf, o.Path)
+	buf = append(buf, ",\"content\":"...)
+	buf = colferAppendJSONBinary(buf, o.Content)
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *File) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include protocol.ColferMax.
+func (o *File) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct protocol.file: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = File{}
+	for name, raw := range doc {
+		switch name {
+		case "path":
+			if err := json.Unmarshal(raw, &o.Path); err != nil {
+				return fmt.Errorf("colfer: protocol.file.path: %s", err)
+			}
+			if l := len(o.Path); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.file.path size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "content":
+			v, err := colferJSONBinary(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: protocol.file.content: %s", err)
+			}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: protocol.file.content size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+			o.Content = v
+		default:
+			return fmt.Errorf("colfer: struct protocol.file has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value, except that binaries keep their capacity for reuse.
+func (o *File) Reset() {
+	*o = File{
+		Content: o.Content[:0],
+	}
+}
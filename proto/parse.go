@@ -0,0 +1,420 @@
+package proto
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+// parser is a recursive descent reader for the proto2 and proto3 syntax.
+type parser struct {
+	s        scanner.Scanner
+	filename string
+
+	// tok is the current token with its text and position.
+	tok  rune
+	text string
+	pos  scanner.Position
+	// docs are the comments which precede tok without blank lines.
+	docs []string
+
+	file   *file
+	issues []Issue
+}
+
+// file is a parsed .proto file.
+type file struct {
+	pkg      string
+	docs     []string
+	messages []*message
+	enums    []*enum
+}
+
+// message is a parsed message declaration.
+type message struct {
+	pos  scanner.Position
+	docs []string
+	// name is the qualified name within the package.
+	name   string
+	fields []*field
+}
+
+// field is a parsed message field.
+type field struct {
+	pos      scanner.Position
+	docs     []string
+	name     string
+	typ      string
+	number   int
+	repeated bool
+	// scope is the qualified name of the enclosing message.
+	scope string
+}
+
+// enum is a parsed enumeration.
+type enum struct {
+	pos    scanner.Position
+	name   string
+	values []string
+}
+
+func newParser(filename string, src string) *parser {
+	p := &parser{filename: filename, file: new(file)}
+	p.s.Init(strings.NewReader(src))
+	p.s.Filename = filename
+	p.s.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings | scanner.ScanRawStrings | scanner.ScanComments
+	p.s.Error = func(s *scanner.Scanner, msg string) {
+		p.fail(s.Pos(), msg)
+	}
+	p.next()
+	return p
+}
+
+// syntaxError aborts parsing.
+type syntaxError struct {
+	pos scanner.Position
+	msg string
+}
+
+func (p *parser) fail(pos scanner.Position, msg string) {
+	panic(syntaxError{pos, msg})
+}
+
+// report registers a construct which has no Colfer equivalent.
+func (p *parser) report(pos scanner.Position, format string, args ...interface{}) {
+	p.issues = append(p.issues, Issue{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// next moves to the following token and collects the doc comments.
+func (p *parser) next() {
+	p.docs = nil
+	tokLine := p.pos.Line
+	lastLine := 0
+	for {
+		p.tok = p.s.Scan()
+		p.text = p.s.TokenText()
+		p.pos = p.s.Position
+		if p.tok != scanner.Comment {
+			if lastLine != 0 && p.pos.Line > lastLine+1 {
+				p.docs = nil // detached
+			}
+			return
+		}
+
+		if p.pos.Line == tokLine {
+			continue // trailing comment
+		}
+		if lastLine != 0 && p.pos.Line > lastLine+1 {
+			p.docs = nil // detached
+		}
+		p.docs = append(p.docs, commentLines(p.text)...)
+		lastLine = p.pos.Line + strings.Count(p.text, "\n")
+	}
+}
+
+// commentLines returns the comment text as Go line comments.
+func commentLines(text string) []string {
+	if strings.HasPrefix(text, "//") {
+		return []string{"// " + strings.TrimSpace(text[2:])}
+	}
+
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		if line != "" || len(lines) != 0 {
+			lines = append(lines, "// "+line)
+		}
+	}
+	for len(lines) != 0 && lines[len(lines)-1] == "// " {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func (p *parser) expect(text string) {
+	if p.text != text {
+		p.fail(p.pos, fmt.Sprintf("expected %q, found %q", text, p.text))
+	}
+	p.next()
+}
+
+func (p *parser) ident() string {
+	if p.tok != scanner.Ident {
+		p.fail(p.pos, fmt.Sprintf("expected identifier, found %q", p.text))
+	}
+	s := p.text
+	p.next()
+	return s
+}
+
+// fullIdent reads a dotted name, including the optional leading dot.
+func (p *parser) fullIdent() string {
+	var buf bytes.Buffer
+	if p.text == "." {
+		buf.WriteByte('.')
+		p.next()
+	}
+	buf.WriteString(p.ident())
+	for p.text == "." {
+		p.next()
+		buf.WriteByte('.')
+		buf.WriteString(p.ident())
+	}
+	return buf.String()
+}
+
+func (p *parser) number() int {
+	neg := false
+	if p.text == "-" {
+		neg = true
+		p.next()
+	}
+	if p.tok != scanner.Int {
+		p.fail(p.pos, fmt.Sprintf("expected integer, found %q", p.text))
+	}
+	n, err := strconv.ParseInt(p.text, 0, 64)
+	if err != nil {
+		p.fail(p.pos, fmt.Sprintf("malformed integer %q", p.text))
+	}
+	p.next()
+	if neg {
+		return -int(n)
+	}
+	return int(n)
+}
+
+// skipStatement reads up to and including the terminating semicolon, or the
+// block with braces.
+func (p *parser) skipStatement() {
+	depth := 0
+	for {
+		switch p.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case ";":
+			if depth == 0 {
+				p.next()
+				return
+			}
+		}
+		if p.tok == scanner.EOF {
+			p.fail(p.pos, "unexpected end of file")
+		}
+		p.next()
+	}
+}
+
+// skipOptions reads an optional field option list in brackets.
+func (p *parser) skipOptions() {
+	if p.text != "[" {
+		return
+	}
+	for p.text != "]" {
+		if p.tok == scanner.EOF {
+			p.fail(p.pos, "unexpected end of file")
+		}
+		p.next()
+	}
+	p.next()
+}
+
+func (p *parser) parseFile() {
+	for p.tok != scanner.EOF {
+		pos, docs := p.pos, p.docs
+		switch p.text {
+		case "syntax":
+			p.file.docs = docs
+			p.next()
+			p.expect("=")
+			if p.tok != scanner.String {
+				p.fail(p.pos, fmt.Sprintf("expected syntax string, found %q", p.text))
+			}
+			p.next()
+			p.expect(";")
+
+		case "package":
+			if len(docs) != 0 {
+				p.file.docs = docs
+			}
+			p.next()
+			p.file.pkg = p.fullIdent()
+			p.expect(";")
+
+		case "import", "option":
+			p.skipStatement()
+
+		case "message":
+			p.parseMessage(docs, "")
+
+		case "enum":
+			p.parseEnum("")
+
+		case "service":
+			p.report(pos, "service %s has no Colfer equivalent", p.peekName())
+			p.skipStatement()
+
+		case "extend":
+			p.report(pos, "extension of %s has no Colfer equivalent", p.peekName())
+			p.skipStatement()
+
+		case ";":
+			p.next()
+
+		default:
+			p.fail(p.pos, fmt.Sprintf("unexpected %q", p.text))
+		}
+	}
+}
+
+// peekName returns the identifier after the current keyword token without
+// consuming any.
+func (p *parser) peekName() string {
+	s := p.s
+	s.Error = func(*scanner.Scanner, string) {}
+	if tok := s.Scan(); tok == scanner.Ident {
+		return s.TokenText()
+	}
+	return "?"
+}
+
+func (p *parser) parseMessage(docs []string, scope string) {
+	pos := p.pos
+	p.expect("message")
+	name := p.ident()
+	if scope != "" {
+		name = scope + "." + name
+	}
+	m := &message{pos: pos, docs: docs, name: name}
+	p.file.messages = append(p.file.messages, m)
+
+	p.expect("{")
+	p.parseBody(m, "")
+	p.expect("}")
+}
+
+// parseBody reads message content up to the closing brace. The oneof name is
+// set for the content of a oneof block.
+func (p *parser) parseBody(m *message, oneof string) {
+	for p.text != "}" {
+		if p.tok == scanner.EOF {
+			p.fail(p.pos, "unexpected end of file")
+		}
+
+		pos, docs := p.pos, p.docs
+		switch p.text {
+		case ";":
+			p.next()
+
+		case "message":
+			if oneof != "" {
+				p.fail(p.pos, "message in oneof")
+			}
+			p.parseMessage(docs, m.name)
+
+		case "enum":
+			p.parseEnum(m.name)
+
+		case "option", "reserved", "extensions":
+			p.skipStatement()
+
+		case "extend":
+			p.report(pos, "extension of %s has no Colfer equivalent", p.peekName())
+			p.skipStatement()
+
+		case "group":
+			p.report(pos, "group has no Colfer equivalent")
+			p.skipStatement()
+
+		case "oneof":
+			p.next()
+			name := p.ident()
+			p.report(pos, "oneof %s has no Colfer equivalent; the options are mapped as regular fields", name)
+			p.expect("{")
+			p.parseBody(m, name)
+			p.expect("}")
+
+		case "map":
+			p.next()
+			if p.text != "<" {
+				// field type named map
+				p.parseField(m, pos, docs, false, "map")
+				continue
+			}
+			p.report(pos, "map field has no Colfer equivalent")
+			p.skipStatement()
+
+		case "repeated":
+			p.next()
+			p.parseField(m, pos, docs, true, "")
+
+		case "optional", "required":
+			p.next()
+			p.parseField(m, pos, docs, false, "")
+
+		default:
+			p.parseField(m, pos, docs, false, "")
+		}
+	}
+}
+
+// parseField reads a field declaration, with the type name when already
+// consumed.
+func (p *parser) parseField(m *message, pos scanner.Position, docs []string, repeated bool, typ string) {
+	if typ == "" {
+		typ = p.fullIdent()
+	} else {
+		for p.text == "." {
+			p.next()
+			typ += "." + p.ident()
+		}
+	}
+	f := &field{pos: pos, docs: docs, typ: typ, repeated: repeated, scope: m.name}
+	f.name = p.ident()
+	p.expect("=")
+	f.number = p.number()
+	p.skipOptions()
+	p.expect(";")
+	m.fields = append(m.fields, f)
+}
+
+func (p *parser) parseEnum(scope string) {
+	pos := p.pos
+	p.expect("enum")
+	name := p.ident()
+	if scope != "" {
+		name = scope + "." + name
+	}
+	e := &enum{pos: pos, name: name}
+	p.file.enums = append(p.file.enums, e)
+
+	p.expect("{")
+	for p.text != "}" {
+		switch p.text {
+		case ";":
+			p.next()
+		case "option", "reserved":
+			p.skipStatement()
+		default:
+			value := p.ident()
+			p.expect("=")
+			p.number()
+			p.skipOptions()
+			p.expect(";")
+			e.values = append(e.values, value)
+		}
+		if p.tok == scanner.EOF {
+			p.fail(p.pos, "unexpected end of file")
+		}
+	}
+	p.next()
+}
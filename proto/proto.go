@@ -0,0 +1,279 @@
+// Package proto converts Protocol Buffers schemas to Colfer.
+package proto
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/scanner"
+	"unicode"
+)
+
+// Issue is a construct without Colfer equivalent.
+type Issue struct {
+	Pos     scanner.Position
+	Message string
+}
+
+// String returns the issue in a single line, prefixed with the position.
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+// scalars maps the Protocol Buffers types to their Colfer equivalent.
+var scalars = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"int64":    "int64",
+	"uint32":   "uint32",
+	"uint64":   "uint64",
+	"sint32":   "int32",
+	"sint64":   "int64",
+	"fixed32":  "uint32",
+	"fixed64":  "uint64",
+	"sfixed32": "int32",
+	"sfixed64": "int64",
+	"bool":     "bool",
+	"string":   "text",
+	"bytes":    "binary",
+
+	"google.protobuf.Timestamp": "timestamp",
+}
+
+// Convert reads a .proto schema and returns the Colfer equivalent. Nested
+// messages become structs with the enclosing names as a prefix, separated by
+// an underscore. Fields get the order of their numbers, as Colfer has
+// positional identification, which closes any gaps in the numbering. The
+// issues report each construct which was omitted or mapped with loss, and
+// each gap. The error is for malformed input only.
+func Convert(filename string, src []byte) (schema []byte, issues []Issue, err error) {
+	// the parser reads ahead on construction already
+	defer func() {
+		switch e := recover().(type) {
+		case nil:
+			break
+		case syntaxError:
+			schema, issues, err = nil, nil, fmt.Errorf("colf: %s: %s", e.pos, e.msg)
+		default:
+			panic(e)
+		}
+	}()
+	p := newParser(filename, string(src))
+	p.parseFile()
+
+	c := converter{parser: p, types: make(map[string]string)}
+	for _, m := range p.file.messages {
+		c.types[m.name] = c.ident(m.pos, strings.Replace(m.name, ".", "_", -1))
+	}
+	for _, e := range p.file.enums {
+		c.types[e.name] = "enum"
+	}
+
+	c.writePackage()
+	for _, m := range p.file.messages {
+		c.writeMessage(m)
+	}
+
+	schema, err = format.Source(c.buf.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("colf: %s: malformed conversion: %s", filename, err)
+	}
+	sort.SliceStable(p.issues, func(i, j int) bool {
+		a, b := p.issues[i].Pos, p.issues[j].Pos
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return schema, p.issues, nil
+}
+
+// converter is the output state.
+type converter struct {
+	*parser
+	buf bytes.Buffer
+	// types has the Colfer names of messages and the "enum" value
+	// for enumerations, keyed by the qualified name within the package.
+	types map[string]string
+}
+
+// ident returns a valid Colfer identifier for name.
+func (c *converter) ident(pos scanner.Position, name string) string {
+	if token.Lookup(name).IsKeyword() {
+		c.report(pos, "name %q is a reserved word; renamed to %q", name, name+"_")
+		return name + "_"
+	}
+	return name
+}
+
+func (c *converter) writePackage() {
+	pkg := c.file.pkg
+	if i := strings.LastIndexByte(pkg, '.'); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		base := filepath.Base(c.filename)
+		pkg = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, strings.TrimSuffix(base, filepath.Ext(base)))
+		if pkg == "" || !unicode.IsLetter(rune(pkg[0])) {
+			pkg = "schema"
+		}
+		c.report(scanner.Position{Filename: c.filename, Line: 1, Column: 1}, "no package declaration; named %q", pkg)
+	}
+
+	for _, line := range c.file.docs {
+		c.buf.WriteString(line)
+		c.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&c.buf, "package %s\n", c.ident(scanner.Position{Filename: c.filename, Line: 1, Column: 1}, pkg))
+}
+
+func (c *converter) writeMessage(m *message) {
+	c.buf.WriteByte('\n')
+	for _, line := range m.docs {
+		c.buf.WriteString(line)
+		c.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&c.buf, "type %s struct {\n", c.types[m.name])
+
+	fields := append([]*field(nil), m.fields...)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].number < fields[j].number
+	})
+	prev := 0
+	for _, f := range fields {
+		if f.number != prev+1 {
+			c.report(f.pos, "field %s: number %d follows %d; Colfer indices are positional, so the gap is closed", f.name, f.number, prev)
+		}
+		prev = f.number
+
+		typ, ok := c.fieldType(f)
+		if !ok {
+			continue
+		}
+		for _, line := range f.docs {
+			c.buf.WriteByte('\t')
+			c.buf.WriteString(line)
+			c.buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&c.buf, "\t%s %s\n", c.ident(f.pos, camelCase(f.name)), typ)
+	}
+	c.buf.WriteString("}\n")
+}
+
+// fieldType returns the Colfer datatype, with false for omission.
+func (c *converter) fieldType(f *field) (string, bool) {
+	typ, ok := scalars[strings.TrimPrefix(f.typ, ".")]
+	if ok {
+		switch f.typ {
+		case "sint32", "sint64":
+			c.report(f.pos, "field %s: zig-zag encoding of %s has no Colfer equivalent; mapped to %s", f.name, f.typ, typ)
+		}
+	} else {
+		name := c.resolve(f.typ, f.scope)
+		switch t := c.types[name]; {
+		case t == "enum":
+			typ = "int32"
+			if !f.repeated {
+				c.report(f.pos, "field %s: enum %s has no Colfer equivalent; mapped to int32", f.name, f.typ)
+			}
+		case t != "":
+			typ = t
+		default:
+			typ = c.external(f)
+		}
+	}
+
+	if !f.repeated {
+		return typ, true
+	}
+	switch typ {
+	case "float32", "float64", "text", "binary":
+		return "[]" + typ, true
+	case "bool", "uint8", "uint16", "uint32", "uint64", "int32", "int64", "timestamp":
+		c.report(f.pos, "field %s: repeated %s has no Colfer equivalent; omitted", f.name, f.typ)
+		return "", false
+	default:
+		return "[]" + typ, true
+	}
+}
+
+// resolve returns the qualified name within the package conform the scoping
+// rules of Protocol Buffers, or the empty string when not found.
+func (c *converter) resolve(name, scope string) string {
+	if strings.HasPrefix(name, ".") {
+		name = strings.TrimPrefix(name[1:], c.file.pkg+".")
+		if _, ok := c.types[name]; ok {
+			return name
+		}
+		return ""
+	}
+
+	for {
+		qname := name
+		if scope != "" {
+			qname = scope + "." + name
+		}
+		if _, ok := c.types[qname]; ok {
+			return qname
+		}
+		if scope == "" {
+			break
+		}
+		if i := strings.LastIndexByte(scope, '.'); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+
+	if c.file.pkg != "" && strings.HasPrefix(name, c.file.pkg+".") {
+		name = name[len(c.file.pkg)+1:]
+		if _, ok := c.types[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// external returns a cross-package reference for a type from another file.
+func (c *converter) external(f *field) string {
+	name := strings.TrimPrefix(f.typ, ".")
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		c.report(f.pos, "field %s: type %s not found; assumed in package", f.name, name)
+		return name
+	}
+
+	pkg := name[:i]
+	if j := strings.LastIndexByte(pkg, '.'); j >= 0 {
+		pkg = pkg[j+1:]
+	}
+	ref := pkg + "." + name[i+1:]
+	c.report(f.pos, "field %s: type %s from another file; mapped to %s", f.name, name, ref)
+	return ref
+}
+
+// camelCase returns the lower camel case for a snake case name.
+func camelCase(name string) string {
+	var buf bytes.Buffer
+	upper := false
+	for i, r := range name {
+		switch {
+		case r == '_' && i != 0:
+			upper = true
+		case upper:
+			buf.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
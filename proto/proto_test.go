@@ -0,0 +1,119 @@
+package proto
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestConvertBench(t *testing.T) {
+	src, err := ioutil.ReadFile("../testdata/bench/scheme.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, issues, err := Convert("scheme.proto", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// field number 3 is absent
+	const wantIssue = "scheme.proto:8:2: field port: number 4 follows 2; Colfer indices are positional, so the gap is closed"
+	if len(issues) != 1 || issues[0].String() != wantIssue {
+		t.Errorf("got issues %q, want %q", issues, wantIssue)
+	}
+
+	const want = `package bench
+
+type ProtoBuf struct {
+	key   int64
+	host  text
+	port  uint32
+	size  int64
+	hash  uint64
+	ratio float64
+	route bool
+}
+`
+	if got := string(schema); got != want {
+		t.Errorf("got schema:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertIssues(t *testing.T) {
+	const src = `syntax = "proto3";
+package a.b;
+
+// M has a doc.
+message M {
+  map<string, int32> labels = 5;
+  oneof choice {
+    string name = 2;
+  }
+  repeated bool flags = 3;
+  sint32 delta = 1;
+  N nested = 4;
+  message N {
+    repeated M parents = 1;
+    bool root = 3;
+  }
+}
+`
+	schema, issues, err := Convert("x.proto", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantIssues := []string{
+		"x.proto:6:3: map field has no Colfer equivalent",
+		"x.proto:7:3: oneof choice has no Colfer equivalent; the options are mapped as regular fields",
+		"x.proto:10:3: field flags: repeated bool has no Colfer equivalent; omitted",
+		"x.proto:11:3: field delta: zig-zag encoding of sint32 has no Colfer equivalent; mapped to int32",
+		"x.proto:15:5: field root: number 3 follows 1; Colfer indices are positional, so the gap is closed",
+	}
+	if len(issues) != len(wantIssues) {
+		t.Errorf("got %d issues %q, want %d", len(issues), issues, len(wantIssues))
+	} else {
+		for i, issue := range issues {
+			if got := issue.String(); got != wantIssues[i] {
+				t.Errorf("issue %d: got %q, want %q", i, got, wantIssues[i])
+			}
+		}
+	}
+
+	const want = `package b
+
+// M has a doc.
+type M struct {
+	delta  int32
+	name   text
+	nested M_N
+}
+
+type M_N struct {
+	parents []M
+	root    bool
+}
+`
+	if got := string(schema); got != want {
+		t.Errorf("got schema:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConvertSyntaxError(t *testing.T) {
+	for _, src := range []string{
+		`message M { int32 x = ; }`,
+		`message M { int32 x = 1 }`,
+		`message M {`,
+		`enum E { A = 1;`,
+		`bogus;`,
+		`/* unterminated`,
+		`"abc`,
+	} {
+		_, _, err := Convert("x.proto", []byte(src))
+		if err == nil {
+			t.Errorf("%q: no error", src)
+		} else if !strings.HasPrefix(err.Error(), "colf: x.proto:") {
+			t.Errorf("%q: got error %q, want position prefix", src, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package colfer
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pascaldekloe/name"
+)
+
+// protoNatives sets the Protocol Buffers names and datatypes.
+func protoNatives(packages Packages) {
+	for _, p := range packages {
+		p.NameNative = strings.Replace(p.Name, "/", ".", -1)
+	}
+
+	for _, p := range packages {
+		for _, s := range p.Structs {
+			s.NameNative = s.NameTitle()
+
+			for _, f := range s.Fields {
+				f.NameNative = name.SnakeCase(f.Name)
+
+				switch f.Type {
+				default:
+					f.TypeNative = f.TypeRef.NameTitle()
+					if f.TypeRef.Pkg != p {
+						// fully qualified, as names resolve
+						// relative to the current package
+						f.TypeNative = "." + f.TypeRef.Pkg.NameNative + "." + f.TypeNative
+					}
+				case "bool", "uint32", "uint64", "int32", "int64":
+					f.TypeNative = f.Type
+				case "uint8", "uint16":
+					f.TypeNative = "uint32"
+				case "float32":
+					f.TypeNative = "float"
+				case "float64":
+					f.TypeNative = "double"
+				case "timestamp":
+					f.TypeNative = ".google.protobuf.Timestamp"
+				case "text":
+					f.TypeNative = "string"
+				case "binary":
+					f.TypeNative = "bytes"
+				}
+			}
+		}
+	}
+}
+
+// GenerateProto writes the proto3 definitions into the respective
+// "Colfer.proto" files. The field numbers are the Colfer indices plus one.
+func GenerateProto(basedir string, packages Packages) error {
+	sources, err := ProtoSources(packages)
+	if err != nil {
+		return err
+	}
+	return sources.Write(basedir)
+}
+
+// ProtoSources returns the definitions of GenerateProto in memory.
+func ProtoSources(packages Packages) (Sources, error) {
+	protoNatives(packages)
+
+	t := template.New("proto")
+	t.Funcs(template.FuncMap{"inc": func(i int) int { return i + 1 }})
+	template.Must(t.Parse(protoCode))
+
+	sources := make(Sources)
+	for _, p := range packages {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, p); err != nil {
+			return nil, err
+		}
+		sources[p.Name+"/Colfer.proto"] = buf.Bytes()
+	}
+	return sources, nil
+}
+
+const protoCode = `// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}}.
+
+syntax = "proto3";
+
+{{with .DocText "// "}}{{.}}
+{{end -}}
+package {{.NameNative}};
+{{if .HasTimestamp}}
+import "google/protobuf/timestamp.proto";
+{{- end}}
+{{- range .Refs}}
+import "{{.Name}}/Colfer.proto";
+{{- end}}
+{{range .Structs}}
+{{with .DocText "// "}}{{.}}
+{{end -}}
+message {{.NameNative}} {
+{{- range .Fields}}
+{{- with .DocText "\t// "}}
+{{.}}
+{{- end}}
+	{{if .TypeList}}repeated {{end}}{{.TypeNative}} {{.NameNative}} = {{inc .Index}};
+{{- end}}
+}
+{{end}}`
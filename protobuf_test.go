@@ -0,0 +1,56 @@
+package colfer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// goldenSchemas covers cross-package references and all datatypes.
+var goldenSchemas = []string{"testdata/test.colf", "testdata/break.colf", "testdata/break-refs.colf"}
+
+// verifyGoldenSources compares the output of generate for goldenSchemas
+// with the files in dir.
+func verifyGoldenSources(t *testing.T, generate func(Packages) (Sources, error), dir string) {
+	sources, err := Compile(goldenSchemas, nil, Options{Generator: generate})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			want = append(want, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := sources.Paths()
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got files %q, want %q", paths, want)
+	}
+
+	for _, p := range paths {
+		want, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got := sources[p]; !bytes.Equal(got, want) {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", p, got, want)
+		}
+	}
+}
+
+func TestProtoSources(t *testing.T) {
+	verifyGoldenSources(t, ProtoSources, "testdata/protobuf")
+}
@@ -5,12 +5,19 @@ package internal
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"sync"
+	"unicode/utf8"
 )
 
 var intconv = binary.BigEndian
 
+// colferBuffers recycles the encoding space of MarshalBinary.
+var colferBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
 // Colfer configuration attributes
 var (
 	// ColferSizeMax is the upper limit for serial byte sizes.
@@ -39,6 +46,90 @@ func (i ColferTail) Error() string {
 	return fmt.Sprintf("colfer: data continuation at byte %d", i)
 }
 
+// colferAppendJSONString appends s as a JSON string, with the escapes of
+// JSON.stringify from ECMAScript. Malformed UTF-8 is replaced with U+FFFD.
+func colferAppendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, "\uFFFD"...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < ' ' {
+				buf = append(buf, '\\', 'u', '0', '0', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+
+// colferJSONUint64 parses a JSON string with the decimal value, or a number up
+// to Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have lost
+// precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONUint64(data []byte) (uint64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(s, 10, 64)
+	}
+	x, err := strconv.ParseUint(string(data), 10, 64)
+	if err == nil && x > 1<<53-1 {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
+// colferJSONInt64 parses a JSON string with the decimal value, or a number
+// within ±Number.MAX_SAFE_INTEGER from ECMAScript, as larger numbers may have
+// lost precision in an IEEE 754 double already. Null reads as zero.
+func colferJSONInt64(data []byte) (int64, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+	x, err := strconv.ParseInt(string(data), 10, 64)
+	if err == nil && (x > 1<<53-1 || x < -(1<<53-1)) {
+		return 0, fmt.Errorf("number %s exceeds Number.MAX_SAFE_INTEGER precision; use a string", data)
+	}
+	return x, err
+}
+
 // Header is a prefix for requests and responses.
 type Header struct {
 	SeqID uint64
@@ -51,7 +142,7 @@ type Header struct {
 }
 
 // MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
-// If the buffer is too small, MarshalTo will panic.
+// If the buffer is too small, MarshalTo will panic. See MarshalInto for a check.
 func (o *Header) MarshalTo(buf []byte) int {
 	var i int
 
@@ -168,13 +259,97 @@ func (o *Header) MarshalLen() (int, error) {
 // MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
 // The error return option is internal.ColferMax.
 func (o *Header) MarshalBinary() (data []byte, err error) {
-	l, err := o.MarshalLen()
+	// A single traversal into reused space saves both the MarshalLen
+	// pass and the growth of a new buffer. The copy is exact in size.
+	p := colferBuffers.Get().(*[]byte)
+	buf, err := o.MarshalAppend((*p)[:0])
+	if err == nil {
+		data = make([]byte, len(buf))
+		copy(data, buf)
+	}
+	*p = buf
+	colferBuffers.Put(p)
+	return data, err
+}
+
+// MarshalAppend encodes o as Colfer and appends the result to dst, which grows
+// as needed, like the append built-in. The data is traversed only once, i.e.,
+// no MarshalLen is needed, which makes it the fastest option for buffer reuse.
+// The error return option is internal.ColferMax, in which case dst is returned as is.
+func (o *Header) MarshalAppend(dst []byte) ([]byte, error) {
+	buf := dst
+	offset := len(buf)
+
+	if x := o.SeqID; x >= 1<<49 {
+		buf = append(buf, 0|0x80, byte(x>>56), byte(x>>48), byte(x>>40), byte(x>>32), byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 0)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if l := len(o.Method); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field internal.header.method exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Method...)
+	}
+
+	if l := len(o.Error); l != 0 {
+		if l > ColferSizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field internal.header.error exceeds %d bytes", ColferSizeMax))
+		}
+		buf = append(buf, 2)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Error...)
+	}
+
+	if x := o.BodySize; x >= 1<<21 {
+		buf = append(buf, 3|0x80, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 3)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-offset > ColferSizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct internal.header exceeds %d bytes", ColferSizeMax))
+	}
+	return buf, nil
+}
+
+// MarshalInto encodes o as Colfer into buf and returns the number of bytes written.
+// Unlike MarshalTo, the size is checked, without a MarshalLen traversal.
+// The error return options are io.ErrShortBuffer and internal.ColferMax, in which case the content of buf is undefined.
+func (o *Header) MarshalInto(buf []byte) (int, error) {
+	// the capacity limit makes append reallocate instead of overflow
+	data, err := o.MarshalAppend(buf[:0:len(buf)])
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if len(data) > len(buf) {
+		return 0, io.ErrShortBuffer
 	}
-	data = make([]byte, l)
-	o.MarshalTo(data)
-	return data, nil
+	return len(data), nil
 }
 
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
@@ -364,3 +539,81 @@ func (o *Header) UnmarshalBinary(data []byte) error {
 	}
 	return err
 }
+
+// AppendJSON appends the JSON representation of o to dst, which grows as
+// needed, like the append built-in. The output is canonical, i.e., it matches
+// the JSON of the other languages byte for byte.
+func (o *Header) AppendJSON(dst []byte) []byte {
+	buf := append(dst, '{')
+	buf = append(buf, "\"seqID\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendUint(buf, o.SeqID, 10)
+	buf = append(buf, '"')
+	buf = append(buf, ",\"method\":"...)
+	buf = colferAppendJSONString(buf, o.Method)
+	buf = append(buf, ",\"error\":"...)
+	buf = colferAppendJSONString(buf, o.Error)
+	buf = append(buf, ",\"bodySize\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.BodySize), 10)
+
+	return append(buf, '}')
+}
+
+// MarshalJSON encodes o as JSON conform encoding/json.Marshaler. See
+// AppendJSON for details. Note that package json applies HTML escaping on
+// the result, unless disabled with Encoder.SetEscapeHTML.
+func (o *Header) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil), nil
+}
+
+// UnmarshalJSON decodes data as JSON conform encoding/json.Unmarshaler. Any
+// previous content of o is discarded. Absent fields and null values read as
+// the zero value, except for null, which leaves o as is.
+// The error return options include internal.ColferMax.
+func (o *Header) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("colfer: struct internal.header: %s", err)
+	}
+	if doc == nil {
+		return nil // null
+	}
+
+	*o = Header{}
+	for name, raw := range doc {
+		switch name {
+		case "seqID":
+			v, err := colferJSONUint64(raw)
+			if err != nil {
+				return fmt.Errorf("colfer: internal.header.seqID: %s", err)
+			}
+			o.SeqID = v
+		case "method":
+			if err := json.Unmarshal(raw, &o.Method); err != nil {
+				return fmt.Errorf("colfer: internal.header.method: %s", err)
+			}
+			if l := len(o.Method); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: internal.header.method size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "error":
+			if err := json.Unmarshal(raw, &o.Error); err != nil {
+				return fmt.Errorf("colfer: internal.header.error: %s", err)
+			}
+			if l := len(o.Error); l > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: internal.header.error size %d exceeds %d bytes", l, ColferSizeMax))
+			}
+		case "bodySize":
+			if err := json.Unmarshal(raw, &o.BodySize); err != nil {
+				return fmt.Errorf("colfer: internal.header.bodySize: %s", err)
+			}
+		default:
+			return fmt.Errorf("colfer: struct internal.header has no field %q", name)
+		}
+	}
+	return nil
+}
+
+// Reset sets o to the zero value.
+func (o *Header) Reset() {
+	*o = Header{}
+}
@@ -7,36 +7,34 @@ import (
 	"net/rpc"
 
 	"github.com/pascaldekloe/colfer/rpc/internal"
+	"github.com/pascaldekloe/colfer/stream"
 )
 
 // colferer covers the encoding methods.
 type colferer interface {
-	MarshalTo([]byte) int
-	MarshalLen() (int, error)
-	Unmarshal([]byte) (int, error)
+	stream.Marshaler
+	stream.Unmarshaler
 }
 
 type codec struct {
 	conn io.ReadWriteCloser
 
-	// buf is the read buffer.
-	buf []byte
-
-	// offset is the index of the first data byte in buf.
-	offset int
-
-	// i is the index of the data end (exclusive) in buf.
-	i int
+	dec *stream.Decoder
+	enc *stream.Encoder
 
 	// header holds the last received header. (reusable)
 	header internal.Header
+
+	// body is the write buffer for the body serial. (reusable)
+	body []byte
 }
 
 // NewClientCodec returns a new RPC codec.
 func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
 	return &codec{
 		conn: conn,
-		buf:  make([]byte, 32*1024),
+		dec:  stream.NewDecoder(conn),
+		enc:  stream.NewEncoder(conn),
 	}
 }
 
@@ -44,13 +42,14 @@ func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
 func NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
 	return &codec{
 		conn: conn,
-		buf:  make([]byte, 32*1024),
+		dec:  stream.NewDecoder(conn),
+		enc:  stream.NewEncoder(conn),
 	}
 }
 
 func (c *codec) ReadRequestHeader(r *rpc.Request) error {
 	c.header = internal.Header{} // reset
-	if err := c.decode(&c.header); err != nil {
+	if err := c.dec.Decode(&c.header); err != nil {
 		return err
 	}
 
@@ -61,7 +60,7 @@ func (c *codec) ReadRequestHeader(r *rpc.Request) error {
 
 func (c *codec) ReadResponseHeader(r *rpc.Response) error {
 	c.header = internal.Header{} // reset
-	if err := c.decode(&c.header); err != nil {
+	if err := c.dec.Decode(&c.header); err != nil {
 		return err
 	}
 
@@ -73,28 +72,26 @@ func (c *codec) ReadResponseHeader(r *rpc.Response) error {
 
 func (c *codec) ReadRequestBody(body interface{}) error {
 	if body == nil {
-		c.skip(int(c.header.BodySize))
-		return nil
+		return c.dec.Discard(int(c.header.BodySize))
 	}
 
 	b, ok := body.(colferer)
 	if !ok {
 		return fmt.Errorf("colfer/rpc: body type %T not a Colfer type", body)
 	}
-	return c.decode(b)
+	return c.dec.Decode(b)
 }
 
 func (c *codec) ReadResponseBody(body interface{}) error {
 	if body == nil {
-		c.skip(int(c.header.BodySize))
-		return nil
+		return c.dec.Discard(int(c.header.BodySize))
 	}
 
 	b, ok := body.(colferer)
 	if !ok {
 		return fmt.Errorf("colfer/rpc: body type %T not a Colfer type", body)
 	}
-	return c.decode(b)
+	return c.dec.Decode(b)
 }
 
 func (c *codec) WriteRequest(header *rpc.Request, body interface{}) error {
@@ -129,81 +126,19 @@ func (c *codec) Close() error {
 }
 
 func (c *codec) encode(h *internal.Header, body colferer) error {
-	bl, err := body.MarshalLen()
+	// body first for the size in the header
+	var err error
+	c.body, err = body.MarshalAppend(c.body[:0])
 	if err != nil {
 		return err
 	}
+	h.BodySize = uint32(len(c.body))
 
-	h.BodySize = uint32(bl)
-
-	hl, err := h.MarshalLen()
-	if err != nil {
+	if err := c.enc.Encode(h); err != nil {
 		return err
 	}
-
-	buf := make([]byte, hl+bl)
-	h.MarshalTo(buf)
-	body.MarshalTo(buf[hl:])
-
-	_, err = c.conn.Write(buf)
-	return err
-}
-
-func (c *codec) decode(v colferer) error {
-	for {
-		if c.offset < c.i {
-			n, err := v.Unmarshal(c.buf[c.offset:c.i])
-			switch err {
-			case nil:
-				c.offset += n
-				return nil
-
-			default:
-				return err
-
-			case io.EOF:
-			}
-		}
-		// not enough data
-
-		if c.i >= len(c.buf) {
-			if c.offset == 0 {
-				// grow
-				bigger := make([]byte, len(c.buf)*4)
-				copy(bigger, c.buf)
-				c.buf = bigger
-			} else {
-				// move data to start of buffer
-				copy(c.buf, c.buf[c.offset:])
-				c.i -= c.offset
-				c.offset = 0
-			}
-		}
-
-		n, err := c.conn.Read(c.buf[c.i:])
-		c.i += n
-		if err != nil {
-			return err
-		}
-	}
-}
-
-// skip advances n bytes in the stream.
-func (c *codec) skip(n int) error {
-	for {
-		pending := c.i - c.offset
-		if n <= pending {
-			c.offset += n
-			return nil
-		}
-
-		n -= pending
-		c.offset = 0
-
-		var err error
-		c.i, err = c.conn.Read(c.buf)
-		if err != nil {
-			return err
-		}
+	if _, err := c.enc.Write(c.body); err != nil {
+		return err
 	}
+	return c.enc.Flush()
 }
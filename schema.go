@@ -8,9 +8,58 @@ import (
 	"go/parser"
 	"go/token"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
+// SchemaError is a definition violation.
+type SchemaError struct {
+	// Pos is the location of the violation.
+	Pos token.Position
+	// Msg is the description, including the "colfer: " prefix.
+	Msg string
+}
+
+// Error honors the error interface.
+func (e *SchemaError) Error() string {
+	return e.Msg
+}
+
+func schemaErrorf(pos token.Position, format string, args ...interface{}) error {
+	return &SchemaError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Sources are generated files in memory, keyed by their path relative to the
+// base directory. The paths use slash as a separator.
+type Sources map[string][]byte
+
+// Paths returns the keys in ascending order.
+func (s Sources) Paths() []string {
+	paths := make([]string, 0, len(s))
+	for path := range s {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Write saves each file in basedir, including any parent directories.
+func (s Sources) Write(basedir string) error {
+	for _, path := range s.Paths() {
+		file := filepath.Join(basedir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, s[path], 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Format normalizes the file's content.
 // The content of file is expected to be syntactically correct.
 func Format(file string) (changed bool, err error) {
@@ -19,7 +68,7 @@ func Format(file string) (changed bool, err error) {
 		return false, err
 	}
 
-	clean, err := format.Source(orig)
+	clean, err := FormatSource(orig)
 	if err != nil {
 		return false, fmt.Errorf("colfer: format %q: %s", file, err)
 	}
@@ -34,13 +83,30 @@ func Format(file string) (changed bool, err error) {
 	return true, nil
 }
 
+// FormatSource returns the normalized content of a schema file.
+func FormatSource(src []byte) ([]byte, error) {
+	return format.Source(src)
+}
+
 // ParseFiles returns the schema definitions.
 func ParseFiles(files []string) ([]*Package, error) {
+	return ParseSources(files, nil)
+}
+
+// ParseSources returns the schema definitions like ParseFiles does, with the
+// content of files read from sources when present. Violations of the schema
+// definitions are reported as a *SchemaError. Syntax errors are a
+// go/scanner.ErrorList.
+func ParseSources(files []string, sources map[string][]byte) ([]*Package, error) {
 	var packages []*Package
 
 	fileSet := token.NewFileSet()
 	for _, file := range files {
-		fileAST, err := parser.ParseFile(fileSet, file, nil, parser.ParseComments|parser.AllErrors)
+		var src interface{}
+		if b, ok := sources[file]; ok {
+			src = b
+		}
+		fileAST, err := parser.ParseFile(fileSet, file, src, parser.ParseComments|parser.AllErrors)
 		if err != nil {
 			return nil, err
 		}
@@ -52,22 +118,40 @@ func ParseFiles(files []string) ([]*Package, error) {
 			}
 		}
 		if pkg == nil {
-			pkg = &Package{Name: fileAST.Name.Name}
+			pkg = &Package{Name: fileAST.Name.Name, Pos: fileSet.Position(fileAST.Name.Pos())}
 			packages = append(packages, pkg)
 		}
 
 		pkg.SchemaFiles = append(pkg.SchemaFiles, path.Base(file))
 
 		pkg.Docs = append(pkg.Docs, docs(fileAST.Doc)...)
+		sizeMax, listMax, err := limitDirectives(fileSet, fileAST.Doc)
+		if err != nil {
+			return nil, err
+		}
+		if sizeMax != "" {
+			if pkg.SizeMax != "" && pkg.SizeMax != sizeMax {
+				return nil, schemaErrorf(fileSet.Position(fileAST.Package), "colfer: package %s size maximum %q conflicts with %q", pkg.Name, sizeMax, pkg.SizeMax)
+			}
+			pkg.SizeMax = sizeMax
+			pkg.SizeMaxDirective = true
+		}
+		if listMax != "" {
+			if pkg.ListMax != "" && pkg.ListMax != listMax {
+				return nil, schemaErrorf(fileSet.Position(fileAST.Package), "colfer: package %s list maximum %q conflicts with %q", pkg.Name, listMax, pkg.ListMax)
+			}
+			pkg.ListMax = listMax
+			pkg.ListMaxDirective = true
+		}
 
 		// switch through the AST types
 		for _, decl := range fileAST.Decls {
 			switch decl := decl.(type) {
 			default:
-				return nil, fmt.Errorf("colfer: unsupported declaration type %T", decl)
+				return nil, schemaErrorf(fileSet.Position(decl.Pos()), "colfer: unsupported declaration type %T", decl)
 			case *ast.GenDecl:
 				for _, spec := range decl.Specs {
-					if err := addSpec(pkg, decl, spec, file); err != nil {
+					if err := addSpec(fileSet, pkg, decl, spec, file); err != nil {
 						return nil, err
 					}
 				}
@@ -80,7 +164,7 @@ func ParseFiles(files []string) ([]*Package, error) {
 		for _, s := range pkg.Structs {
 			qname := s.String()
 			if dupe, ok := names[qname]; ok {
-				return nil, fmt.Errorf("colfer: duplicate struct definition %q in file %s and %s", qname, dupe.SchemaFile, s.SchemaFile)
+				return nil, schemaErrorf(s.Pos, "colfer: duplicate struct definition %q in file %s and %s", qname, dupe.SchemaFile, s.SchemaFile)
 			}
 			names[qname] = s
 		}
@@ -96,7 +180,7 @@ func ParseFiles(files []string) ([]*Package, error) {
 						switch t {
 						case "float32", "float64", "text", "binary":
 						default:
-							return nil, fmt.Errorf("colfer: unsupported lists type %q for field %s", t, f.String())
+							return nil, schemaErrorf(f.TypePos, "colfer: unsupported lists type %q for field %s", t, f.String())
 						}
 					}
 					continue
@@ -107,7 +191,7 @@ func ParseFiles(files []string) ([]*Package, error) {
 				if f.TypeRef, ok = names[pkg.Name+"."+t]; ok {
 					continue
 				}
-				return nil, fmt.Errorf("colfer: unknown datatype %q for field %s", t, f.String())
+				return nil, schemaErrorf(f.TypePos, "colfer: unknown datatype %q for field %s", t, f.String())
 			}
 		}
 	}
@@ -115,20 +199,32 @@ func ParseFiles(files []string) ([]*Package, error) {
 	return packages, nil
 }
 
-func addSpec(pkg *Package, decl *ast.GenDecl, spec ast.Spec, file string) error {
+func addSpec(fileSet *token.FileSet, pkg *Package, decl *ast.GenDecl, spec ast.Spec, file string) error {
 	switch spec := spec.(type) {
 	default:
-		return fmt.Errorf("colfer: unsupported specification type %T", spec)
+		return schemaErrorf(fileSet.Position(spec.Pos()), "colfer: unsupported specification type %T", spec)
 	case *ast.TypeSpec:
 		switch t := spec.Type.(type) {
 		default:
-			return fmt.Errorf("colfer: unsupported data type %T", t)
+			return schemaErrorf(fileSet.Position(t.Pos()), "colfer: unsupported data type %T", t)
 		case *ast.StructType:
-			s := &Struct{Pkg: pkg, Name: spec.Name.Name, SchemaFile: path.Base(file)}
+			s := &Struct{Pkg: pkg, Name: spec.Name.Name, SchemaFile: path.Base(file), Pos: fileSet.Position(spec.Name.Pos())}
 			pkg.Structs = append(pkg.Structs, s)
 
 			s.Docs = append(docs(decl.Doc), docs(spec.Doc)...)
-			if err := mapStruct(s, t); err != nil {
+			for _, g := range []*ast.CommentGroup{decl.Doc, spec.Doc} {
+				sizeMax, listMax, err := limitDirectives(fileSet, g)
+				if err != nil {
+					return err
+				}
+				if sizeMax != "" {
+					s.SizeMax = sizeMax
+				}
+				if listMax != "" {
+					s.ListMax = listMax
+				}
+			}
+			if err := mapStruct(fileSet, s, t); err != nil {
 				return err
 			}
 		}
@@ -137,17 +233,25 @@ func addSpec(pkg *Package, decl *ast.GenDecl, spec ast.Spec, file string) error
 	return nil
 }
 
-func mapStruct(dst *Struct, src *ast.StructType) error {
+func mapStruct(fileSet *token.FileSet, dst *Struct, src *ast.StructType) error {
 	for i, f := range src.Fields.List {
 		field := Field{Struct: dst, Index: i}
 		dst.Fields = append(dst.Fields, &field)
 
 		if len(f.Names) == 0 {
-			return fmt.Errorf("colfer: missing name for field %d", i)
+			return schemaErrorf(fileSet.Position(f.Pos()), "colfer: missing name for field %d", i)
 		}
 		field.Name = f.Names[0].Name
+		field.Pos = fileSet.Position(f.Names[0].Pos())
 
 		field.Docs = docs(f.Doc)
+		if f.Doc != nil {
+			for _, c := range f.Doc.List {
+				if strings.HasPrefix(c.Text, directivePrefix) {
+					return schemaErrorf(fileSet.Position(c.Pos()), "colfer: directive %q not supported for field %s", c.Text, field.String())
+				}
+			}
+		}
 
 		expr := f.Type
 		for {
@@ -158,15 +262,17 @@ func mapStruct(dst *Struct, src *ast.StructType) error {
 				continue
 			case *ast.Ident:
 				field.Type = t.Name
+				field.TypePos = fileSet.Position(t.Pos())
 			case *ast.SelectorExpr:
 				switch pkgIdent := t.X.(type) {
 				case *ast.Ident:
 					field.Type = pkgIdent.Name + "." + t.Sel.Name
+					field.TypePos = fileSet.Position(t.Pos())
 				default:
-					return fmt.Errorf("colfer: unknown datatype selector expression %T for field %s", pkgIdent, field.String())
+					return schemaErrorf(fileSet.Position(t.Pos()), "colfer: unknown datatype selector expression %T for field %s", pkgIdent, field.String())
 				}
 			default:
-				return fmt.Errorf("colfer: unknown datatype declaration %T for field %s", t, field.String())
+				return schemaErrorf(fileSet.Position(t.Pos()), "colfer: unknown datatype declaration %T for field %s", t, field.String())
 			}
 			break
 		}
@@ -175,12 +281,61 @@ func mapStruct(dst *Struct, src *ast.StructType) error {
 	return nil
 }
 
+// docs returns the comment texts, excluding directives. The separator line
+// which gofmt puts before directives is dropped too.
 func docs(g *ast.CommentGroup) []string {
 	var a []string
 	if g != nil {
 		for _, c := range g.List {
-			a = append(a, c.Text)
+			if !strings.HasPrefix(c.Text, directivePrefix) {
+				a = append(a, c.Text)
+			}
 		}
 	}
+	for len(a) != 0 && a[len(a)-1] == "//" {
+		a = a[:len(a)-1]
+	}
 	return a
 }
+
+// directivePrefix marks a compiler instruction in a comment, as in
+// "//colf:sizemax 1024".
+const directivePrefix = "//colf:"
+
+// limitDirectives returns the expressions of the "sizemax" and "listmax"
+// directives in g, if any.
+func limitDirectives(fileSet *token.FileSet, g *ast.CommentGroup) (sizeMax, listMax string, err error) {
+	if g == nil {
+		return "", "", nil
+	}
+	for _, c := range g.List {
+		if !strings.HasPrefix(c.Text, directivePrefix) {
+			continue
+		}
+		pos := fileSet.Position(c.Pos())
+
+		line := c.Text[len(directivePrefix):]
+		name, expr := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			name, expr = line[:i], strings.TrimSpace(line[i:])
+		}
+
+		var dst *string
+		switch name {
+		case "sizemax":
+			dst = &sizeMax
+		case "listmax":
+			dst = &listMax
+		default:
+			return "", "", schemaErrorf(pos, "colfer: unknown directive %q", c.Text)
+		}
+		if *dst != "" {
+			return "", "", schemaErrorf(pos, "colfer: duplicate directive %q", c.Text)
+		}
+		if _, err := EvalLimit(expr, 0); err != nil || expr == "" {
+			return "", "", schemaErrorf(pos, "colfer: directive %q needs a positive integer expression", c.Text)
+		}
+		*dst = expr
+	}
+	return sizeMax, listMax, nil
+}
@@ -0,0 +1,168 @@
+package colfer
+
+import (
+	"fmt"
+	"math"
+)
+
+// SizeBounds is the serial size range of a struct.
+type SizeBounds struct {
+	// Min is the smallest serial size in bytes, which is the zero value.
+	Min int
+	// Max is the largest serial size in bytes, conform the limits.
+	Max int
+	// Fixed is set when Max does not depend on the limits, i.e., the
+	// struct has no text, no binaries and no lists, not even through
+	// any of its references.
+	Fixed bool
+	// Unbounded is set when the struct can contain itself. Only the size
+	// limit constrains Max then.
+	Unbounded bool
+}
+
+// SizeBounds returns the serial size range. The limit expressions must be
+// constant integers, like the command-line options of colf(1).
+func (s *Struct) SizeBounds() (SizeBounds, error) {
+	calc := sizeCalc{
+		memo:   make(map[*Struct]sizeResult),
+		active: make(map[*Struct]bool),
+	}
+	r, err := calc.structMax(s)
+	if err != nil {
+		return SizeBounds{}, err
+	}
+	sizeMax, _, err := structLimits(s)
+	if err != nil {
+		return SizeBounds{}, err
+	}
+
+	b := SizeBounds{Min: 1, Unbounded: !r.bounded}
+	if r.bounded && r.max <= int64(sizeMax) {
+		b.Max = int(r.max)
+		b.Fixed = r.fixed
+	} else {
+		b.Max = sizeMax
+	}
+	return b, nil
+}
+
+// FixedSizeMax returns the upper limit for the serial size, or zero when the
+// size depends on the limits.
+func (s *Struct) FixedSizeMax() int {
+	b, err := s.SizeBounds()
+	if err != nil || !b.Fixed {
+		return 0
+	}
+	return b.Max
+}
+
+// structLimits returns the evaluated limits which apply to s.
+func structLimits(s *Struct) (sizeMax, listMax int, err error) {
+	sizeMax, err = EvalLimit(s.SizeMaxExpr(), defaultSizeMax)
+	if err != nil {
+		return 0, 0, fmt.Errorf("colf: struct %s size maximum: %s", s, err)
+	}
+	listMax, err = EvalLimit(s.ListMaxExpr(), defaultListMax)
+	if err != nil {
+		return 0, 0, fmt.Errorf("colf: struct %s list maximum: %s", s, err)
+	}
+	return sizeMax, listMax, nil
+}
+
+// sizeResult is the structural upper limit of a struct.
+type sizeResult struct {
+	max     int64
+	fixed   bool
+	bounded bool
+}
+
+// sizeCalc resolves the upper limits of structs through their references.
+type sizeCalc struct {
+	memo map[*Struct]sizeResult
+	// active has the structs in progress, to detect recursion.
+	active map[*Struct]bool
+}
+
+func (c *sizeCalc) structMax(s *Struct) (sizeResult, error) {
+	if r, ok := c.memo[s]; ok {
+		return r, nil
+	}
+	if c.active[s] {
+		// any struct in progress is part of the cycle
+		return sizeResult{}, nil
+	}
+	c.active[s] = true
+	defer delete(c.active, s)
+
+	sizeMax, listMax, err := structLimits(s)
+	if err != nil {
+		return sizeResult{}, err
+	}
+
+	r := sizeResult{max: 1, fixed: true, bounded: true} // struct end
+	for _, f := range s.Fields {
+		var elem int64
+		switch f.Type {
+		case "bool":
+			elem = 0
+		case "uint8":
+			elem = 1
+		case "uint16":
+			elem = 2
+		case "uint32", "float32":
+			elem = 4
+		case "uint64", "float64":
+			elem = 8
+		case "int32":
+			elem = 5
+		case "int64":
+			elem = 9
+		case "timestamp":
+			elem = 8 + 4
+		case "text", "binary":
+			r.fixed = false
+			elem = varintLen(int64(sizeMax)) + int64(sizeMax)
+		default:
+			ref, err := c.structMax(f.TypeRef)
+			if err != nil {
+				return sizeResult{}, err
+			}
+			r.fixed = r.fixed && ref.fixed
+			r.bounded = r.bounded && ref.bounded
+			elem = ref.max
+		}
+
+		n := 1 + elem // header
+		if f.TypeList {
+			r.fixed = false
+			n = 1 + varintLen(int64(listMax)) + saturatedMul(int64(listMax), elem)
+		}
+		r.max = saturatedAdd(r.max, n)
+	}
+
+	c.memo[s] = r
+	return r, nil
+}
+
+// varintLen returns the number of bytes for x in the 7-bit encoding.
+func varintLen(x int64) int64 {
+	n := int64(1)
+	for ; x >= 0x80; x >>= 7 {
+		n++
+	}
+	return n
+}
+
+func saturatedAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func saturatedMul(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
@@ -0,0 +1,151 @@
+package colfer
+
+import "testing"
+
+const sizeTestSchema = `package p
+
+// Point has all fixed size types.
+type point struct {
+	b   bool
+	u8  uint8
+	u16 uint16
+	u32 uint32
+	u64 uint64
+	i32 int32
+	i64 int64
+	f32 float32
+	f64 float64
+	t   timestamp
+}
+
+// Pair has fixed size references.
+type pair struct {
+	a point
+	b point
+}
+
+// Polygon has a bounded list.
+//colf:listmax 4
+type polygon struct {
+	points []point
+}
+
+// Label has a text bound by its size limit.
+//colf:sizemax 100
+type label struct {
+	s text
+}
+
+// Node contains itself.
+type node struct {
+	next node
+}
+
+// Tree contains itself through a list.
+type tree struct {
+	children []tree
+}
+
+// Leaf references recursion.
+type leaf struct {
+	n  int32
+	up node
+}
+
+// Empty has no fields.
+type empty struct {
+}
+`
+
+func TestSizeBounds(t *testing.T) {
+	packages, err := ParseSources([]string{"p.colf"}, map[string][]byte{"p.colf": []byte(sizeTestSchema)})
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+
+	golden := map[string]SizeBounds{
+		"point":   {Min: 1, Max: 64, Fixed: true},
+		"pair":    {Min: 1, Max: 131, Fixed: true},
+		"polygon": {Min: 1, Max: 259},
+		"label":   {Min: 1, Max: 100},
+		"node":    {Min: 1, Max: 16 * 1024 * 1024, Unbounded: true},
+		"tree":    {Min: 1, Max: 16 * 1024 * 1024, Unbounded: true},
+		"leaf":    {Min: 1, Max: 16 * 1024 * 1024, Unbounded: true},
+		"empty":   {Min: 1, Max: 1, Fixed: true},
+	}
+	for _, s := range packages[0].Structs {
+		want, ok := golden[s.Name]
+		if !ok {
+			t.Errorf("no golden for struct %s", s)
+			continue
+		}
+
+		got, err := s.SizeBounds()
+		if err != nil {
+			t.Errorf("struct %s: got error %s", s, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("struct %s: got %+v, want %+v", s, got, want)
+		}
+
+		wantFixed := 0
+		if want.Fixed {
+			wantFixed = want.Max
+		}
+		if got := s.FixedSizeMax(); got != wantFixed {
+			t.Errorf("struct %s: got fixed size maximum %d, want %d", s, got, wantFixed)
+		}
+	}
+}
+
+func TestSizeBoundsLimits(t *testing.T) {
+	packages, err := ParseSources([]string{"p.colf"}, map[string][]byte{"p.colf": []byte(`package p
+
+// Packet has a package size limit.
+type packet struct {
+	payload binary
+	seq     uint32
+}
+
+// Point is fixed regardless of the limits.
+type point struct {
+	x float32
+	y float32
+}
+`)})
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+	p := packages[0]
+
+	golden := []struct {
+		sizeMax, listMax string
+		packet, point    SizeBounds
+	}{
+		{"", "", SizeBounds{Min: 1, Max: 16 * 1024 * 1024}, SizeBounds{Min: 1, Max: 11, Fixed: true}},
+		{"2048", "96", SizeBounds{Min: 1, Max: 2048}, SizeBounds{Min: 1, Max: 11, Fixed: true}},
+		// point exceeds the limit
+		{"8", "", SizeBounds{Min: 1, Max: 8}, SizeBounds{Min: 1, Max: 8}},
+	}
+	for _, g := range golden {
+		p.SizeMax, p.ListMax = g.sizeMax, g.listMax
+		for _, s := range p.Structs {
+			want := g.packet
+			if s.Name == "point" {
+				want = g.point
+			}
+			got, err := s.SizeBounds()
+			if err != nil {
+				t.Errorf("size maximum %q: struct %s: got error %s", g.sizeMax, s, err)
+			} else if got != want {
+				t.Errorf("size maximum %q: struct %s: got %+v, want %+v", g.sizeMax, s, got, want)
+			}
+		}
+	}
+
+	p.SizeMax = "1 << n"
+	if _, err := p.Structs[0].SizeBounds(); err == nil {
+		t.Error("no error for a non-constant size maximum")
+	}
+}
@@ -0,0 +1,234 @@
+// Package stream provides buffered I/O for the generated Go code.
+package stream
+
+import (
+	"errors"
+	"io"
+)
+
+// DefaultBufferSize is the initial buffer size in bytes.
+const DefaultBufferSize = 32 * 1024
+
+// Marshaler is implemented by the generated Go types.
+type Marshaler interface {
+	MarshalAppend([]byte) ([]byte, error)
+}
+
+// Unmarshaler is implemented by the generated Go types.
+type Unmarshaler interface {
+	Unmarshal([]byte) (int, error)
+}
+
+// Decoder reads consecutive serials from an input stream. The buffer grows
+// as needed. Growth is bounded by ColferSizeMax of the generated code, as
+// Unmarshal fails once the pending data exceeds the limit.
+//
+// The buffer is reused for consecutive reads. Types generated with
+// zero-copy (colf -z) must not retain their text and binaries beyond the
+// next call on the Decoder.
+type Decoder struct {
+	r io.Reader
+
+	// buf is the read buffer.
+	buf []byte
+
+	// offset is the index of the first data byte in buf.
+	offset int
+
+	// i is the index of the data end (exclusive) in buf.
+	i int
+
+	// err is the read error, if any, pending the buffered data.
+	err error
+}
+
+// NewDecoder returns a new Decoder for r.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderSize(r, DefaultBufferSize)
+}
+
+// NewDecoderSize returns a new Decoder for r with an initial buffer size.
+func NewDecoderSize(r io.Reader, size int) *Decoder {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Decoder{r: r, buf: make([]byte, size)}
+}
+
+// Decode reads the next serial into v. The error is io.EOF when the stream
+// ends before any data, and io.ErrUnexpectedEOF when the stream ends with a
+// partial serial. Unmarshal errors are passed as is.
+func (d *Decoder) Decode(v Unmarshaler) error {
+	for {
+		if d.offset < d.i {
+			n, err := v.Unmarshal(d.buf[d.offset:d.i])
+			switch err {
+			case nil:
+				d.offset += n
+				return nil
+
+			default:
+				return err
+
+			case io.EOF:
+			}
+		}
+		// not enough data
+
+		if err := d.fill(); err != nil {
+			if err == io.EOF && d.offset < d.i {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+}
+
+// Discard skips the next n bytes of the stream.
+func (d *Decoder) Discard(n int) error {
+	if n < 0 {
+		return errors.New("colfer/stream: negative discard count")
+	}
+	for {
+		pending := d.i - d.offset
+		if n <= pending {
+			d.offset += n
+			return nil
+		}
+
+		n -= pending
+		d.offset, d.i = 0, 0
+		if err := d.fill(); err != nil {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+}
+
+// fill reads more data into the buffer.
+func (d *Decoder) fill() error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if d.offset == d.i {
+		d.offset, d.i = 0, 0
+	} else if d.i >= len(d.buf) {
+		if d.offset == 0 {
+			// grow
+			bigger := make([]byte, len(d.buf)*4)
+			copy(bigger, d.buf)
+			d.buf = bigger
+		} else {
+			// move data to start of buffer
+			copy(d.buf, d.buf[d.offset:d.i])
+			d.i -= d.offset
+			d.offset = 0
+		}
+	}
+
+	for tries := 0; tries < 100; tries++ {
+		n, err := d.r.Read(d.buf[d.i:])
+		d.i += n
+		if n != 0 {
+			// report any error after the data is consumed
+			d.err = err
+			return nil
+		}
+		if err != nil {
+			d.err = err
+			return err
+		}
+	}
+	return io.ErrNoProgress
+}
+
+// Encoder writes consecutive serials to an output stream. Data is buffered
+// until Flush, or until the buffer is full.
+type Encoder struct {
+	w io.Writer
+
+	// buf has the pending data.
+	buf []byte
+
+	// size is the flush threshold.
+	size int
+
+	// err is the first write error, if any.
+	err error
+}
+
+// NewEncoder returns a new Encoder for w.
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderSize(w, DefaultBufferSize)
+}
+
+// NewEncoderSize returns a new Encoder for w with a buffer size. Serials
+// larger than the buffer size grow the buffer.
+func NewEncoderSize(w io.Writer, size int) *Encoder {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Encoder{w: w, buf: make([]byte, 0, size), size: size}
+}
+
+// Encode appends the serial of v to the buffer, with a single traversal of
+// the data. Errors from the underlying writer are sticky, like bufio.Writer.
+func (e *Encoder) Encode(v Marshaler) error {
+	if e.err != nil {
+		return e.err
+	}
+	buf, err := v.MarshalAppend(e.buf)
+	if err != nil {
+		return err
+	}
+	e.buf = buf
+
+	if len(e.buf) >= e.size {
+		return e.Flush()
+	}
+	return nil
+}
+
+// Write appends raw data to the buffer conform io.Writer.
+func (e *Encoder) Write(p []byte) (n int, err error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	e.buf = append(e.buf, p...)
+
+	if len(e.buf) >= e.size {
+		if err := e.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Buffered returns the number of bytes pending.
+func (e *Encoder) Buffered() int {
+	return len(e.buf)
+}
+
+// Flush writes any pending data to the underlying writer.
+func (e *Encoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	if len(e.buf) == 0 {
+		return nil
+	}
+
+	n, err := e.w.Write(e.buf)
+	if err == nil && n < len(e.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		e.err = err
+		return err
+	}
+	e.buf = e.buf[:0]
+	return nil
+}
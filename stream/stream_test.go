@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pascaldekloe/colfer/go/gen"
+)
+
+func newSamples() []*gen.O {
+	return []*gen.O{
+		{},
+		{B: true, U32: 42},
+		{S: strings.Repeat("grow ", 20000)},
+		{Os: []*gen.O{{S: "nested"}, {A: []byte{1, 2, 3}}}},
+		{Ss: []string{"", "a", "b"}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderSize(&buf, 64)
+	for _, o := range newSamples() {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal("encode error:", err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal("flush error:", err)
+	}
+	if n := enc.Buffered(); n != 0 {
+		t.Errorf("got %d bytes buffered after flush", n)
+	}
+
+	readers := map[string]func() io.Reader{
+		"plain":    func() io.Reader { return bytes.NewReader(buf.Bytes()) },
+		"one-byte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(buf.Bytes())) },
+		"data-EOF": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(buf.Bytes())) },
+	}
+	for name, newReader := range readers {
+		dec := NewDecoderSize(newReader(), 16)
+		for i, want := range newSamples() {
+			got := new(gen.O)
+			if err := dec.Decode(got); err != nil {
+				t.Fatalf("%s: sample %d: decode error: %s", name, i, err)
+			}
+			if got.S != want.S || got.U32 != want.U32 || len(got.Os) != len(want.Os) || len(got.Ss) != len(want.Ss) {
+				t.Errorf("%s: sample %d: got %+v, want %+v", name, i, got, want)
+			}
+		}
+		if err := dec.Decode(new(gen.O)); err != io.EOF {
+			t.Errorf("%s: got error %v at end of stream, want io.EOF", name, err)
+		}
+	}
+}
+
+func TestDecodeUnexpectedEOF(t *testing.T) {
+	data, err := (&gen.O{S: "partial"}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(bytes.NewReader(data[:len(data)-1]))
+	if err := dec.Decode(new(gen.O)); err != io.ErrUnexpectedEOF {
+		t.Errorf("got error %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestDecodeSizeMax(t *testing.T) {
+	orig := gen.ColferSizeMax
+	defer func() {
+		gen.ColferSizeMax = orig
+	}()
+
+	data, err := (&gen.O{S: strings.Repeat("X", 1000)}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.ColferSizeMax = 100
+
+	dec := NewDecoderSize(iotest.OneByteReader(bytes.NewReader(data)), 8)
+	if err := dec.Decode(new(gen.O)); err == nil {
+		t.Fatal("no error for serial size beyond ColferSizeMax")
+	} else if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error %T: %s, want gen.ColferMax", err, err)
+	}
+	if len(dec.buf) > 4*gen.ColferSizeMax {
+		t.Errorf("buffer grew to %d bytes with ColferSizeMax %d", len(dec.buf), gen.ColferSizeMax)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	skip, err := (&gen.O{S: strings.Repeat("skip", 100)}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := (&gen.O{U64: 99}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoderSize(iotest.HalfReader(bytes.NewReader(append(skip, next...))), 16)
+	if err := dec.Discard(len(skip)); err != nil {
+		t.Fatal("discard error:", err)
+	}
+	got := new(gen.O)
+	if err := dec.Decode(got); err != nil {
+		t.Fatal("decode error:", err)
+	}
+	if got.U64 != 99 {
+		t.Errorf("got U64 %d after discard, want 99", got.U64)
+	}
+
+	if err := dec.Discard(1); err != io.ErrUnexpectedEOF {
+		t.Errorf("got error %v for discard beyond the end, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
+func TestEncodeStickyError(t *testing.T) {
+	enc := NewEncoder(failWriter{})
+	if err := enc.Encode(&gen.O{B: true}); err != nil {
+		t.Fatal("encode error before flush:", err)
+	}
+	if err := enc.Flush(); err != io.ErrClosedPipe {
+		t.Fatalf("got flush error %v, want io.ErrClosedPipe", err)
+	}
+	if err := enc.Encode(&gen.O{}); err != io.ErrClosedPipe {
+		t.Errorf("got encode error %v after failure, want io.ErrClosedPipe", err)
+	}
+}